@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS 缓存时间，遇到未知 kid 时会提前刷新
+const jwksCacheTTL = 10 * time.Minute
+
+// 获取 JWKS 的超时时间
+const jwksFetchTimeout = 10 * time.Second
+
+// 远程传输（http、sse、ws）的认证：配置了 MYSQL_AUTH_TOKENS 或 MYSQL_OAUTH_JWKS_URL 时，
+// 每个请求都必须携带 Authorization: Bearer <token>。
+// 浏览器无法为 WebSocket 设置请求头，因此 /ws 也接受 access_token 查询参数。
+func (s *MCPServer) requireAuth(next http.Handler) http.Handler {
+	if len(s.config.AuthTokens) == 0 && s.config.OAuthJWKSURL == "" {
+		return next
+	}
+	keys := &jwksCache{url: s.config.OAuthJWKSURL}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		if err := s.authenticate(r.Context(), keys, token); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	if r.URL.Path == "/ws" {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
+// 先匹配静态 token，再按 JWT 校验签名和声明
+func (s *MCPServer) authenticate(ctx context.Context, keys *jwksCache, token string) error {
+	for _, allowed := range s.config.AuthTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return nil
+		}
+	}
+	if keys.url == "" {
+		return errors.New("unknown token")
+	}
+	return s.verifyJWT(ctx, keys, token)
+}
+
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+}
+
+// 校验 RS256 签名的 JWT 访问令牌
+func (s *MCPServer) verifyJWT(ctx context.Context, keys *jwksCache, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+	key, err := keys.key(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	if claims.ExpiresAt == 0 || now >= claims.ExpiresAt {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return errors.New("token not yet valid")
+	}
+	if s.config.OAuthIssuer != "" && claims.Issuer != s.config.OAuthIssuer {
+		return errors.New("unexpected issuer")
+	}
+	if s.config.OAuthAudience != "" && !audienceContains(claims.Audience, s.config.OAuthAudience) {
+		return errors.New("unexpected audience")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// aud 可以是字符串或字符串数组
+func audienceContains(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 授权服务器公钥（JWKS）的缓存
+type jwksCache struct {
+	url       string
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (c *jwksCache) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[kid]; ok && time.Since(c.fetchedAt) < jwksCacheTTL {
+		return key, nil
+	}
+	// 未知 kid 可能是密钥轮换，刷新一次；刷新间隔至少一分钟，避免被伪造的 kid 放大请求
+	if time.Since(c.fetchedAt) > time.Minute {
+		if err := c.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (c *jwksCache) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("获取 JWKS 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("获取 JWKS 失败: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("解析 JWKS 失败: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+// 校验浏览器请求的 Origin，防止 DNS 重绑定：恶意网页把自己的域名解析到 127.0.0.1 后，
+// Host 与 Origin 同源，只比较两者无法识别，因此未配置 MYSQL_ALLOWED_ORIGINS 时只允许本机来源。
+// 没有 Origin 头的请求来自非浏览器客户端，直接放行。
+func (s *MCPServer) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(s.config.AllowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && isLoopbackHost(u.Hostname())
+	}
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// 拒绝 Origin 不在允许范围内的请求
+func (s *MCPServer) requireOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.checkOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// 监听非本机地址且未配置认证时，任何能访问该端口的人都可以查询数据库
+func (s *MCPServer) warnIfExposed(addr string) {
+	if len(s.config.AuthTokens) > 0 || s.config.OAuthJWKSURL != "" {
+		return
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err == nil && host != "" && isLoopbackHost(host) {
+		return
+	}
+	log.Printf("警告: 监听地址 %s 不是本机地址且未配置认证（MYSQL_AUTH_TOKENS 或 MYSQL_OAUTH_JWKS_URL），网络上的任何人都可以访问数据库", addr)
+}
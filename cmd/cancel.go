@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KILL QUERY 的超时时间
+const killQueryTimeout = 5 * time.Second
+
+// 跟踪同一连接（会话）上正在执行的请求，按请求 ID 保存取消函数
+type inflightRequests struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{cancels: make(map[string]context.CancelFunc)}
+}
+
+// JSON 数字解码为 float64，统一格式化为字符串作为键
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%v", id)
+}
+
+// 为请求创建可取消的 context，返回的 done 在请求结束时调用
+func (r *inflightRequests) start(ctx context.Context, id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(id)
+
+	r.mu.Lock()
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// 取消指定请求，请求不存在（已完成或未知）时返回 false
+func (r *inflightRequests) cancel(id interface{}) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[requestKey(id)]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// 处理 notifications/cancelled
+func (s *MCPServer) handleCancelled(ctx context.Context, req MCPRequest) {
+	var params struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.RequestID == nil {
+		return
+	}
+	tracker := inflightFrom(ctx)
+	if tracker == nil {
+		return
+	}
+	if tracker.cancel(params.RequestID) {
+		log.Printf("请求 %v 已取消: %s", params.RequestID, params.Reason)
+	}
+}
+
+// 终止指定连接上正在执行的语句
+func (s *MCPServer) killQuery(connID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), killQueryTimeout)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, "KILL QUERY "+strconv.FormatInt(connID, 10)); err != nil {
+		log.Printf("KILL QUERY %d 失败: %v", connID, err)
+	}
+}
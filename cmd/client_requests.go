@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 等待客户端响应服务端请求（如 elicitation）的最长时间
+const clientRequestTimeout = 10 * time.Minute
+
+// 服务端发给客户端的请求
+type outgoingRequest struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	ID      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// 跟踪同一连接上服务端发出、等待客户端响应的请求，以及客户端在 initialize 中声明的能力
+type clientRequests struct {
+	mu           sync.Mutex
+	nextID       int64
+	pending      map[string]chan MCPRequest
+	capabilities map[string]interface{}
+}
+
+func newClientRequests() *clientRequests {
+	return &clientRequests{pending: make(map[string]chan MCPRequest)}
+}
+
+func (c *clientRequests) setCapabilities(capabilities map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capabilities = capabilities
+}
+
+// 客户端是否声明了指定能力，如 elicitation、sampling
+func (c *clientRequests) supports(capability string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.capabilities[capability]
+	return ok
+}
+
+// 把客户端的响应交给等待中的请求，未知 ID 返回 false
+func (c *clientRequests) deliver(resp MCPRequest) bool {
+	c.mu.Lock()
+	ch, ok := c.pending[requestKey(resp.ID)]
+	delete(c.pending, requestKey(resp.ID))
+	c.mu.Unlock()
+	if ok {
+		ch <- resp
+	}
+	return ok
+}
+
+// 向客户端发送请求并等待响应，返回响应的 result
+func (s *MCPServer) requestClient(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c := clientRequestsFrom(ctx)
+	notify := notifierFrom(ctx)
+	if c == nil || notify == nil {
+		return nil, fmt.Errorf("当前传输不支持服务端请求")
+	}
+
+	ch := make(chan MCPRequest, 1)
+	c.mu.Lock()
+	c.nextID++
+	id := fmt.Sprintf("server-%d", c.nextID)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	notify(outgoingRequest{Jsonrpc: "2.0", ID: id, Method: method, Params: params})
+
+	ctx, cancel := context.WithTimeout(ctx, clientRequestTimeout)
+	defer cancel()
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("客户端返回错误: %s", resp.Error.Message)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待客户端响应 %s 失败: %v", method, ctx.Err())
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// completion/complete 最多返回的候选数（MCP 规定不超过 100）
+const maxCompletionValues = 100
+
+// 为提示模板和资源模板的参数补全表名和列名：
+// table_name/table 补全当前数据库中允许访问的表，column 及 *_column 补全
+// context.arguments 中已填写的表的列，database 补全当前数据库名。
+func (s *MCPServer) complete(ctx context.Context, req MCPRequest) MCPResponse {
+	var params struct {
+		Ref struct {
+			Type string `json:"type"`
+		} `json:"ref"`
+		Argument struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"argument"`
+		Context struct {
+			Arguments map[string]string `json:"arguments"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Argument.Name == "" {
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	var values []string
+	var err error
+	name, prefix := params.Argument.Name, params.Argument.Value
+	switch {
+	case name == "database":
+		if strings.HasPrefix(s.config.Database, prefix) {
+			values = []string{s.config.Database}
+		}
+	case name == "table_name" || name == "table":
+		values, err = s.completeTables(ctx, prefix)
+	case name == "column" || strings.HasSuffix(name, "_column"):
+		tableName := params.Context.Arguments["table_name"]
+		if tableName == "" {
+			tableName = params.Context.Arguments["table"]
+		}
+		if tableName != "" && s.isTableAllowed(tableName) {
+			values, err = s.completeColumns(ctx, tableName, prefix)
+		}
+	}
+	if err != nil {
+		return s.queryErrorResponse(req.ID, err)
+	}
+
+	total := len(values)
+	if total > maxCompletionValues {
+		values = values[:maxCompletionValues]
+	}
+	if values == nil {
+		values = []string{}
+	}
+
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"completion": map[string]interface{}{
+				"values":  values,
+				"total":   total,
+				"hasMore": total > len(values),
+			},
+		},
+	}
+}
+
+func (s *MCPServer) completeTables(ctx context.Context, prefix string) ([]string, error) {
+	if !s.config.AllowInformationSchema {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME LIKE ?
+		ORDER BY TABLE_NAME
+	`, s.currentDatabase(ctx), escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			continue
+		}
+		if s.isTableAllowed(tableName) {
+			tables = append(tables, tableName)
+		}
+	}
+	return tables, rows.Err()
+}
+
+func (s *MCPServer) completeColumns(ctx context.Context, tableName, prefix string) ([]string, error) {
+	if !s.config.AllowInformationSchema {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME LIKE ?
+		ORDER BY ORDINAL_POSITION
+	`, s.currentDatabase(ctx), tableName, escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			continue
+		}
+		columns = append(columns, columnName)
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey int
+
+const (
+	toolNameKey contextKey = iota
+	inflightKey
+	notifierKey
+	progressTokenKey
+	subscriptionsKey
+	logLevelKey
+	clientRequestsKey
+	sessionLimiterKey
+	callTraceKey
+	queryTimeoutKey
+	sessionDatabaseKey
+	connSlotKey
+)
+
+// 在 context 中记录当前调用的工具名
+func withToolName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, toolNameKey, name)
+}
+
+func toolNameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey).(string)
+	return name
+}
+
+// 在 context 中记录当前连接的在途请求表
+func withInflight(ctx context.Context, r *inflightRequests) context.Context {
+	return context.WithValue(ctx, inflightKey, r)
+}
+
+func inflightFrom(ctx context.Context) *inflightRequests {
+	r, _ := ctx.Value(inflightKey).(*inflightRequests)
+	return r
+}
+
+// 向当前连接发送通知或服务端请求的函数，由各传输层提供
+type notifier func(msg interface{})
+
+func withNotifier(ctx context.Context, n notifier) context.Context {
+	return context.WithValue(ctx, notifierKey, n)
+}
+
+func notifierFrom(ctx context.Context) notifier {
+	n, _ := ctx.Value(notifierKey).(notifier)
+	return n
+}
+
+// 在 context 中记录客户端请求的 progressToken
+func withProgressToken(ctx context.Context, token interface{}) context.Context {
+	return context.WithValue(ctx, progressTokenKey, token)
+}
+
+func progressTokenFrom(ctx context.Context) interface{} {
+	return ctx.Value(progressTokenKey)
+}
+
+// 在 context 中记录当前连接的资源订阅
+func withSubscriptions(ctx context.Context, subs *resourceSubscriptions) context.Context {
+	return context.WithValue(ctx, subscriptionsKey, subs)
+}
+
+func subscriptionsFrom(ctx context.Context) *resourceSubscriptions {
+	subs, _ := ctx.Value(subscriptionsKey).(*resourceSubscriptions)
+	return subs
+}
+
+// 在 context 中记录当前连接的客户端日志级别
+func withLogLevel(ctx context.Context, l *clientLogLevel) context.Context {
+	return context.WithValue(ctx, logLevelKey, l)
+}
+
+func logLevelFrom(ctx context.Context) *clientLogLevel {
+	l, _ := ctx.Value(logLevelKey).(*clientLogLevel)
+	return l
+}
+
+// 在 context 中记录当前连接上服务端发给客户端的请求
+func withClientRequests(ctx context.Context, c *clientRequests) context.Context {
+	return context.WithValue(ctx, clientRequestsKey, c)
+}
+
+func clientRequestsFrom(ctx context.Context) *clientRequests {
+	c, _ := ctx.Value(clientRequestsKey).(*clientRequests)
+	return c
+}
+
+// 在 context 中记录当前会话的调用限制
+func withSessionLimiter(ctx context.Context, l *sessionLimiter) context.Context {
+	return context.WithValue(ctx, sessionLimiterKey, l)
+}
+
+func sessionLimiterFrom(ctx context.Context) *sessionLimiter {
+	l, _ := ctx.Value(sessionLimiterKey).(*sessionLimiter)
+	return l
+}
+
+// 在 context 中记录当前工具调用执行的 SQL
+func withCallTrace(ctx context.Context, t *callTrace) context.Context {
+	return context.WithValue(ctx, callTraceKey, t)
+}
+
+func callTraceFrom(ctx context.Context) *callTrace {
+	t, _ := ctx.Value(callTraceKey).(*callTrace)
+	return t
+}
+
+// 在 context 中记录工具调用通过 timeout_ms 指定的查询超时
+func withQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey, d)
+}
+
+func queryTimeoutFrom(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(queryTimeoutKey).(time.Duration)
+	return d
+}
+
+// 在 context 中记录当前连接通过 use_database 选择的数据库
+func withSessionDatabase(ctx context.Context, d *sessionDatabase) context.Context {
+	return context.WithValue(ctx, sessionDatabaseKey, d)
+}
+
+func sessionDatabaseFrom(ctx context.Context) *sessionDatabase {
+	d, _ := ctx.Value(sessionDatabaseKey).(*sessionDatabase)
+	return d
+}
+
+// 在 context 中记录当前工具调用占用的全局连接名额
+func withConnSlot(ctx context.Context, slot *connSlot) context.Context {
+	return context.WithValue(ctx, connSlotKey, slot)
+}
+
+func connSlotFrom(ctx context.Context) *connSlot {
+	slot, _ := ctx.Value(connSlotKey).(*connSlot)
+	return slot
+}
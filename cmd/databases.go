@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 系统库只有显式列在 MYSQL_ALLOWED_DATABASES 中时才允许访问
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// MYSQL_DATABASE 总是允许；其他数据库需要在 MYSQL_ALLOWED_DATABASES 中，或配置了 * 且不是系统库
+func (s *MCPServer) isDatabaseAllowed(database string) bool {
+	if strings.EqualFold(database, s.config.Database) {
+		return true
+	}
+	for _, allowed := range s.config.AllowedDatabases {
+		if strings.EqualFold(allowed, database) {
+			return true
+		}
+		if allowed == "*" && !systemDatabases[strings.ToLower(database)] {
+			return true
+		}
+	}
+	return false
+}
+
+// 连接（会话）通过 use_database 选择的默认数据库，为空表示 MYSQL_DATABASE
+type sessionDatabase struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (d *sessionDatabase) get() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.name
+}
+
+func (d *sessionDatabase) set(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.name = name
+}
+
+// 当前连接的默认数据库
+func (s *MCPServer) currentDatabase(ctx context.Context) string {
+	if d := sessionDatabaseFrom(ctx); d != nil {
+		if name := d.get(); name != "" {
+			return name
+		}
+	}
+	return s.config.Database
+}
+
+// 读取工具的 database 参数，未传时使用当前连接的默认数据库
+func (s *MCPServer) databaseArg(ctx context.Context, args map[string]interface{}) (string, error) {
+	database, _ := args["database"].(string)
+	if database == "" {
+		return s.currentDatabase(ctx), nil
+	}
+	if err := validateIdentifier(database); err != nil {
+		return "", err
+	}
+	if !s.isDatabaseAllowed(database) {
+		return "", &rejectedError{fmt.Sprintf("不允许访问数据库 '%s'", database)}
+	}
+	return database, nil
+}
+
+// 生成 `db`.`table` 形式的表名
+func qualifiedTable(database, tableName string) string {
+	return quoteIdentifier(database) + "." + quoteIdentifier(tableName)
+}
+
+func (s *MCPServer) listDatabases(ctx context.Context, id interface{}) MCPResponse {
+	rows, err := s.db.QueryContext(ctx, "SHOW DATABASES")
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var database string
+		if err := rows.Scan(&database); err != nil {
+			continue
+		}
+		if !s.isDatabaseAllowed(database) {
+			continue
+		}
+		if database == s.currentDatabase(ctx) {
+			database += "（当前）"
+		}
+		databases = append(databases, database)
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	return s.textResponse(id, fmt.Sprintf("可访问的数据库: %s\n", strings.Join(databases, ", ")))
+}
+
+func (s *MCPServer) useDatabase(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	d := sessionDatabaseFrom(ctx)
+	if d == nil {
+		return s.errorResponse(id, "当前传输不支持切换数据库")
+	}
+	database, _ := args["database"].(string)
+	if err := validateIdentifier(database); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isDatabaseAllowed(database) {
+		return s.queryErrorResponse(id, &rejectedError{fmt.Sprintf("不允许访问数据库 '%s'", database)})
+	}
+	// 不在连接池的连接上执行 USE，只确认数据库存在
+	rows, err := s.db.QueryContext(ctx, "SHOW TABLES FROM "+quoteIdentifier(database))
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	rows.Close()
+
+	if strings.EqualFold(database, s.config.Database) {
+		database = s.config.Database
+		d.set("")
+	} else {
+		d.set(database)
+	}
+	return s.textResponse(id, fmt.Sprintf("当前数据库已切换为 '%s'\n", database))
+}
+
+// 在从连接池取出的连接上切换到当前连接的默认数据库，返回的函数把连接恢复到 MYSQL_DATABASE，
+// 恢复失败时让连接池丢弃该连接，避免其他会话在错误的数据库上执行
+func (s *MCPServer) useSessionDatabase(ctx context.Context, conn *sql.Conn) (func(), error) {
+	database := s.currentDatabase(ctx)
+	if database == s.config.Database {
+		return func() {}, nil
+	}
+	if _, err := conn.ExecContext(ctx, "USE "+quoteIdentifier(database)); err != nil {
+		return nil, fmt.Errorf("切换数据库错误: %w", err)
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "USE "+quoteIdentifier(s.config.Database)); err != nil {
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}, nil
+}
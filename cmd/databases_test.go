@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListDatabases(t *testing.T) {
+	runToolCases(t, "list_databases", []toolCase{
+		{
+			name:   "lists allowed databases and marks the current one",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"*"} },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW DATABASES")).
+					WillReturnRows(sqlmock.NewRows([]string{"Database"}).
+						AddRow("information_schema").
+						AddRow("shop").
+						AddRow("testdb"))
+			},
+			want: []string{"可访问的数据库: shop, testdb（当前）"},
+		},
+	})
+}
+
+func TestUseDatabase(t *testing.T) {
+	session := &sessionDatabase{}
+	withSession := func(ctx context.Context) context.Context { return withSessionDatabase(ctx, session) }
+	runToolCases(t, "use_database", []toolCase{
+		{
+			name:   "switches the session database",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"shop"} },
+			ctx:    withSession,
+			args:   map[string]interface{}{"database": "shop"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW TABLES FROM `shop`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Tables_in_shop"}))
+			},
+			want: []string{"当前数据库已切换为 'shop'"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if got := session.get(); got != "shop" {
+					t.Errorf("会话数据库 = %q，期望 shop", got)
+				}
+			},
+		},
+		{
+			name:    "rejects database outside allowlist",
+			ctx:     withSession,
+			args:    map[string]interface{}{"database": "mysql"},
+			wantErr: "不允许访问数据库 'mysql'",
+		},
+		{
+			name:    "requires a session",
+			args:    map[string]interface{}{"database": "testdb"},
+			wantErr: "当前传输不支持切换数据库",
+		},
+	})
+}
+
+// use_database 之后的查询在取出的连接上切换数据库，结束后恢复 MYSQL_DATABASE
+func TestUseDatabaseAppliesToQueries(t *testing.T) {
+	cfg := testConfig()
+	cfg.AllowedDatabases = []string{"shop"}
+	s, mock := newTestServer(t, cfg)
+	ctx := withSessionDatabase(context.Background(), &sessionDatabase{name: "shop"})
+
+	expectConnectionID(mock)
+	mock.ExpectExec(regexp.QuoteMeta("USE `shop`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM orders")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta("USE `testdb`")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	resp := invokeTool(t, s, ctx, "execute_query", map[string]interface{}{"query": "SELECT COUNT(*) FROM orders"})
+	if resp.Error != nil {
+		t.Fatalf("意外的错误: %s", resp.Error.Message)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
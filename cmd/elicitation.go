@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// 通过 elicitation 请用户确认危险操作。客户端不支持 elicitation 或当前请求无法向客户端
+// 发送消息时无法询问用户，必须由调用方显式传入 confirm: true，否则拒绝执行。
+func (s *MCPServer) confirmWithUser(ctx context.Context, args map[string]interface{}, message string) (bool, error) {
+	c := clientRequestsFrom(ctx)
+	if c == nil || !c.supports("elicitation") || notifierFrom(ctx) == nil {
+		if confirm, _ := args["confirm"].(bool); confirm {
+			return true, nil
+		}
+		return false, &argumentError{Path: "confirm", Reason: "客户端不支持 elicitation，无法请求用户确认，需要传入 confirm: true"}
+	}
+
+	result, err := s.requestClient(ctx, "elicitation/create", map[string]interface{}{
+		"message": message,
+		"requestedSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"confirm": map[string]interface{}{
+					"type":        "boolean",
+					"title":       "确认执行",
+					"description": "勾选以确认执行该操作",
+				},
+			},
+			"required": []string{"confirm"},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Action  string `json:"action"`
+		Content struct {
+			Confirm bool `json:"confirm"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return false, fmt.Errorf("解析 elicitation 响应失败: %v", err)
+	}
+	return response.Action == "accept" && response.Content.Confirm, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mermaid 实体名和属性名只允许字母、数字、下划线和连字符
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+func mermaidName(name string) string {
+	return mermaidUnsafe.ReplaceAllString(name, "_")
+}
+
+type erColumn struct {
+	Name string
+	Type string
+	Keys []string
+}
+
+// 生成 Mermaid erDiagram；指定表时只包含该表和通过外键直接相连的表
+func (s *MCPServer) generateERDiagram(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, _ := args["table_name"].(string)
+	if tableName != "" {
+		if err := validateIdentifier(tableName); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		if !s.isTableAllowed(tableName) {
+			return s.tableNotAllowed(id, tableName)
+		}
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	fks, err := s.loadForeignKeys(ctx, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	var scope map[string]bool
+	if tableName != "" {
+		scope = map[string]bool{tableName: true}
+		for _, fk := range fks {
+			scope[fk.Table] = true
+			scope[fk.ReferencedTable] = true
+		}
+	}
+	fkColumns := make(map[string]bool)
+	for _, fk := range fks {
+		for _, col := range fk.Columns {
+			fkColumns[fk.Table+"."+col] = true
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_KEY
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var tables []string
+	columns := make(map[string][]erColumn)
+	truncated := false
+	for rows.Next() {
+		var table, column, dataType, key string
+		if err := rows.Scan(&table, &column, &dataType, &key); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(table) || (scope != nil && !scope[table]) {
+			continue
+		}
+		if _, ok := columns[table]; !ok {
+			if len(tables) >= maxDumpSchemaTables {
+				truncated = true
+				continue
+			}
+			tables = append(tables, table)
+		}
+		col := erColumn{Name: column, Type: dataType}
+		switch key {
+		case "PRI":
+			col.Keys = append(col.Keys, "PK")
+		case "UNI":
+			col.Keys = append(col.Keys, "UK")
+		}
+		if fkColumns[table+"."+column] {
+			col.Keys = append(col.Keys, "FK")
+		}
+		columns[table] = append(columns[table], col)
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if len(tables) == 0 {
+		return s.textResponse(id, "没有找到表\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\nerDiagram\n")
+	for _, table := range tables {
+		sb.WriteString(fmt.Sprintf("    %s {\n", mermaidName(table)))
+		for _, col := range columns[table] {
+			line := fmt.Sprintf("        %s %s", mermaidName(col.Type), mermaidName(col.Name))
+			if len(col.Keys) > 0 {
+				line += " " + strings.Join(col.Keys, ", ")
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	// 子表多对一引用父表
+	sort.SliceStable(fks, func(i, j int) bool { return fks[i].Table < fks[j].Table })
+	for _, fk := range fks {
+		if _, ok := columns[fk.Table]; !ok {
+			continue
+		}
+		if _, ok := columns[fk.ReferencedTable]; !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("    %s }o--|| %s : %q\n",
+			mermaidName(fk.Table), mermaidName(fk.ReferencedTable), strings.Join(fk.Columns, ", ")))
+	}
+	sb.WriteString("```\n")
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n（表数量超过上限 %d，其余表未包含）\n", maxDumpSchemaTables))
+	}
+	return s.textResponse(id, sb.String())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// loadForeignKeys 返回的列
+var foreignKeyColumns = []string{"TABLE_NAME", "CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME",
+	"REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}
+
+func TestGenerateERDiagram(t *testing.T) {
+	runToolCases(t, "generate_er_diagram", []toolCase{
+		{
+			name: "draws tables and relations",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(foreignKeyColumns).
+						AddRow("orders", "fk_orders_user", "user_id", "users", "id", "RESTRICT", "CASCADE"))
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "COLUMN_KEY"}).
+						AddRow("orders", "id", "int", "PRI").
+						AddRow("orders", "user_id", "int", "MUL").
+						AddRow("users", "id", "int", "PRI").
+						AddRow("users", "email", "varchar", "UNI"))
+			},
+			want: []string{
+				"erDiagram",
+				"    orders {\n        int id PK\n        int user_id FK\n    }",
+				"varchar email UK",
+				`orders }o--|| users : "user_id"`,
+			},
+		},
+		{
+			name: "no tables",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").
+					WillReturnRows(sqlmock.NewRows(foreignKeyColumns))
+				mock.ExpectQuery("FROM information_schema.COLUMNS").
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "COLUMN_KEY"}))
+			},
+			want: []string{"没有找到表"},
+		},
+	})
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// JSON-RPC 错误码。-32602/-32603 为 JSON-RPC 标准错误，其余为服务端自定义错误
+// （resourceNotFoundCode、rateLimitedCode 分别见 resources.go、governor.go）。
+const (
+	invalidParamsCode    = -32602
+	internalErrorCode    = -32603
+	queryRejectedCode    = -32010
+	queryTimeoutCode     = -32011
+	connectionLostCode   = -32012
+	mysqlErrorCode       = -32013
+	queryCancelledCode   = -32014
+	mysqlQueryTimeout    = 3024 // ER_QUERY_TIMEOUT，超过 MAX_EXECUTION_TIME
+	mysqlLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	mysqlInterrupted     = 1317 // ER_QUERY_INTERRUPTED，被 KILL QUERY 终止
+	mysqlParseError      = 1064 // ER_PARSE_ERROR，旧版本不支持的语法也返回该错误
+)
+
+var (
+	errInvalidIdentifier     = errors.New("非法的标识符")
+	errConnectionUnavailable = errors.New("MySQL 连接不可用")
+)
+
+// 被只读检查等规则拒绝执行的语句
+type rejectedError struct {
+	msg string
+}
+
+func (e *rejectedError) Error() string {
+	return e.msg
+}
+
+// 执行失败的语句，错误响应的 data 中会带上该语句
+type statementError struct {
+	Statement string
+	Err       error
+}
+
+func (e *statementError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *statementError) Unwrap() error {
+	return e.Err
+}
+
+// 把错误归类为 JSON-RPC 错误码，并整理出 MySQL 错误号、SQLSTATE 和出错语句
+func classifyError(err error) (int, map[string]interface{}) {
+	data := make(map[string]interface{})
+	var stmtErr *statementError
+	if errors.As(err, &stmtErr) {
+		data["statement"] = redactSQL(stmtErr.Statement)
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		data["mysql_errno"] = mysqlErr.Number
+		data["sqlstate"] = string(mysqlErr.SQLState[:])
+	}
+	var argErr *argumentError
+	var rejErr *rejectedError
+	var netErr net.Error
+
+	code := internalErrorCode
+	switch {
+	case errors.As(err, &argErr):
+		code = invalidParamsCode
+		data["path"] = argErr.Path
+	case errors.Is(err, errInvalidIdentifier):
+		code = invalidParamsCode
+	case errors.As(err, &rejErr):
+		code = queryRejectedCode
+	case errors.Is(err, context.DeadlineExceeded),
+		mysqlErr != nil && (mysqlErr.Number == mysqlQueryTimeout || mysqlErr.Number == mysqlLockWaitTimeout):
+		code = queryTimeoutCode
+	case errors.Is(err, context.Canceled), mysqlErr != nil && mysqlErr.Number == mysqlInterrupted:
+		code = queryCancelledCode
+	case errors.Is(err, errConnectionUnavailable), errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, mysql.ErrInvalidConn), errors.As(err, &netErr):
+		code = connectionLostCode
+	case mysqlErr != nil:
+		code = mysqlErrorCode
+	}
+	if len(data) == 0 {
+		data = nil
+	}
+	return code, data
+}
+
+// 查询超过超时时间。超时后 KILL QUERY 返回的可能是中断错误，这里统一按超时处理
+type timeoutError struct {
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("查询超时（超过 %s）: %v", e.Timeout, e.Err)
+}
+
+func (e *timeoutError) Unwrap() []error {
+	return []error{context.DeadlineExceeded, e.Err}
+}
+
+// 查询因超时失败时改写为 timeoutError，其余错误原样返回
+func queryTimeoutError(ctx context.Context, err error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return err
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlQueryTimeout) {
+		return &timeoutError{Timeout: timeout, Err: err}
+	}
+	return err
+}
+
+// 按错误类型返回对应错误码的错误响应
+func (s *MCPServer) queryErrorResponse(id interface{}, err error) MCPResponse {
+	code, data := classifyError(err)
+	resp := MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Error: &MCPError{
+			Code:    code,
+			Message: err.Error(),
+		},
+	}
+	if data != nil {
+		resp.Error.Data = data
+	}
+	return resp
+}
+
+// 判断 err 是否为指定错误号的 MySQL 错误
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
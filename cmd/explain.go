@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 慢查询 EXPLAIN 的超时时间
+const slowExplainTimeout = 5 * time.Second
+
+// 查询耗时超过 MYSQL_EXPLAIN_SLOW_MS 时在执行查询的同一连接上执行 EXPLAIN（与查询使用相同的默认数据库），
+// 并以警告级别记录执行计划（不作为工具结果返回）
+func (s *MCPServer) explainIfSlow(ctx context.Context, conn *sql.Conn, query string, args []interface{}, duration time.Duration) {
+	if s.config.ExplainSlowMs <= 0 || duration < time.Duration(s.config.ExplainSlowMs)*time.Millisecond {
+		return
+	}
+	// 只对 SELECT/WITH 做 EXPLAIN；EXPLAIN 自身不会再被 EXPLAIN
+	upperQuery := strings.ToUpper(strings.TrimSpace(query))
+	if !strings.HasPrefix(upperQuery, "SELECT") && !strings.HasPrefix(upperQuery, "WITH") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), slowExplainTimeout)
+	defer cancel()
+
+	// 直接查询而不经过 runQuery，避免 EXPLAIN 本身被计时、记录或再次触发
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		s.logEvent(ctx, "warning", "慢查询 (%s) EXPLAIN 失败: %v", duration.Round(time.Millisecond), err)
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+	var plan []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			continue
+		}
+		parts := make([]string, 0, len(columns))
+		for i, col := range columns {
+			if values[i] == nil {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s=%v", col, convertValue("", values[i])))
+		}
+		plan = append(plan, "  "+strings.Join(parts, " "))
+	}
+
+	s.logEvent(ctx, "warning", "慢查询 (%s): %s\n执行计划:\n%s",
+		duration.Round(time.Millisecond), redactSQL(query), strings.Join(plan, "\n"))
+}
+
+// 执行计划中一张表的访问方式
+type planTable struct {
+	Table        string   `json:"table"`
+	AccessType   string   `json:"access_type,omitempty"`
+	Key          string   `json:"key,omitempty"`
+	PossibleKeys []string `json:"possible_keys,omitempty"`
+	Rows         float64  `json:"rows"`
+}
+
+// 递归收集 EXPLAIN FORMAT=JSON 中所有 table 节点
+func collectPlanTables(node interface{}, out *[]planTable) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if name, ok := v["table_name"].(string); ok {
+			t := planTable{Table: name}
+			t.AccessType, _ = v["access_type"].(string)
+			t.Key, _ = v["key"].(string)
+			if keys, ok := v["possible_keys"].([]interface{}); ok {
+				for _, k := range keys {
+					if k, ok := k.(string); ok {
+						t.PossibleKeys = append(t.PossibleKeys, k)
+					}
+				}
+			}
+			t.Rows, _ = v["rows_examined_per_scan"].(float64)
+			*out = append(*out, t)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectPlanTables(v[k], out)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectPlanTables(item, out)
+		}
+	}
+}
+
+func formatPlanTables(tables []planTable) string {
+	var sb strings.Builder
+	var total float64
+	for _, t := range tables {
+		key := t.Key
+		if key == "" {
+			key = "(无)"
+		}
+		sb.WriteString(fmt.Sprintf("- %s: access_type=%s key=%s", t.Table, t.AccessType, key))
+		if len(t.PossibleKeys) > 0 {
+			sb.WriteString(" possible_keys=" + strings.Join(t.PossibleKeys, ","))
+		}
+		sb.WriteString(fmt.Sprintf(" 预估行数=%.0f\n", t.Rows))
+		total += t.Rows
+	}
+	sb.WriteString(fmt.Sprintf("预估扫描行数合计: %.0f\n", total))
+	return sb.String()
+}
+
+func (s *MCPServer) explainQuery(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query, ok := args["query"].(string)
+	if !ok {
+		return s.errorResponse(id, "query is required")
+	}
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+		return s.queryErrorResponse(id, &argumentError{Path: "query", Reason: "只支持 SELECT 语句"})
+	}
+	raw, _ := args["params"].([]interface{})
+	queryArgs, err := bindParams(raw)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	result, err := s.runQuery(ctx, "EXPLAIN FORMAT=JSON "+query, queryArgs...)
+	if err == nil && result.Count == 1 && len(result.Columns) == 1 {
+		if text, ok := result.Rows[0][result.Columns[0]].(string); ok {
+			var plan interface{}
+			if json.Unmarshal([]byte(text), &plan) == nil {
+				return s.textResponse(id, jsonPlanSummary(plan)+"\n执行计划 (JSON):\n"+text+"\n")
+			}
+		}
+	}
+
+	// 不支持 FORMAT=JSON 时退回普通 EXPLAIN
+	result, err = s.runQuery(ctx, "EXPLAIN "+query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	tables := make([]planTable, 0, result.Count)
+	for _, row := range result.Rows {
+		t := planTable{}
+		t.Table, _ = row["table"].(string)
+		t.AccessType, _ = row["type"].(string)
+		t.Key, _ = row["key"].(string)
+		if keys, ok := row["possible_keys"].(string); ok && keys != "" {
+			t.PossibleKeys = strings.Split(keys, ",")
+		}
+		t.Rows = planRows(row["rows"])
+		tables = append(tables, t)
+	}
+	return s.textResponse(id, formatPlanTables(tables)+"\n执行计划:\n"+formatQueryResult(result))
+}
+
+// 从 JSON 执行计划中提取预估成本和各表的访问方式
+func jsonPlanSummary(plan interface{}) string {
+	var sb strings.Builder
+	if root, ok := plan.(map[string]interface{}); ok {
+		if block, ok := root["query_block"].(map[string]interface{}); ok {
+			if cost, ok := block["cost_info"].(map[string]interface{}); ok {
+				if c, ok := cost["query_cost"].(string); ok {
+					sb.WriteString("预估成本: " + c + "\n")
+				}
+			}
+		}
+	}
+	var tables []planTable
+	collectPlanTables(plan, &tables)
+	sb.WriteString(formatPlanTables(tables))
+	return sb.String()
+}
+
+// 普通 EXPLAIN 的 rows 列可能是整数或字符串
+func planRows(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// EXPLAIN ANALYZE 树中每个节点的实际执行统计
+var analyzeNodePattern = regexp.MustCompile(`-> (.+?)  ?\(.*actual time=([\d.]+)\.\.([\d.]+) rows=([\d.]+) loops=(\d+)\)`)
+
+// 耗时最多的节点，按 最后一行耗时 × loops 排序
+type analyzeNode struct {
+	Operation string
+	TotalMs   float64
+	Rows      string
+	Loops     int
+}
+
+func slowestAnalyzeNodes(tree string, n int) []analyzeNode {
+	var nodes []analyzeNode
+	for _, line := range strings.Split(tree, "\n") {
+		m := analyzeNodePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		last, _ := strconv.ParseFloat(m[3], 64)
+		loops, _ := strconv.Atoi(m[5])
+		nodes = append(nodes, analyzeNode{Operation: m[1], TotalMs: last * float64(loops), Rows: m[4], Loops: loops})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].TotalMs > nodes[j].TotalMs })
+	if len(nodes) > n {
+		nodes = nodes[:n]
+	}
+	return nodes
+}
+
+func (s *MCPServer) explainAnalyze(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query, ok := args["query"].(string)
+	if !ok {
+		return s.errorResponse(id, "query is required")
+	}
+	// EXPLAIN ANALYZE 会真正执行语句，因此只允许 SELECT
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+		return s.queryErrorResponse(id, &rejectedError{"explain_analyze 只支持 SELECT 语句"})
+	}
+	raw, _ := args["params"].([]interface{})
+	queryArgs, err := bindParams(raw)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	result, err := s.runQuery(ctx, "EXPLAIN ANALYZE "+query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("EXPLAIN ANALYZE 需要 MySQL 8.0.18 及以上版本: %w", err))
+	}
+	if result.Count != 1 || len(result.Columns) != 1 {
+		return s.textResponse(id, formatQueryResult(result))
+	}
+	tree, _ := result.Rows[0][result.Columns[0]].(string)
+
+	var sb strings.Builder
+	if nodes := slowestAnalyzeNodes(tree, 5); len(nodes) > 0 {
+		sb.WriteString("耗时最多的节点（实际耗时 × loops）:\n")
+		for _, node := range nodes {
+			sb.WriteString(fmt.Sprintf("- %.3f ms  rows=%s loops=%d  %s\n", node.TotalMs, node.Rows, node.Loops, node.Operation))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("执行计划（总耗时 %s）:\n%s\n", result.Duration.Round(time.Microsecond), tree))
+	return s.textResponse(id, sb.String())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExplainQuery(t *testing.T) {
+	runToolCases(t, "explain_query", []toolCase{
+		{
+			name: "summarizes the JSON plan",
+			args: map[string]interface{}{"query": "SELECT * FROM users WHERE name = ?", "params": []interface{}{"ann"}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN FORMAT=JSON SELECT * FROM users WHERE name = ?")).WithArgs("ann").
+					WillReturnRows(sqlmock.NewRows([]string{"EXPLAIN"}).AddRow(`{"query_block": {"cost_info": {"query_cost": "3.25"},
+						"table": {"table_name": "users", "access_type": "ALL", "rows_examined_per_scan": 30}}}`))
+			},
+			want: []string{"预估成本: 3.25", "- users: access_type=ALL key=(无) 预估行数=30", "预估扫描行数合计: 30"},
+		},
+		{
+			name:    "only SELECT",
+			args:    map[string]interface{}{"query": "SHOW TABLES"},
+			wantErr: "query: 只支持 SELECT 语句",
+		},
+	})
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	runToolCases(t, "explain_analyze", []toolCase{
+		{
+			name: "lists the slowest nodes",
+			args: map[string]interface{}{"query": "SELECT * FROM users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN ANALYZE SELECT * FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"EXPLAIN"}).
+						AddRow("-> Table scan on users  (cost=3.25 rows=30) (actual time=0.050..0.420 rows=30 loops=1)\n"))
+			},
+			want: []string{"耗时最多的节点", "- 0.420 ms  rows=30 loops=1  Table scan on users"},
+		},
+		{
+			name:    "rejects writes",
+			args:    map[string]interface{}{"query": "DELETE FROM users"},
+			wantErr: "只允许执行SELECT、SHOW、DESCRIBE查询",
+		},
+	})
+}
+
+func TestExplainIfSlow(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		duration time.Duration
+		explain  bool
+	}{
+		{name: "slow SELECT plan is logged", query: "SELECT * FROM users WHERE name = 'ann'", duration: 2 * time.Second, explain: true},
+		{name: "fast query not explained", query: "SELECT * FROM users", duration: 100 * time.Millisecond},
+		{name: "only SELECT is explained", query: "SHOW PROCESSLIST", duration: 2 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.ExplainSlowMs = 500
+			s, mock := newTestServer(t, cfg)
+			if tc.explain {
+				mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN " + tc.query)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "table", "type", "key", "rows"}).
+						AddRow(1, "users", "ALL", nil, 5000))
+			}
+
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			conn, err := s.db.Conn(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			s.explainIfSlow(context.Background(), conn, tc.query, nil, tc.duration)
+
+			logged := buf.String()
+			if !tc.explain {
+				if logged != "" {
+					t.Errorf("不应记录慢查询日志:\n%s", logged)
+				}
+				return
+			}
+			for _, want := range []string{
+				"慢查询 (2s): SELECT * FROM users WHERE name = '?'",
+				"执行计划:\n  id=1 table=users type=ALL rows=5000",
+			} {
+				if !strings.Contains(logged, want) {
+					t.Errorf("日志缺少 %q:\n%s", want, logged)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 按外键依赖排序表：被引用的表在前。存在循环引用时其余表按名称追加，
+// 导出脚本开头会关闭 FOREIGN_KEY_CHECKS，因此仍可执行
+func sortTablesByDependency(tables []string, fks []*foreignKey) []string {
+	deps := make(map[string]map[string]bool, len(tables))
+	for _, t := range tables {
+		deps[t] = make(map[string]bool)
+	}
+	for _, fk := range fks {
+		if _, ok := deps[fk.Table]; !ok || fk.Table == fk.ReferencedTable {
+			continue
+		}
+		if _, ok := deps[fk.ReferencedTable]; ok {
+			deps[fk.Table][fk.ReferencedTable] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(sorted) < len(tables) {
+		progressed := false
+		for _, t := range tables {
+			if done[t] {
+				continue
+			}
+			ready := true
+			for dep := range deps[t] {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted = append(sorted, t)
+				done[t] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, t := range tables {
+				if !done[t] {
+					sorted = append(sorted, t)
+					done[t] = true
+				}
+			}
+		}
+	}
+	return sorted
+}
+
+// 读取数据库中的对象名，query 第一列为名称，第二列为类型
+func (s *MCPServer) schemaObjects(ctx context.Context, query string, args ...interface{}) ([][2]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var objects [][2]string
+	for rows.Next() {
+		var name, kind string
+		if err := rows.Scan(&name, &kind); err != nil {
+			continue
+		}
+		objects = append(objects, [2]string{name, kind})
+	}
+	return objects, rows.Err()
+}
+
+func (s *MCPServer) exportSchema(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	objects, err := s.schemaObjects(ctx, `
+		SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	var tables, views []string
+	for _, obj := range objects {
+		if !s.isTableAllowed(obj[0]) {
+			continue
+		}
+		if obj[1] == "VIEW" {
+			views = append(views, obj[0])
+		} else {
+			tables = append(tables, obj[0])
+		}
+	}
+	fks, err := s.loadForeignKeys(ctx, database, "")
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	triggers, err := s.schemaObjects(ctx, `
+		SELECT TRIGGER_NAME, EVENT_OBJECT_TABLE FROM information_schema.TRIGGERS
+		WHERE TRIGGER_SCHEMA = ? ORDER BY EVENT_OBJECT_TABLE, ACTION_ORDER`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	routines, err := s.schemaObjects(ctx, `
+		SELECT ROUTINE_NAME, ROUTINE_TYPE FROM information_schema.ROUTINES
+		WHERE ROUTINE_SCHEMA = ? ORDER BY ROUTINE_TYPE, ROUTINE_NAME`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- 数据库 %s 的结构导出\n", database))
+	sb.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n")
+	// 无法导出的对象以注释标出，不中断整个导出
+	write := func(kind, name, query string, column int, delimited bool) {
+		ddl, err := s.showCreate(ctx, query, column)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("\n-- 无法导出%s %s: %v\n", kind, name, err))
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n-- %s %s\n", kind, name))
+		if delimited {
+			sb.WriteString("DELIMITER ;;\n" + ddl + ";;\nDELIMITER ;\n")
+		} else {
+			sb.WriteString(ddl + ";\n")
+		}
+	}
+
+	for _, t := range sortTablesByDependency(tables, fks) {
+		write("表", t, "SHOW CREATE TABLE "+qualifiedTable(database, t), 1, false)
+	}
+	// 视图可能引用其他视图，这里按名称排序，依赖关系由 MySQL 在首次使用时解析
+	sort.Strings(views)
+	for _, v := range views {
+		write("视图", v, "SHOW CREATE VIEW "+qualifiedTable(database, v), 1, false)
+	}
+	for _, r := range routines {
+		write(strings.ToLower(r[1]), r[0], fmt.Sprintf("SHOW CREATE %s %s", r[1], qualifiedTable(database, r[0])), 2, true)
+	}
+	for _, t := range triggers {
+		if !s.isTableAllowed(t[1]) {
+			continue
+		}
+		write("触发器", t[0], "SHOW CREATE TRIGGER "+qualifiedTable(database, t[0]), 2, true)
+	}
+	sb.WriteString("\nSET FOREIGN_KEY_CHECKS = 1;\n")
+
+	return s.textResponse(id, sb.String())
+}
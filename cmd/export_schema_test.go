@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportSchema(t *testing.T) {
+	runToolCases(t, "export_schema", []toolCase{
+		{
+			name: "orders tables by foreign key dependency",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.TABLES").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE"}).
+						AddRow("orders", "BASE TABLE").
+						AddRow("users", "BASE TABLE").
+						AddRow("v_orders", "VIEW"))
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(foreignKeyColumns).
+						AddRow("orders", "fk_user", "user_id", "users", "id", "RESTRICT", "RESTRICT"))
+				mock.ExpectQuery("FROM information_schema.TRIGGERS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TRIGGER_NAME", "EVENT_OBJECT_TABLE"}))
+				mock.ExpectQuery("FROM information_schema.ROUTINES").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"ROUTINE_NAME", "ROUTINE_TYPE"}).AddRow("refresh", "PROCEDURE"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE TABLE `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("users", "CREATE TABLE `users` (`id` int)"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE TABLE `testdb`.`orders`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("orders", "CREATE TABLE `orders` (`user_id` int)"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE VIEW `testdb`.`v_orders`")).
+					WillReturnError(errors.New("view definer missing"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE PROCEDURE `testdb`.`refresh`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Procedure", "sql_mode", "Create Procedure"}).
+						AddRow("refresh", "", "CREATE PROCEDURE `refresh`() BEGIN END"))
+			},
+			want: []string{
+				"SET FOREIGN_KEY_CHECKS = 0;",
+				"-- 无法导出视图 v_orders: view definer missing",
+				"DELIMITER ;;\nCREATE PROCEDURE `refresh`() BEGIN END;;\nDELIMITER ;",
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				text := responseText(resp)
+				if strings.Index(text, "CREATE TABLE `users`") > strings.Index(text, "CREATE TABLE `orders`") {
+					t.Errorf("被引用的表应在前:\n%s", text)
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 超过并发或频率限制时返回的错误码
+const rateLimitedCode = -32003
+
+// 为 KILL QUERY 和连接检查保留的连接数，不计入 MYSQL_MAX_CONNECTIONS
+const reservedConns = 2
+
+// 一个连接（会话）上的工具调用限制
+type sessionLimiter struct {
+	mu     sync.Mutex
+	active int
+	// 最近一分钟内开始的调用时间
+	recent []time.Time
+}
+
+func newSessionLimiter() *sessionLimiter {
+	return &sessionLimiter{}
+}
+
+// 全局连接上限对应的信号量，未设置上限时为 nil
+func (s *MCPServer) connSlots() chan struct{} {
+	s.slotsOnce.Do(func() {
+		if s.config.MaxConnections > 0 {
+			s.slots = make(chan struct{}, s.config.MaxConnections)
+		}
+	})
+	return s.slots
+}
+
+// 工具调用占用的 MYSQL_MAX_CONNECTIONS 名额。submit_query 通过 take 把名额转交给后台任务，
+// 调用结束时不再归还
+type connSlot struct {
+	mu      sync.Mutex
+	release func()
+}
+
+// 取走名额，之后由调用方负责归还；没有占用名额时返回 nil
+func (c *connSlot) take() func() {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	release := c.release
+	c.release = nil
+	return release
+}
+
+// 检查会话并发数、每分钟调用次数和全局连接数，通过时返回记录了连接名额的 context
+// 和在调用结束后执行的 release
+func (s *MCPServer) admitToolCall(ctx context.Context) (context.Context, func(), *MCPError) {
+	l := sessionLimiterFrom(ctx)
+	if l != nil {
+		l.mu.Lock()
+		now := time.Now()
+		for len(l.recent) > 0 && now.Sub(l.recent[0]) >= time.Minute {
+			l.recent = l.recent[1:]
+		}
+		if max := s.config.SessionMaxConcurrent; max > 0 && l.active >= max {
+			l.mu.Unlock()
+			return ctx, nil, &MCPError{Code: rateLimitedCode, Message: fmt.Sprintf("当前会话正在执行的查询已达上限 %d，请稍后重试", max)}
+		}
+		if max := s.config.SessionQueriesPerMinute; max > 0 && len(l.recent) >= max {
+			l.mu.Unlock()
+			return ctx, nil, &MCPError{Code: rateLimitedCode, Message: fmt.Sprintf("当前会话每分钟最多执行 %d 次查询，请稍后重试", max)}
+		}
+		l.active++
+		l.recent = append(l.recent, now)
+		l.mu.Unlock()
+	}
+
+	releaseSession := func() {
+		if l != nil {
+			l.mu.Lock()
+			l.active--
+			l.mu.Unlock()
+		}
+	}
+
+	slots := s.connSlots()
+	if slots == nil {
+		return ctx, releaseSession, nil
+	}
+	select {
+	case slots <- struct{}{}:
+	default:
+		releaseSession()
+		return ctx, nil, &MCPError{Code: rateLimitedCode, Message: fmt.Sprintf("服务器正在执行的查询已达上限 %d，请稍后重试", s.config.MaxConnections)}
+	}
+	slot := &connSlot{release: func() { <-slots }}
+	return withConnSlot(ctx, slot), func() {
+		if release := slot.take(); release != nil {
+			release()
+		}
+		releaseSession()
+	}, nil
+}
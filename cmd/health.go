@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 单次连接检查的超时时间
+const healthCheckTimeout = 5 * time.Second
+
+// 最近一次 MySQL 连接检查的结果
+type dbHealth struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (h *dbHealth) lastError() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// 执行一次 PingContext 并记录结果，状态变化时写日志
+func (s *MCPServer) checkConnection(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := s.db.PingContext(ctx)
+
+	s.health.mu.Lock()
+	previous := s.health.err
+	s.health.err = err
+	s.health.mu.Unlock()
+
+	switch {
+	case err != nil && previous == nil:
+		s.logEvent(ctx, "error", "MySQL 连接不可用: %v", err)
+	case err == nil && previous != nil:
+		s.logEvent(ctx, "notice", "MySQL 连接已恢复")
+	}
+	return err
+}
+
+// 每隔 MYSQL_HEALTH_CHECK_SECONDS 检查一次连接，直到 ctx 结束
+func (s *MCPServer) monitorConnection(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.config.HealthCheckSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkConnection(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 查询前确认连接可用：最近一次检查失败时立即重新 ping，仍失败则返回明确的错误
+func (s *MCPServer) ensureConnection(ctx context.Context) error {
+	if s.health.lastError() == nil {
+		return nil
+	}
+	if err := s.checkConnection(ctx); err != nil {
+		return fmt.Errorf("%w: %v", errConnectionUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 在 SELECT 关键字后注入 /*+ MAX_EXECUTION_TIME(ms) */ 提示，
+// 让超时的查询即使客户端未取消也会被服务端终止。
+// 对 WITH 开头的 CTE 查询，提示放在主查询的 SELECT 之后；非 SELECT 语句原样返回。
+func addMaxExecutionTimeHint(query string, ms int) string {
+	if strings.Contains(strings.ToUpper(query), "MAX_EXECUTION_TIME(") {
+		return query
+	}
+
+	offset := len(query) - len(strings.TrimLeftFunc(query, unicode.IsSpace))
+	var pos int
+	switch {
+	case hasKeywordAt(query, offset, "SELECT"):
+		pos = offset + len("SELECT")
+	case hasKeywordAt(query, offset, "WITH"):
+		pos = findTopLevelSelect(query, offset+len("WITH"))
+		if pos < 0 {
+			return query
+		}
+		pos += len("SELECT")
+	default:
+		return query
+	}
+
+	return query[:pos] + fmt.Sprintf(" /*+ MAX_EXECUTION_TIME(%d) */", ms) + query[pos:]
+}
+
+// 判断 query[pos:] 是否以独立的关键字 keyword 开头（不区分大小写）
+func hasKeywordAt(query string, pos int, keyword string) bool {
+	end := pos + len(keyword)
+	if end > len(query) || !strings.EqualFold(query[pos:end], keyword) {
+		return false
+	}
+	if pos > 0 && isWordChar(query[pos-1]) {
+		return false
+	}
+	return end == len(query) || !isWordChar(query[end])
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// 从 start 开始查找括号外的第一个 SELECT，跳过引号和注释中的内容；找不到返回 -1
+func findTopLevelSelect(query string, start int) int {
+	return findTopLevelKeyword(query, start, "SELECT")
+}
+
+// 从 start 开始查找括号外的第一个关键字 keyword，跳过引号和注释中的内容；找不到返回 -1
+func findTopLevelKeyword(query string, start int, keyword string) int {
+	depth := 0
+	for i := start; i < len(query); i++ {
+		if end := commentEnd(query, i); end >= 0 {
+			i = end - 1
+			continue
+		}
+		switch c := query[i]; c {
+		case '\'', '"', '`':
+			// 跳到匹配的结束引号
+			i = skipQuoted(query, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && hasKeywordAt(query, i, keyword) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// 没有顶层 LIMIT 的 SELECT/WITH 查询追加 LIMIT limit，返回是否追加。
+// LIMIT 必须位于末尾的 FOR UPDATE、FOR SHARE、LOCK IN SHARE MODE 和 FROM 之后的
+// INTO @var/OUTFILE 之前；LIMIT 另起一行，避免被前面的 -- 注释吞掉。
+func addDefaultLimit(query string, limit int) (string, bool) {
+	offset := len(query) - len(strings.TrimLeftFunc(query, unicode.IsSpace))
+	if !hasKeywordAt(query, offset, "SELECT") && !hasKeywordAt(query, offset, "WITH") {
+		return query, false
+	}
+	if findTopLevelKeyword(query, offset, "LIMIT") >= 0 {
+		return query, false
+	}
+	query = strings.TrimRightFunc(query, func(r rune) bool { return r == ';' || unicode.IsSpace(r) })
+
+	pos := len(query)
+	for _, keyword := range []string{"FOR", "LOCK"} {
+		if p := findTopLevelKeyword(query, offset, keyword); p >= 0 && p < pos {
+			pos = p
+		}
+	}
+	// SELECT ... INTO @var FROM t 中的 INTO 在 FROM 之前，LIMIT 仍追加在末尾
+	if from := findTopLevelKeyword(query, offset, "FROM"); from >= 0 {
+		if p := findTopLevelKeyword(query, from, "INTO"); p >= 0 && p < pos {
+			pos = p
+		}
+	}
+	if pos == len(query) {
+		return fmt.Sprintf("%s\nLIMIT %d", query, limit), true
+	}
+	head := strings.TrimRightFunc(query[:pos], unicode.IsSpace)
+	return fmt.Sprintf("%s\nLIMIT %d\n%s", head, limit, query[pos:]), true
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddMaxExecutionTimeHint(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "simple select",
+			query: "SELECT * FROM users",
+			want:  "SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM users",
+		},
+		{
+			name:  "leading whitespace and lower case",
+			query: "  select id from users",
+			want:  "  select /*+ MAX_EXECUTION_TIME(500) */ id from users",
+		},
+		{
+			name:  "hint goes after the main SELECT of a CTE",
+			query: "WITH recent AS (SELECT id FROM orders WHERE created_at > '2024-01-01') SELECT COUNT(*) FROM recent",
+			want:  "WITH recent AS (SELECT id FROM orders WHERE created_at > '2024-01-01') SELECT /*+ MAX_EXECUTION_TIME(500) */ COUNT(*) FROM recent",
+		},
+		{
+			name:  "SELECT inside a CTE string literal is skipped",
+			query: "WITH t AS (SELECT 'a) SELECT' AS s) SELECT s FROM t",
+			want:  "WITH t AS (SELECT 'a) SELECT' AS s) SELECT /*+ MAX_EXECUTION_TIME(500) */ s FROM t",
+		},
+		{
+			name:  "existing hint is kept",
+			query: "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM users",
+			want:  "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM users",
+		},
+		{
+			name:  "non-SELECT statement unchanged",
+			query: "SHOW TABLES",
+			want:  "SHOW TABLES",
+		},
+		{
+			name:  "SELECT must be a whole word",
+			query: "SELECTED_ROWS",
+			want:  "SELECTED_ROWS",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := addMaxExecutionTimeHint(tc.query, 500); got != tc.want {
+				t.Errorf("addMaxExecutionTimeHint(%q) = %q，期望 %q", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+// 配置 MYSQL_MAX_EXECUTION_TIME_MS 后，发送给服务端的语句带有提示
+func TestMaxExecutionTimeHintApplied(t *testing.T) {
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "hint injected into the executed query",
+			config: func(cfg *MySQLConfig) { cfg.MaxExecutionTimeMs = 2000 },
+			args:   map[string]interface{}{"query": "SELECT id FROM users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(2000) */ id FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+		},
+		{
+			name:   "hint injected into the main SELECT of a CTE",
+			config: func(cfg *MySQLConfig) { cfg.MaxExecutionTimeMs = 2000 },
+			args: map[string]interface{}{"query": "WITH recent AS (SELECT id FROM orders WHERE id > 100) " +
+				"SELECT COUNT(*) AS n FROM recent"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("WITH recent AS (SELECT id FROM orders WHERE id > 100) " +
+					"SELECT /*+ MAX_EXECUTION_TIME(2000) */ COUNT(*) AS n FROM recent")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(7))
+			},
+			want: []string{"查询结果 (1 行)"},
+		},
+	})
+}
+
+// 配置 MYSQL_AUTO_LIMIT 后，没有 LIMIT 的 CTE 查询在主语句末尾追加 LIMIT
+func TestAutoLimitApplied(t *testing.T) {
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "LIMIT appended to a CTE query",
+			config: func(cfg *MySQLConfig) { cfg.AutoLimit = 50 },
+			args: map[string]interface{}{"query": "WITH recent AS (SELECT id FROM orders WHERE id > 100) " +
+				"SELECT id FROM recent;"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("WITH recent AS (SELECT id FROM orders WHERE id > 100) "+
+					"SELECT id FROM recent\nLIMIT 50") + "$").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(101))
+			},
+			want: []string{"已自动添加 LIMIT 50"},
+		},
+		{
+			name:   "LIMIT inside the CTE does not count",
+			config: func(cfg *MySQLConfig) { cfg.AutoLimit = 50 },
+			args: map[string]interface{}{"query": "WITH top AS (SELECT id FROM orders LIMIT 5) " +
+				"SELECT id FROM top FOR UPDATE"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("WITH top AS (SELECT id FROM orders LIMIT 5) "+
+					"SELECT id FROM top\nLIMIT 50\nFOR UPDATE") + "$").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			want: []string{"已自动添加 LIMIT 50"},
+		},
+	})
+}
+
+func TestCheckReadOnlyQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		ok    bool
+	}{
+		{"SELECT 1", true},
+		{"  show tables", true},
+		{"WITH t AS (SELECT 1 AS a) SELECT a FROM t", true},
+		{"with recursive n AS (SELECT 1 UNION ALL SELECT n + 1 FROM n WHERE n < 5) select * from n", true},
+		{"WITH t AS (SELECT id FROM users) SELECT * FROM t FOR UPDATE", true},
+		{"WITH t AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM t)", false},
+		{"WITH t AS (SELECT id FROM users) UPDATE users SET name = 'x' WHERE id IN (SELECT id FROM t)", false},
+		{"WITHDRAW", false},
+		{"DELETE FROM users", false},
+	}
+	for _, tc := range cases {
+		if err := checkReadOnlyQuery(tc.query); (err == nil) != tc.ok {
+			t.Errorf("checkReadOnlyQuery(%q) = %v，期望允许=%t", tc.query, err, tc.ok)
+		}
+	}
+}
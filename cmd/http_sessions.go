@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Streamable HTTP 会话 ID 的请求/响应头
+const sessionIDHeader = "Mcp-Session-Id"
+
+// 检查空闲会话的间隔
+const sessionSweepInterval = time.Minute
+
+// 一个 HTTP 会话：独立的在途请求表、日志级别、客户端能力、调用限制和当前数据库。
+// 会话不占用 MySQL 连接，每次查询从连接池取连接，并切换到会话的当前数据库。
+type httpSession struct {
+	inflight *inflightRequests
+	logLevel *clientLogLevel
+	clients  *clientRequests
+	limiter  *sessionLimiter
+	database *sessionDatabase
+	lastSeen time.Time
+}
+
+type httpSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+func newHTTPSessions() *httpSessions {
+	return &httpSessions{sessions: make(map[string]*httpSession)}
+}
+
+func (m *httpSessions) create() (string, *httpSession) {
+	id := newSessionID()
+	session := &httpSession{
+		inflight: newInflightRequests(),
+		logLevel: newClientLogLevel(),
+		clients:  newClientRequests(),
+		limiter:  newSessionLimiter(),
+		database: &sessionDatabase{},
+		lastSeen: time.Now(),
+	}
+	m.mu.Lock()
+	m.sessions[id] = session
+	m.mu.Unlock()
+	return id, session
+}
+
+// 查找会话并刷新最近活动时间
+func (m *httpSessions) get(id string) (*httpSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if ok {
+		session.lastSeen = time.Now()
+	}
+	return session, ok
+}
+
+func (m *httpSessions) remove(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[id]
+	delete(m.sessions, id)
+	return ok
+}
+
+// 关闭所有会话，服务退出时调用
+func (m *httpSessions) closeAll() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		m.remove(id)
+	}
+}
+
+// 定期关闭空闲超过 idle 的会话，直到 ctx 结束
+func (m *httpSessions) expire(ctx context.Context, idle time.Duration) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		var expired []string
+		m.mu.Lock()
+		for id, session := range m.sessions {
+			if time.Since(session.lastSeen) > idle {
+				expired = append(expired, id)
+			}
+		}
+		m.mu.Unlock()
+		for _, id := range expired {
+			if m.remove(id) {
+				log.Printf("HTTP 会话 %s 空闲超时，已关闭", id)
+			}
+		}
+	}
+}
+
+// 把会话状态放入请求的 context
+func (session *httpSession) context(ctx context.Context) context.Context {
+	ctx = withInflight(ctx, session.inflight)
+	ctx = withLogLevel(ctx, session.logLevel)
+	ctx = withClientRequests(ctx, session.clients)
+	ctx = withSessionLimiter(ctx, session.limiter)
+	return withSessionDatabase(ctx, session.database)
+}
+
+// 按 Mcp-Session-Id 找到会话：initialize 请求创建新会话，DELETE 结束会话，
+// 其余请求必须携带有效的会话 ID。返回 false 表示已写出错误响应。
+func (m *httpSessions) resolve(w http.ResponseWriter, r *http.Request, req MCPRequest) (*httpSession, bool) {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		if req.Method == "initialize" {
+			id, session := m.create()
+			w.Header().Set(sessionIDHeader, id)
+			return session, true
+		}
+		http.Error(w, "missing "+sessionIDHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+
+	session, ok := m.get(id)
+	if !ok {
+		// 会话不存在或已过期，客户端应重新 initialize
+		http.Error(w, "session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return session, true
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 已结束的任务保留多久供 fetch_results 读取
+const jobRetention = 30 * time.Minute
+
+// fetch_results 默认每页行数
+const jobPageSize = 100
+
+// 每个会话最多保留的任务数（含已结束的），超出时丢弃最早结束的任务结果
+const jobsRetainedPerSession = 20
+
+// 后台执行的查询任务
+type queryJob struct {
+	ID       string
+	Query    string
+	Started  time.Time
+	rowsRead atomic.Int64
+	cancel   context.CancelFunc
+	// 提交任务的会话，其他会话看不到该任务
+	session *sessionLimiter
+
+	mu       sync.Mutex
+	status   string
+	finished time.Time
+	result   *QueryResult
+	err      error
+}
+
+// 所有会话共享的任务表，任务 ID 随机生成，只能由提交任务的会话访问
+type queryJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*queryJob
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 登记新任务。会话正在运行的任务已达 maxRunning 时返回错误；
+// 会话保留的任务超过 jobsRetainedPerSession 时丢弃最早结束的任务
+func (j *queryJobs) add(job *queryJob, maxRunning int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.jobs == nil {
+		j.jobs = make(map[string]*queryJob)
+	}
+	var running int
+	var finished []*queryJob
+	for id, old := range j.jobs {
+		old.mu.Lock()
+		end := old.finished
+		old.mu.Unlock()
+		// 顺便清理过期任务
+		if !end.IsZero() && time.Since(end) > jobRetention {
+			delete(j.jobs, id)
+			continue
+		}
+		if old.session != job.session {
+			continue
+		}
+		if end.IsZero() {
+			running++
+		} else {
+			finished = append(finished, old)
+		}
+	}
+	if maxRunning > 0 && running >= maxRunning {
+		return &rejectedError{fmt.Sprintf("当前会话正在运行的任务已达上限 %d（MYSQL_SESSION_MAX_JOBS），请等待任务结束或使用 cancel_query 取消", maxRunning)}
+	}
+	if excess := running + len(finished) + 1 - jobsRetainedPerSession; excess > 0 {
+		sort.Slice(finished, func(a, b int) bool { return finished[a].finished.Before(finished[b].finished) })
+		for _, old := range finished[:min(excess, len(finished))] {
+			delete(j.jobs, old.ID)
+		}
+	}
+	j.jobs[job.ID] = job
+	return nil
+}
+
+// 按 ID 取当前会话提交的任务
+func (j *queryJobs) get(id string, session *sessionLimiter) *queryJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job := j.jobs[id]
+	if job == nil || job.session != session {
+		return nil
+	}
+	return job
+}
+
+// 服务关闭时取消所有仍在运行的任务
+func (j *queryJobs) cancelAll() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, job := range j.jobs {
+		job.cancel()
+	}
+}
+
+// 在后台执行查询。任务不随请求结束，但在整个执行期间占用
+// MYSQL_MAX_CONNECTIONS 的一个名额：releaseSlot 为提交请求转交的名额，
+// 为 nil 时另外申请；保留的结果行数和字节数受 runQuery 的
+// MYSQL_RESULT_MAX_ROWS、MYSQL_RESULT_MAX_BYTES 限制。
+// 通过 progressToken 和 notifier 接收 runQuery 的进度，记录已读取的行数。
+// database 为提交时会话的当前数据库。
+func (s *MCPServer) startJob(session *sessionLimiter, database, query string, args []interface{}, releaseSlot func()) (*queryJob, error) {
+	if releaseSlot == nil {
+		releaseSlot = func() {}
+		if slots := s.connSlots(); slots != nil {
+			select {
+			case slots <- struct{}{}:
+				releaseSlot = func() { <-slots }
+			default:
+				return nil, &rejectedError{fmt.Sprintf("服务器正在执行的查询已达上限 %d，请稍后重试", s.config.MaxConnections)}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &queryJob{
+		ID:      newJobID(),
+		Query:   query,
+		Started: time.Now(),
+		cancel:  cancel,
+		session: session,
+		status:  "running",
+	}
+	ctx = withSessionDatabase(ctx, &sessionDatabase{name: database})
+	ctx = withProgressToken(ctx, job.ID)
+	ctx = withNotifier(ctx, func(msg interface{}) {
+		n, ok := msg.(MCPNotification)
+		if !ok || n.Method != "notifications/progress" {
+			return
+		}
+		if params, ok := n.Params.(map[string]interface{}); ok {
+			if rows, ok := params["progress"].(int); ok {
+				job.rowsRead.Store(int64(rows))
+			}
+		}
+	})
+	if err := s.jobs.add(job, s.config.SessionMaxJobs); err != nil {
+		cancel()
+		releaseSlot()
+		return nil, err
+	}
+
+	go func() {
+		defer cancel()
+		defer releaseSlot()
+		result, err := s.runQuery(ctx, query, args...)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.finished = time.Now()
+		switch {
+		case err == nil:
+			job.status = "completed"
+			job.result = result
+			job.rowsRead.Store(int64(result.Count))
+		case ctx.Err() != nil:
+			job.status = "cancelled"
+			job.err = err
+		default:
+			job.status = "failed"
+			job.err = err
+		}
+	}()
+	return job, nil
+}
+
+func (s *MCPServer) submitQuery(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query, ok := args["query"].(string)
+	if !ok {
+		return s.errorResponse(id, "query is required")
+	}
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+	raw, _ := args["params"].([]interface{})
+	queryArgs, err := bindParams(raw)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	// 接管本次调用占用的连接名额，任务结束时才归还
+	job, err := s.startJob(sessionLimiterFrom(ctx), s.currentDatabase(ctx), query, queryArgs, connSlotFrom(ctx).take())
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("已提交任务 %s，使用 query_status 查看进度，完成后使用 fetch_results 读取结果\n", job.ID))
+}
+
+func (s *MCPServer) jobFromArgs(ctx context.Context, id interface{}, args map[string]interface{}) (*queryJob, *MCPResponse) {
+	jobID, _ := args["job_id"].(string)
+	job := s.jobs.get(jobID, sessionLimiterFrom(ctx))
+	if job == nil {
+		resp := s.queryErrorResponse(id, &argumentError{Path: "job_id", Reason: "任务不存在或已过期"})
+		return nil, &resp
+	}
+	return job, nil
+}
+
+func (s *MCPServer) queryStatus(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	job, errResp := s.jobFromArgs(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+
+	job.mu.Lock()
+	end := job.finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	status := map[string]interface{}{
+		"job_id":     job.ID,
+		"status":     job.status,
+		"rows_read":  job.rowsRead.Load(),
+		"elapsed_ms": end.Sub(job.Started).Milliseconds(),
+	}
+	if job.result != nil {
+		status["row_count"] = job.result.Count
+	}
+	if job.err != nil {
+		status["error"] = job.err.Error()
+	}
+	job.mu.Unlock()
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+	return s.textResponse(id, string(data)+"\n")
+}
+
+func (s *MCPServer) fetchResults(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	job, errResp := s.jobFromArgs(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	job.mu.Lock()
+	status, result, jobErr := job.status, job.result, job.err
+	job.mu.Unlock()
+	switch status {
+	case "completed":
+	case "running":
+		return s.errorResponse(id, fmt.Sprintf("任务 %s 仍在执行，已读取 %d 行", job.ID, job.rowsRead.Load()))
+	default:
+		return s.queryErrorResponse(id, jobErr)
+	}
+
+	pageSize := jobPageSize
+	if v, ok := args["page_size"].(float64); ok && v >= 1 {
+		pageSize = int(v)
+	}
+	if s.config.MaxRows > 0 && pageSize > s.config.MaxRows {
+		pageSize = s.config.MaxRows
+	}
+	start := 0
+	if cursor, _ := args["cursor"].(string); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return s.queryErrorResponse(id, &argumentError{Path: "cursor", Reason: "无效的 cursor"})
+		}
+		start = min(offset, len(result.Rows))
+	}
+	end := min(start+pageSize, len(result.Rows))
+
+	page := *result
+	page.Rows = result.Rows[start:end]
+	page.Count = len(page.Rows)
+	var nextCursor string
+	if end < len(result.Rows) {
+		nextCursor = encodeCursor(end)
+	}
+
+	format, _ := args["format"].(string)
+	return withNextCursorNote(s.queryResultResponse(id, &page, format), nextCursor)
+}
+
+// 取消仍在运行的任务，runQuery 会通过 KILL QUERY 终止服务端的查询
+func (s *MCPServer) cancelQuery(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	job, errResp := s.jobFromArgs(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	job.mu.Lock()
+	status := job.status
+	job.mu.Unlock()
+	if status != "running" {
+		return s.textResponse(id, fmt.Sprintf("任务 %s 已结束（%s），无需取消\n", job.ID, status))
+	}
+	job.cancel()
+	return s.textResponse(id, fmt.Sprintf("已取消任务 %s\n", job.ID))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var jobIDPattern = regexp.MustCompile(`已提交任务 ([0-9a-f]+)`)
+
+// 提交任务后轮询 query_status 直到任务结束
+func TestQueryJobLifecycle(t *testing.T) {
+	s, mock := newTestServer(t, testConfig())
+	ctx := context.Background()
+
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM orders")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	resp := invokeTool(t, s, ctx, "submit_query", map[string]interface{}{"query": "SELECT id FROM orders"})
+	if resp.Error != nil {
+		t.Fatalf("提交任务失败: %s", resp.Error.Message)
+	}
+	match := jobIDPattern.FindStringSubmatch(responseText(resp))
+	if match == nil {
+		t.Fatalf("响应中没有任务 ID: %s", responseText(resp))
+	}
+	jobID := match[1]
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := responseText(invokeTool(t, s, ctx, "query_status", map[string]interface{}{"job_id": jobID}))
+		if strings.Contains(status, `"status": "completed"`) {
+			if !strings.Contains(status, `"row_count": 3`) {
+				t.Errorf("任务状态中行数不正确:\n%s", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("任务未在期限内完成:\n%s", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	page := invokeTool(t, s, ctx, "fetch_results", map[string]interface{}{"job_id": jobID, "page_size": 2})
+	if page.Error != nil {
+		t.Fatalf("读取结果失败: %s", page.Error.Message)
+	}
+	if text := responseText(page); !strings.Contains(text, "还有更多行") {
+		t.Errorf("第一页应提示下一页 cursor:\n%s", text)
+	}
+
+	cancel := responseText(invokeTool(t, s, ctx, "cancel_query", map[string]interface{}{"job_id": jobID}))
+	if !strings.Contains(cancel, "已结束（completed），无需取消") {
+		t.Errorf("取消已完成的任务: %s", cancel)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// MYSQL_MAX_CONNECTIONS=1 时 submit_query 把调用占用的名额转交给任务，任务结束后归还
+func TestSubmitQueryWithOneConnection(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxConnections = 1
+	s, mock := newTestServer(t, cfg)
+	ctx := context.Background()
+
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM orders")).WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	resp := invokeTool(t, s, ctx, "submit_query", map[string]interface{}{"query": "SELECT id FROM orders"})
+	if resp.Error != nil {
+		t.Fatalf("提交任务失败: %s", resp.Error.Message)
+	}
+
+	// 任务运行期间名额被占用
+	busy := invokeTool(t, s, ctx, "execute_query", map[string]interface{}{"query": "SELECT 1"})
+	if busy.Error == nil || busy.Error.Code != rateLimitedCode {
+		t.Fatalf("任务运行期间应拒绝其他调用，得到 %+v", busy.Error)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		select {
+		case s.connSlots() <- struct{}{}:
+			<-s.connSlots()
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			return
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("任务结束后名额未归还")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubmitQuery(t *testing.T) {
+	runToolCases(t, "submit_query", []toolCase{
+		{
+			name:    "rejects writes",
+			args:    map[string]interface{}{"query": "DELETE FROM orders"},
+			wantErr: "只允许执行SELECT、SHOW、DESCRIBE查询",
+		},
+	})
+}
+
+func TestQueryStatus(t *testing.T) {
+	runToolCases(t, "query_status", []toolCase{
+		{
+			name:    "unknown job",
+			args:    map[string]interface{}{"job_id": "deadbeef"},
+			wantErr: "job_id: 任务不存在或已过期",
+		},
+	})
+}
+
+func TestFetchResults(t *testing.T) {
+	runToolCases(t, "fetch_results", []toolCase{
+		{
+			name:    "unknown job",
+			args:    map[string]interface{}{"job_id": "deadbeef"},
+			wantErr: "job_id: 任务不存在或已过期",
+		},
+	})
+}
+
+func TestCancelQuery(t *testing.T) {
+	runToolCases(t, "cancel_query", []toolCase{
+		{
+			name:    "unknown job",
+			args:    map[string]interface{}{"job_id": "deadbeef"},
+			wantErr: "job_id: 任务不存在或已过期",
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// 写操作工具，只读模式下不出现在 tools/list 中
+var writeTools = map[string]bool{
+	"truncate_table":      true,
+	"insert_row":          true,
+	"update_rows":         true,
+	"delete_rows":         true,
+	"execute_transaction": true,
+	"call_procedure":      true,
+	"create_table":        true,
+	"alter_table":         true,
+	"drop_table":          true,
+	"create_index":        true,
+}
+
+// 行级写操作工具，还需要 --allow-writes 或 MYSQL_ALLOW_WRITES=true 才会出现
+var rowWriteTools = map[string]bool{
+	"insert_row":          true,
+	"update_rows":         true,
+	"delete_rows":         true,
+	"execute_transaction": true,
+	"call_procedure":      true,
+}
+
+// DDL 工具，还需要 --allow-ddl 或 MYSQL_ALLOW_DDL=true 才会出现
+var ddlTools = map[string]bool{
+	"create_table": true,
+	"alter_table":  true,
+	"drop_table":   true,
+	"create_index": true,
+}
+
+// 管理类工具，需要 MYSQL_ALLOW_ADMIN=true 才会出现
+var adminTools = map[string]bool{
+	"kill_query":      true,
+	"kill_connection": true,
+	"list_users":      true,
+}
+
+// 订阅工具列表变化的连接
+type toolListListeners struct {
+	mu        sync.Mutex
+	nextID    int
+	notifiers map[int]notifier
+}
+
+// 注册连接的通知函数，返回的函数在连接关闭时调用
+func (s *MCPServer) watchToolList(notify notifier) func() {
+	l := &s.toolListeners
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.notifiers == nil {
+		l.notifiers = make(map[int]notifier)
+	}
+	l.nextID++
+	id := l.nextID
+	l.notifiers[id] = notify
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.notifiers, id)
+	}
+}
+
+// 通知所有连接重新获取工具列表
+func (s *MCPServer) notifyToolListChanged() {
+	l := &s.toolListeners
+	l.mu.Lock()
+	notifiers := make([]notifier, 0, len(l.notifiers))
+	for _, notify := range l.notifiers {
+		notifiers = append(notifiers, notify)
+	}
+	l.mu.Unlock()
+
+	for _, notify := range notifiers {
+		notify(MCPNotification{
+			Jsonrpc: "2.0",
+			Method:  "notifications/tools/list_changed",
+		})
+	}
+}
+
+func (s *MCPServer) isReadOnly() bool {
+	return s.readOnly.Load()
+}
+
+// 切换只读/读写模式，模式变化时通知客户端刷新工具列表
+func (s *MCPServer) setReadOnly(readOnly bool) {
+	if s.readOnly.Swap(readOnly) == readOnly {
+		return
+	}
+	if readOnly {
+		log.Printf("已切换到只读模式")
+	} else {
+		log.Printf("已切换到读写模式")
+	}
+	s.notifyToolListChanged()
+}
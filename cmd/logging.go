@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// MCP 日志级别（RFC 5424），按严重程度从低到高排列
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// 客户端未调用 logging/setLevel 时转发的最低级别
+const defaultClientLogLevel = "warning"
+
+func logLevelIndex(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// 一个连接上客户端设置的日志级别
+type clientLogLevel struct {
+	mu    sync.Mutex
+	level int
+}
+
+func newClientLogLevel() *clientLogLevel {
+	return &clientLogLevel{level: logLevelIndex(defaultClientLogLevel)}
+}
+
+func (l *clientLogLevel) enabled(level string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return logLevelIndex(level) >= l.level
+}
+
+func (s *MCPServer) setLogLevel(ctx context.Context, req MCPRequest) MCPResponse {
+	var params struct {
+		Level string `json:"level"`
+	}
+	json.Unmarshal(req.Params, &params)
+	index := logLevelIndex(params.Level)
+	if index < 0 {
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid log level: %s", params.Level),
+			},
+		}
+	}
+
+	if l := logLevelFrom(ctx); l != nil {
+		l.mu.Lock()
+		l.level = index
+		l.mu.Unlock()
+	}
+	return MCPResponse{Jsonrpc: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+// 记录服务端事件：总是写入 stderr，级别达到客户端设置时同时以 notifications/message 发给客户端
+func (s *MCPServer) logEvent(ctx context.Context, level string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("[%s] %s", strings.ToUpper(level), message)
+
+	l := logLevelFrom(ctx)
+	notify := notifierFrom(ctx)
+	if l == nil || notify == nil || !l.enabled(level) {
+		return
+	}
+	notify(MCPNotification{
+		Jsonrpc: "2.0",
+		Method:  "notifications/message",
+		Params: map[string]interface{}{
+			"level":  level,
+			"logger": "mysql-mcp-server",
+			"data":   message,
+		},
+	})
+}
@@ -1,23 +1,39 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 // MCPRequest MCP Protocol structures
+// 客户端对服务端请求的响应也解码为 MCPRequest：没有 method，带 result 或 error
 type MCPRequest struct {
 	Jsonrpc string          `json:"jsonrpc"`
 	ID      interface{}     `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *MCPError       `json:"error,omitempty"`
+}
+
+// 只有带 id 和 method 的请求需要响应，通知和客户端的响应都不需要
+func (r MCPRequest) expectsResponse() bool {
+	return r.ID != nil && r.Method != ""
 }
 
 type MCPResponse struct {
@@ -27,9 +43,17 @@ type MCPResponse struct {
 	Error   *MCPError   `json:"error,omitempty"`
 }
 
+// MCPNotification 服务端发送给客户端的通知（没有 id）
+type MCPNotification struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // Tool definitions
@@ -37,6 +61,8 @@ type Tool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	InputSchema interface{} `json:"inputSchema"`
+	// 返回 structuredContent 的工具声明其结构
+	OutputSchema interface{} `json:"outputSchema,omitempty"`
 }
 
 type ToolInputSchema struct {
@@ -52,9 +78,18 @@ type TableInfo struct {
 }
 
 type QueryResult struct {
-	Columns []string                 `json:"columns"`
-	Rows    []map[string]interface{} `json:"rows"`
-	Count   int                      `json:"count"`
+	Columns        []string                 `json:"columns"`
+	Rows           []map[string]interface{} `json:"rows"`
+	Count          int                      `json:"count"`
+	OmittedColumns int                      `json:"omitted_columns,omitempty"`
+	ColumnTypes    []string                 `json:"-"`
+	PartialError   string                   `json:"partial_error,omitempty"`
+	// 查询未指定 LIMIT 时自动添加的行数上限，0 表示未添加
+	LimitApplied int `json:"limit_applied,omitempty"`
+	// 超过 MYSQL_RESULT_MAX_ROWS/MYSQL_RESULT_MAX_BYTES 时只保留前面的行，TotalRows 为查询实际返回的行数
+	Truncated bool          `json:"truncated,omitempty"`
+	TotalRows int           `json:"total_rows,omitempty"`
+	Duration  time.Duration `json:"-"`
 }
 
 // MySQL配置
@@ -64,17 +99,117 @@ type MySQLConfig struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	Database string `json:"database"`
+	// 结果最多返回的列数，0 表示不限制
+	MaxColumns int `json:"max_columns"`
+	// 是否在查询结果后附加执行耗时
+	IncludeTiming bool `json:"include_timing"`
+	// 允许通过 database 参数访问的其他数据库，* 表示除系统库外的全部数据库；MYSQL_DATABASE 总是允许
+	AllowedDatabases []string `json:"allowed_databases"`
+	// diff_schemas 可比较的其他 MySQL 连接，名称 -> DSN
+	DiffConnections map[string]string `json:"diff_connections"`
+	// 允许访问的表，为空表示不限制。execute_query 等接受任意 SQL 的工具按词法近似检查引用的表，
+	// 不是安全边界，需要严格隔离时应使用数据库账号权限
+	AllowedTables []string `json:"allowed_tables"`
+	// 只读模式下禁止一切写操作
+	ReadOnly bool `json:"read_only"`
+	// 是否允许 truncate_table（同时要求 ReadOnly=false）
+	AllowTruncate bool `json:"allow_truncate"`
+	// 是否允许 insert_row、update_rows、delete_rows（同时要求 ReadOnly=false）
+	AllowWrites bool `json:"allow_writes"`
+	// 是否允许 create_table、alter_table、drop_table、create_index（同时要求 ReadOnly=false）
+	AllowDDL bool `json:"allow_ddl"`
+	// 是否允许管理类操作，如查看全部状态变量
+	AllowAdmin bool `json:"allow_admin"`
+	// query_table 未指定 limit 时的默认行数
+	DefaultLimit int `json:"default_limit"`
+	// query_table 允许的最大行数
+	MaxRows int `json:"max_rows"`
+	// JSON 输出中按布尔值渲染的 TINYINT(1) 列名
+	BoolColumns []string `json:"bool_columns"`
+	// 注入到 SELECT 的 MAX_EXECUTION_TIME 优化器提示（毫秒），0 表示不注入
+	MaxExecutionTimeMs int `json:"max_execution_time_ms"`
+	// 连接池最大空闲连接数
+	MaxIdleConns int `json:"max_idle_conns"`
+	// 启动时是否预先建立 MaxIdleConns 个连接
+	PoolWarmup bool `json:"pool_warmup"`
+	// 文本结果每个内容块的最大行数，0 表示不分块
+	RowsPerBlock int `json:"rows_per_block"`
+	// 查询审计日志文件（JSON Lines），为空表示不记录
+	QueryLogFile string `json:"query_log_file"`
+	// 审计日志文件轮转大小（MB）
+	QueryLogMaxMB int `json:"query_log_max_mb"`
+	// 结构快照保存目录
+	SnapshotDir string `json:"snapshot_dir"`
+	// execute_query 中没有 LIMIT 的 SELECT 自动添加的 LIMIT，0 表示不添加
+	AutoLimit int `json:"auto_limit"`
+	// 查询超时（毫秒），超时后终止查询并返回超时错误，0 表示不限制；工具可通过 timeout_ms 缩短
+	QueryTimeoutMs int `json:"query_timeout_ms"`
+	// 查询中途出错时返回已读取的行
+	PartialOnError bool `json:"partial_on_error"`
+	// 单次查询在内存中保留的最大行数和字节数，超出部分只计数不保留，0 表示不限制
+	ResultMaxRows  int   `json:"result_max_rows"`
+	ResultMaxBytes int64 `json:"result_max_bytes"`
+	// 超过该耗时（毫秒）的查询自动 EXPLAIN 并记录执行计划，0 表示关闭
+	ExplainSlowMs int `json:"explain_slow_ms"`
+	// 允许建立 WebSocket 连接的来源（Origin），为空时只允许同源
+	AllowedOrigins []string `json:"allowed_origins"`
+	// 是否允许读取 information_schema，关闭后元数据工具不可用
+	AllowInformationSchema bool `json:"allow_information_schema"`
+	// 资源订阅检查表结构变化的间隔（秒），0 表示不检查
+	SubscriptionPollSeconds int `json:"subscription_poll_seconds"`
+	// 定期检查 MySQL 连接的间隔（秒），0 表示不检查
+	HealthCheckSeconds int `json:"health_check_seconds"`
+	// 收到退出信号后等待进行中请求的最长时间（秒）
+	ShutdownGraceSeconds int `json:"shutdown_grace_seconds"`
+	// HTTP 会话空闲多久（分钟）后过期
+	HTTPSessionIdleMinutes int `json:"http_session_idle_minutes"`
+	// 远程传输接受的静态 Bearer token
+	AuthTokens []string `json:"-"`
+	// OAuth2 授权服务器的 JWKS 地址，配置后接受其签发的 JWT 访问令牌
+	OAuthJWKSURL string `json:"oauth_jwks_url"`
+	// 要求 JWT 的 iss 和 aud，为空表示不校验
+	OAuthIssuer   string `json:"oauth_issuer"`
+	OAuthAudience string `json:"oauth_audience"`
+	// 每个会话同时执行的工具调用上限，0 表示不限制
+	SessionMaxConcurrent int `json:"session_max_concurrent"`
+	// 每个会话每分钟的工具调用上限，0 表示不限制
+	SessionQueriesPerMinute int `json:"session_queries_per_minute"`
+	// 所有会话同时执行的工具调用（即占用的数据库连接）上限，0 表示不限制
+	MaxConnections int `json:"max_connections"`
+	// 每个会话同时运行的 submit_query 任务上限，0 表示不限制
+	SessionMaxJobs int `json:"session_max_jobs"`
+	// initialize 响应中返回给客户端的使用说明
+	Instructions string `json:"instructions"`
 }
 
 type MCPServer struct {
-	db     *sql.DB
-	config MySQLConfig
+	db       *sql.DB
+	config   MySQLConfig
+	queryLog *queryLogger
+	health   dbHealth
+	// 运行时的只读状态，初始值来自 MYSQL_READ_ONLY，可通过信号切换
+	readOnly      atomic.Bool
+	toolListeners toolListListeners
+	// 全局连接上限的信号量，见 connSlots
+	slots     chan struct{}
+	slotsOnce sync.Once
+	// performance_schema 不可用时不再查询 rows_examined
+	perfSchemaUnavailable atomic.Bool
+	// submit_query 提交的后台查询任务
+	jobs queryJobs
 }
 
 func NewMCPServer() *MCPServer {
 	return &MCPServer{}
 }
 
+// NewMCPServerWithDB 使用已有的数据库连接和配置创建服务，无需调用 initDatabase
+func NewMCPServerWithDB(db *sql.DB, cfg MySQLConfig) *MCPServer {
+	s := &MCPServer{db: db, config: cfg}
+	s.readOnly.Store(cfg.ReadOnly)
+	return s
+}
+
 // 从环境变量或默认值加载配置
 func (s *MCPServer) loadConfig() {
 	s.config = MySQLConfig{
@@ -83,7 +218,66 @@ func (s *MCPServer) loadConfig() {
 		User:     getEnv("MYSQL_USER", "root"),
 		Password: getEnv("MYSQL_PASSWORD", "Aa130069711"),
 		Database: getEnv("MYSQL_DATABASE", "mcp_test"),
+
+		MaxColumns:    getEnvInt("MYSQL_MAX_COLUMNS", 0),
+		IncludeTiming: getEnvBool("MYSQL_INCLUDE_TIMING", false),
+		AllowedTables: getEnvList("MYSQL_ALLOWED_TABLES"),
+
+		AllowedDatabases: getEnvList("MYSQL_ALLOWED_DATABASES"),
+		DiffConnections:  getEnvMap("MYSQL_DIFF_CONNECTIONS"),
+
+		ReadOnly:      getEnvBool("MYSQL_READ_ONLY", true),
+		AllowTruncate: getEnvBool("MYSQL_ALLOW_TRUNCATE", false),
+		AllowWrites:   getEnvBool("MYSQL_ALLOW_WRITES", false),
+		AllowDDL:      getEnvBool("MYSQL_ALLOW_DDL", false),
+		AllowAdmin:    getEnvBool("MYSQL_ALLOW_ADMIN", false),
+		DefaultLimit:  getEnvInt("MYSQL_DEFAULT_LIMIT", 10),
+		MaxRows:       getEnvInt("MYSQL_MAX_ROWS", 1000),
+		BoolColumns:   getEnvList("MYSQL_BOOL_COLUMNS"),
+
+		MaxExecutionTimeMs: getEnvInt("MYSQL_MAX_EXECUTION_TIME_MS", 0),
+		MaxIdleConns:       getEnvInt("MYSQL_MAX_IDLE_CONNS", 2),
+		PoolWarmup:         getEnvBool("MYSQL_POOL_WARMUP", false),
+		RowsPerBlock:       getEnvInt("MYSQL_ROWS_PER_BLOCK", 0),
+		QueryLogFile:       getEnv("MYSQL_QUERY_LOG_FILE", ""),
+		QueryLogMaxMB:      getEnvInt("MYSQL_QUERY_LOG_MAX_MB", 100),
+		SnapshotDir:        getEnv("MYSQL_SNAPSHOT_DIR", "schema-snapshots"),
+		PartialOnError:     getEnvBool("MYSQL_PARTIAL_ON_ERROR", false),
+		QueryTimeoutMs:     getEnvInt("MYSQL_QUERY_TIMEOUT_MS", 0),
+		AutoLimit:          getEnvInt("MYSQL_AUTO_LIMIT", 1000),
+		ResultMaxRows:      getEnvInt("MYSQL_RESULT_MAX_ROWS", 10000),
+		ResultMaxBytes:     int64(getEnvInt("MYSQL_RESULT_MAX_BYTES", 16<<20)),
+		ExplainSlowMs:      getEnvInt("MYSQL_EXPLAIN_SLOW_MS", 0),
+		AllowedOrigins:     getEnvList("MYSQL_ALLOWED_ORIGINS"),
+
+		AllowInformationSchema:  getEnvBool("MYSQL_ALLOW_INFORMATION_SCHEMA", true),
+		SubscriptionPollSeconds: getEnvInt("MYSQL_SUBSCRIPTION_POLL_SECONDS", 10),
+		HealthCheckSeconds:      getEnvInt("MYSQL_HEALTH_CHECK_SECONDS", 30),
+		ShutdownGraceSeconds:    getEnvInt("MYSQL_SHUTDOWN_GRACE_SECONDS", 10),
+		HTTPSessionIdleMinutes:  getEnvInt("MYSQL_HTTP_SESSION_IDLE_MINUTES", 30),
+		AuthTokens:              getEnvList("MYSQL_AUTH_TOKENS"),
+		OAuthJWKSURL:            getEnv("MYSQL_OAUTH_JWKS_URL", ""),
+		OAuthIssuer:             getEnv("MYSQL_OAUTH_ISSUER", ""),
+		OAuthAudience:           getEnv("MYSQL_OAUTH_AUDIENCE", ""),
+		SessionMaxConcurrent:    getEnvInt("MYSQL_SESSION_MAX_CONCURRENT", 4),
+		SessionQueriesPerMinute: getEnvInt("MYSQL_SESSION_QUERIES_PER_MINUTE", 0),
+		MaxConnections:          getEnvInt("MYSQL_MAX_CONNECTIONS", 0),
+		SessionMaxJobs:          getEnvInt("MYSQL_SESSION_MAX_JOBS", 2),
+		Instructions:            getEnv("MYSQL_INSTRUCTIONS", ""),
 	}
+	// 较长的说明可以放在文件中
+	if path := getEnv("MYSQL_INSTRUCTIONS_FILE", ""); path != "" {
+		if data, err := os.ReadFile(path); err != nil {
+			log.Printf("读取 MYSQL_INSTRUCTIONS_FILE 失败: %v", err)
+		} else {
+			s.config.Instructions = strings.TrimSpace(string(data))
+		}
+	}
+	// 兼容旧的 MYSQL_WS_ALLOWED_ORIGINS
+	if len(s.config.AllowedOrigins) == 0 {
+		s.config.AllowedOrigins = getEnvList("MYSQL_WS_ALLOWED_ORIGINS")
+	}
+	s.readOnly.Store(s.config.ReadOnly)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -102,9 +296,66 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// 读取 name=value;name2=value2 形式的映射，DSN 中可能含逗号，因此用分号分隔
+func getEnvMap(key string) map[string]string {
+	m := make(map[string]string)
+	for _, item := range strings.Split(os.Getenv(key), ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if ok && name != "" {
+			m[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return m
+}
+
+// 读取逗号分隔的列表，忽略空项
+func getEnvList(key string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// 判断表是否在 MYSQL_ALLOWED_TABLES 允许范围内
+func (s *MCPServer) isTableAllowed(tableName string) bool {
+	if len(s.config.AllowedTables) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.AllowedTables {
+		if strings.EqualFold(allowed, tableName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MCPServer) tableNotAllowed(id interface{}, tableName string) MCPResponse {
+	return s.errorResponse(id, fmt.Sprintf("不允许访问表 '%s'", tableName))
+}
+
 func (s *MCPServer) initDatabase() error {
 	s.loadConfig()
 
+	if s.config.QueryLogFile != "" {
+		var err error
+		s.queryLog, err = newQueryLogger(s.config.QueryLogFile, s.config.QueryLogMaxMB)
+		if err != nil {
+			return fmt.Errorf("打开查询日志失败: %v", err)
+		}
+	}
+
 	// 构建MySQL连接字符串
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=true",
 		s.config.User,
@@ -119,12 +370,24 @@ func (s *MCPServer) initDatabase() error {
 	if err != nil {
 		return fmt.Errorf("连接数据库失败: %v", err)
 	}
+	s.db.SetMaxIdleConns(s.config.MaxIdleConns)
+	if s.config.MaxConnections > 0 {
+		s.db.SetMaxOpenConns(s.config.MaxConnections + reservedConns)
+	}
 
 	// 测试连接
 	if err = s.db.Ping(); err != nil {
 		return fmt.Errorf("数据库连接测试失败: %v", err)
 	}
 
+	if s.config.PoolWarmup {
+		s.warmupPool(context.Background(), s.config.MaxIdleConns)
+	}
+
+	if s.config.HealthCheckSeconds > 0 {
+		go s.monitorConnection(context.Background())
+	}
+
 	// 创建示例表和数据
 	err = s.createSampleTables()
 	if err != nil {
@@ -188,110 +451,124 @@ func (s *MCPServer) createSampleTables() error {
 	return err
 }
 
-func (s *MCPServer) handleRequest(req MCPRequest) MCPResponse {
+// 支持的 MCP 协议版本，第一个为默认（最新）版本
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// 客户端请求的版本受支持时原样返回，否则返回服务端最新版本
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range supportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return supportedProtocolVersions[0]
+}
+
+func (s *MCPServer) handleRequest(ctx context.Context, req MCPRequest) MCPResponse {
+	// 客户端对服务端请求（如 elicitation）的响应
+	if req.Method == "" {
+		if c := clientRequestsFrom(ctx); c != nil && req.ID != nil {
+			c.deliver(req)
+		}
+		return MCPResponse{Jsonrpc: "2.0"}
+	}
+
+	// 没有 id 的消息是通知，按 JSON-RPC 规范不返回响应，调用方应丢弃返回值
+	if req.ID == nil {
+		s.handleNotification(ctx, req)
+		return MCPResponse{Jsonrpc: "2.0"}
+	}
+
+	// 记录正在执行的请求，以便通过 notifications/cancelled 取消
+	if tracker := inflightFrom(ctx); tracker != nil {
+		var done func()
+		ctx, done = tracker.start(ctx, req.ID)
+		defer done()
+	}
+
 	switch req.Method {
 	case "initialize":
-		return MCPResponse{
-			Jsonrpc: "2.0",
-			ID:      req.ID,
-			Result: map[string]interface{}{
-				"protocolVersion": "2024-11-05",
-				"capabilities": map[string]interface{}{
-					"tools": map[string]interface{}{},
-				},
-				"serverInfo": map[string]interface{}{
-					"name":    "mysql-mcp-server",
-					"version": "1.0.0",
-				},
-			},
+		var params struct {
+			ProtocolVersion string                 `json:"protocolVersion"`
+			Capabilities    map[string]interface{} `json:"capabilities"`
+		}
+		json.Unmarshal(req.Params, &params)
+		if c := clientRequestsFrom(ctx); c != nil {
+			c.setCapabilities(params.Capabilities)
 		}
 
-	case "tools/list":
-		tools := []Tool{
-			{
-				Name:        "list_tables",
-				Description: "列出数据库中的所有表",
-				InputSchema: ToolInputSchema{
-					Type:       "object",
-					Properties: map[string]interface{}{},
-				},
-			},
-			{
-				Name:        "describe_table",
-				Description: "获取指定表的结构信息",
-				InputSchema: ToolInputSchema{
-					Type: "object",
-					Properties: map[string]interface{}{
-						"table_name": map[string]interface{}{
-							"type":        "string",
-							"description": "表名",
-						},
-					},
-					Required: []string{"table_name"},
-				},
-			},
-			{
-				Name:        "query_table",
-				Description: "查询表数据",
-				InputSchema: ToolInputSchema{
-					Type: "object",
-					Properties: map[string]interface{}{
-						"table_name": map[string]interface{}{
-							"type":        "string",
-							"description": "表名",
-						},
-						"limit": map[string]interface{}{
-							"type":        "integer",
-							"description": "限制返回行数，默认10",
-						},
-						"where_clause": map[string]interface{}{
-							"type":        "string",
-							"description": "WHERE条件子句（可选）",
-						},
-					},
-					Required: []string{"table_name"},
+		result := map[string]interface{}{
+			"protocolVersion": negotiateProtocolVersion(params.ProtocolVersion),
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{
+					"listChanged": true,
 				},
-			},
-			{
-				Name:        "execute_query",
-				Description: "执行自定义SQL查询（仅SELECT语句）",
-				InputSchema: ToolInputSchema{
-					Type: "object",
-					Properties: map[string]interface{}{
-						"query": map[string]interface{}{
-							"type":        "string",
-							"description": "SQL查询语句",
-						},
-					},
-					Required: []string{"query"},
+				"resources": map[string]interface{}{
+					"subscribe": true,
 				},
+				"prompts":     map[string]interface{}{},
+				"logging":     map[string]interface{}{},
+				"completions": map[string]interface{}{},
 			},
-			{
-				Name:        "show_table_indexes",
-				Description: "显示表的索引信息",
-				InputSchema: ToolInputSchema{
-					Type: "object",
-					Properties: map[string]interface{}{
-						"table_name": map[string]interface{}{
-							"type":        "string",
-							"description": "表名",
-						},
-					},
-					Required: []string{"table_name"},
-				},
+			"serverInfo": map[string]interface{}{
+				"name":    "mysql-mcp-server",
+				"version": "1.0.0",
 			},
 		}
+		if s.config.Instructions != "" {
+			result["instructions"] = s.config.Instructions
+		}
 
 		return MCPResponse{
 			Jsonrpc: "2.0",
 			ID:      req.ID,
-			Result: map[string]interface{}{
-				"tools": tools,
-			},
+			Result:  result,
+		}
+
+	case "ping":
+		return MCPResponse{Jsonrpc: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+
+	case "tools/list":
+		tools, nextCursor, err := paginate(req.Params, s.listTools())
+		if err != nil {
+			return invalidCursorResponse(req.ID)
+		}
+
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Result:  pagedResult("tools", tools, nextCursor),
 		}
 
 	case "tools/call":
-		return s.handleToolCall(req)
+		return s.handleToolCall(ctx, req)
+
+	case "resources/list":
+		return s.listResources(ctx, req)
+
+	case "resources/read":
+		return s.readResource(ctx, req)
+
+	case "resources/subscribe":
+		return s.subscribeResource(ctx, req)
+
+	case "resources/unsubscribe":
+		return s.unsubscribeResource(ctx, req)
+
+	case "resources/templates/list":
+		return s.listResourceTemplates(req)
+
+	case "prompts/list":
+		return s.listPrompts(req)
+
+	case "prompts/get":
+		return s.getPrompt(ctx, req)
+
+	case "completion/complete":
+		return s.complete(ctx, req)
+
+	case "logging/setLevel":
+		return s.setLogLevel(ctx, req)
 
 	default:
 		return MCPResponse{
@@ -305,64 +582,1962 @@ func (s *MCPServer) handleRequest(req MCPRequest) MCPResponse {
 	}
 }
 
-func (s *MCPServer) handleToolCall(req MCPRequest) MCPResponse {
-	var params struct {
-		Name      string                 `json:"name"`
-		Arguments map[string]interface{} `json:"arguments"`
+// 处理客户端发来的通知
+func (s *MCPServer) handleNotification(ctx context.Context, req MCPRequest) {
+	switch req.Method {
+	case "notifications/initialized":
+		s.logEvent(ctx, "debug", "客户端初始化完成")
+
+	case "notifications/cancelled":
+		s.handleCancelled(ctx, req)
+
+	case "notifications/roots/list_changed":
+		// 服务端不使用 roots，忽略
+
+	default:
+		s.logEvent(ctx, "debug", "忽略未知通知: %s", req.Method)
 	}
+}
 
-	if err := json.Unmarshal(req.Params, &params); err != nil {
-		return MCPResponse{
-			Jsonrpc: "2.0",
-			ID:      req.ID,
-			Error: &MCPError{
-				Code:    -32602,
-				Message: "Invalid params",
+// 所有工具的定义
+func (s *MCPServer) toolDefinitions() []Tool {
+	return []Tool{
+		{
+			Name:        "list_tables",
+			Description: "列出数据库中的所有表",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "use_database",
+			Description: "切换当前会话的默认数据库，之后的查询和表工具无需再限定数据库名（数据库需在 MYSQL_ALLOWED_DATABASES 中）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名",
+					},
+				},
+				Required: []string{"database"},
+			},
+		},
+		{
+			Name:        "list_databases",
+			Description: "列出允许访问的数据库，其他工具可通过 database 参数访问这些数据库",
+			InputSchema: ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "describe_table",
+			Description: "获取指定表的结构信息",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "show_create_table",
+			Description: "获取表或视图完整的 CREATE 语句（SHOW CREATE TABLE），包括约束、分区和表选项，适合编写迁移",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "list_foreign_keys",
+			Description: "列出外键关系（包括 ON DELETE/ON UPDATE 规则）；指定表时返回该表引用和被引用的外键，否则返回整个数据库的外键",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名（可选）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "generate_er_diagram",
+			Description: "根据表结构和外键生成 Mermaid erDiagram，客户端可直接渲染为 ER 图；指定表时只包含该表及与其直接关联的表",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "只包含该表及其相邻表（可选）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "search_columns",
+			Description: "在整个数据库中查找表名、列名或列注释包含指定文本的列，例如查找邮箱存放在哪张表",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "要查找的文本（不区分大小写的子串匹配）",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+		{
+			Name:         "count_rows",
+			Description:  "统计表的行数：estimated（默认）读取 information_schema 的预估值，速度快；exact 使用 COUNT(*)，预估超过 100 万行的表需要 force=true",
+			OutputSchema: countRowsOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名，为空时统计全部表",
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"estimated", "exact"},
+						"description": "统计方式，默认 estimated",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "exact 模式下也统计大表",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "column_histogram",
+			Description: "把数值或日期时间列按最小值到最大值等宽分桶，返回每个桶的行数，用于快速查看数据分布",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"column_name": map[string]interface{}{
+						"type":        "string",
+						"description": "数值、DATE、DATETIME 或 TIMESTAMP 列",
+					},
+					"bins": map[string]interface{}{
+						"type":        "integer",
+						"description": "分桶数，默认 10，最多 100",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"table_name", "column_name"},
+			},
+		},
+		{
+			Name:        "profile_column",
+			Description: "分析列的数据质量：NULL 比例、不同值数量、最小/最大值、平均长度和出现最多的值（需要扫描全表）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"column_name": map[string]interface{}{
+						"type":        "string",
+						"description": "列名",
+					},
+					"top_k": map[string]interface{}{
+						"type":        "integer",
+						"description": "返回出现最多的值的数量，默认 10，最多 50，0 表示不统计",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"table_name", "column_name"},
+			},
+		},
+		{
+			Name:         "sample_rows",
+			Description:  "随机抽取表中的若干行，用于了解真实数据的形态；小表使用 ORDER BY RAND()，大表按主键区间抽样，避免全表扫描",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"size": map[string]interface{}{
+						"type":        "integer",
+						"description": "抽样行数，默认 10，最多 100",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "list_routines",
+			Description: "列出数据库中的存储过程和函数，包括参数、返回类型和注释",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"routine_type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"procedure", "function"},
+						"description": "只列出存储过程或函数，默认两者都列出",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "show_routine",
+			Description: "查看存储过程或函数的定义（SHOW CREATE PROCEDURE/FUNCTION）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"routine_name": map[string]interface{}{
+						"type":        "string",
+						"description": "存储过程或函数名",
+					},
+					"routine_type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"procedure", "function"},
+						"description": "例程类型，默认 procedure",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"routine_name"},
+			},
+		},
+		{
+			Name:        "list_views",
+			Description: "列出数据库中的视图，以及是否可更新和 SQL SECURITY 类型",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "describe_view",
+			Description: "查看视图的定义、依赖的表以及是否可更新",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"view_name": map[string]interface{}{
+						"type":        "string",
+						"description": "视图名",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"view_name"},
+			},
+		},
+		{
+			Name:        "list_triggers",
+			Description: "列出表上的触发器及其触发时机（BEFORE/AFTER）、事件（INSERT/UPDATE/DELETE）和触发器体，用于了解写入时的副作用",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名，为空时列出全部表的触发器",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_events",
+			Description: "列出数据库中的定时事件（EVENT），包括调度、状态、上次执行时间和事件体",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "call_procedure",
+			Description: "调用存储过程并返回其全部结果集，参数通过绑定传入；存储过程可能修改数据，执行前会请求用户确认",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"procedure_name": map[string]interface{}{
+						"type":        "string",
+						"description": "存储过程名",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序传入的 IN 参数（字符串、数字、布尔值或 null）",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"procedure_name"},
+			},
+		},
+		{
+			Name:        "partition_info",
+			Description: "查看分区表的分区方式、分区表达式、各分区的边界、预估行数和数据/索引大小",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名，为空时列出全部分区表",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "table_stats",
+			Description: "查看表的存储统计：引擎、预估行数、平均行长、数据大小、索引大小、AUTO_INCREMENT 下一个值和排序规则，按总大小降序",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名，为空时列出全部表",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:         "query_table",
+			Description:  "查询表数据（空间类型列自动以 ST_AsText 转为 WKT 文本）",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "限制返回行数，默认 MYSQL_DEFAULT_LIMIT（10），不超过 MYSQL_MAX_ROWS",
+					},
+					"where_clause": map[string]interface{}{
+						"type":        "string",
+						"description": "WHERE条件子句（可选）",
+					},
+					"timeout_ms": map[string]interface{}{
+						"type":        "integer",
+						"description": "查询超时（毫秒），不超过 MYSQL_QUERY_TIMEOUT_MS",
+					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "分页读取时每页行数，按主键排序（表必须有主键），传入后忽略 limit，不超过 MYSQL_MAX_ROWS",
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "上一页返回的 cursor，需与 page_size 一起使用",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:         "execute_query",
+			Description:  "执行自定义SQL查询（仅SELECT语句），用户提供的值应通过 params 绑定到 ? 占位符，不要拼接进 SQL",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL查询语句",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序绑定到 query 中 ? 占位符的值（字符串、数字、布尔或 null）",
+					},
+					"timeout_ms": map[string]interface{}{
+						"type":        "integer",
+						"description": "查询超时（毫秒），不超过 MYSQL_QUERY_TIMEOUT_MS",
+					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "分页读取时每页行数，仅支持带 ORDER BY 的 SELECT（否则各页可能重复或遗漏行）；查询自带 LIMIT 时结果列名不能重复；不超过 MYSQL_MAX_ROWS",
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "上一页返回的 cursor，需与 page_size 一起使用",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "explain_query",
+			Description: "查看 SELECT 查询的执行计划（EXPLAIN FORMAT=JSON，不支持时退回普通 EXPLAIN），返回预估成本、各表的访问方式、使用的索引和预估行数，不会真正执行查询",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SELECT 查询语句",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序绑定到 query 中 ? 占位符的值（字符串、数字、布尔或 null）",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "explain_analyze",
+			Description: "使用 EXPLAIN ANALYZE 实际执行 SELECT 查询并返回每个计划节点的实际耗时、行数和循环次数（需要 MySQL 8.0.18+，只允许 SELECT）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SELECT 查询语句",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序绑定到 query 中 ? 占位符的值（字符串、数字、布尔或 null）",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "show_table_indexes",
+			Description: "显示表的索引信息",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				},
+				Required: []string{"table_name"},
 			},
+		},
+		{
+			Name:        "query_matching_tables",
+			Description: "对名称匹配模式的每张表执行同一个查询模板（适用于分表场景），结果中附带 _table 列",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "表名匹配模式（LIKE 语法），如 orders_%",
+					},
+					"template": map[string]interface{}{
+						"type":        "string",
+						"description": "查询模板，使用 {table} 作为表名占位符，如 SELECT COUNT(*) AS cnt FROM {table}",
+					},
+				},
+				Required: []string{"pattern", "template"},
+			},
+		},
+		{
+			Name:         "check_unique",
+			Description:  "检查候选数据在指定列（或列组合）上是否已存在，用于写入前判断是否会违反唯一约束",
+			OutputSchema: checkUniqueOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"columns": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "唯一约束涉及的列",
+					},
+					"values": map[string]interface{}{
+						"type":        "array",
+						"description": "与 columns 一一对应的候选值",
+					},
+				},
+				Required: []string{"table_name", "columns", "values"},
+			},
+		},
+		{
+			Name:         "find_duplicates",
+			Description:  "按指定列分组查找重复数据，返回重复次数大于 1 的组、重复次数以及每组的若干主键样例，便于数据清洗",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"columns": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "判断重复的列，值全部相同的行视为重复（NULL 与 NULL 视为相同）",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "返回的重复组数，按重复次数降序，默认 20，最多 100",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+				Required: []string{"table_name", "columns"},
+			},
+		},
+		{
+			Name:        "distinct_count",
+			Description: "统计列的不同值数量；指定 sample_percent 时按随机抽样估算（近似值）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"column_name": map[string]interface{}{
+						"type":        "string",
+						"description": "列名",
+					},
+					"sample_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "抽样百分比 (0-100)，不传则精确统计",
+					},
+				},
+				Required: []string{"table_name", "column_name"},
+			},
+		},
+		{
+			Name:        "assert_query",
+			Description: "执行只读查询并与期望结果比较，返回是否一致以及差异",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL查询语句",
+					},
+					"expected": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "object"},
+						"description": "期望的行，每行是 列名->值 的对象",
+					},
+					"ordered": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否要求行顺序一致，默认 false",
+					},
+				},
+				Required: []string{"query", "expected"},
+			},
+		},
+		{
+			Name:         "search_in_table",
+			Description:  "在表的所有文本列中搜索包含指定字符串的行",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"search_term": map[string]interface{}{
+						"type":        "string",
+						"description": "要搜索的字符串",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "限制返回行数，默认 MYSQL_DEFAULT_LIMIT，不超过 MYSQL_MAX_ROWS",
+					},
+				},
+				Required: []string{"table_name", "search_term"},
+			},
+		},
+		{
+			Name:        "pluck",
+			Description: "执行只返回一列的查询，把结果输出为按行分隔的值列表（或 JSON 数组）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "只返回一列的SQL查询语句",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"lines", "json"},
+						"description": "输出格式，默认 lines",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:         "exists",
+			Description:  "判断表中是否存在满足条件的行，返回 true/false（比 COUNT 更快）",
+			OutputSchema: existsOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"filters": map[string]interface{}{
+						"type":        "object",
+						"description": "过滤条件，列名->值，多个条件之间为 AND；值为 null 时匹配 IS NULL",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:         "check_fk",
+			Description:  "检查外键列的候选值在被引用表中是否存在，用于写入前校验",
+			OutputSchema: checkFKOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "子表名",
+					},
+					"column_name": map[string]interface{}{
+						"type":        "string",
+						"description": "子表中的外键列",
+					},
+					"value": map[string]interface{}{
+						"description": "候选值",
+					},
+				},
+				Required: []string{"table_name", "column_name", "value"},
+			},
+		},
+		{
+			Name:         "pivot",
+			Description:  "生成透视表：按 row 列分组，column 列的每个不同值成为一列，单元格为计数或求和",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"row": map[string]interface{}{
+						"type":        "string",
+						"description": "作为行的列",
+					},
+					"column": map[string]interface{}{
+						"type":        "string",
+						"description": "其不同值作为透视列的列",
+					},
+					"agg": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"count", "sum"},
+						"description": "聚合方式，默认 count",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "agg 为 sum 时求和的列",
+					},
+				},
+				Required: []string{"table_name", "row", "column"},
+			},
+		},
+		{
+			Name:        "join_count",
+			Description: "统计两张表按指定列 JOIN 后匹配的行数，用于在取数前判断关联基数",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"left_table": map[string]interface{}{
+						"type":        "string",
+						"description": "左表名",
+					},
+					"left_column": map[string]interface{}{
+						"type":        "string",
+						"description": "左表关联列",
+					},
+					"right_table": map[string]interface{}{
+						"type":        "string",
+						"description": "右表名",
+					},
+					"right_column": map[string]interface{}{
+						"type":        "string",
+						"description": "右表关联列",
+					},
+				},
+				Required: []string{"left_table", "left_column", "right_table", "right_column"},
+			},
+		},
+		{
+			Name:         "keyset_page",
+			Description:  "按主键做 keyset 分页读取表数据，返回本页数据和下一页的 next_after 游标",
+			OutputSchema: keysetPageOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"key_column": map[string]interface{}{
+						"type":        "string",
+						"description": "用于排序和分页的键列，必须是主键或单列唯一索引",
+					},
+					"after": map[string]interface{}{
+						"description": "上一页返回的 next_after，首页不传",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "每页行数，默认 MYSQL_DEFAULT_LIMIT，不超过 MYSQL_MAX_ROWS",
+					},
+				},
+				Required: []string{"table_name", "key_column"},
+			},
+		},
+		{
+			Name:        "submit_query",
+			Description: "在后台执行耗时较长的分析查询（仅SELECT语句），立即返回任务 ID，之后用 query_status 查看进度、fetch_results 分页读取结果、cancel_query 取消；每个会话同时运行的任务数受 MYSQL_SESSION_MAX_JOBS 限制",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL查询语句",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序绑定到 query 中 ? 占位符的值（字符串、数字、布尔或 null）",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "query_status",
+			Description: "查看 submit_query 任务的状态（running、completed、failed、cancelled）、已读取行数和耗时",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"job_id": map[string]interface{}{
+						"type":        "string",
+						"description": "submit_query 返回的任务 ID",
+					},
+				},
+				Required: []string{"job_id"},
+			},
+		},
+		{
+			Name:         "fetch_results",
+			Description:  "分页读取已完成的 submit_query 任务结果，任务结束 30 分钟后过期；结果按 MYSQL_RESULT_MAX_ROWS、MYSQL_RESULT_MAX_BYTES 截断",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"job_id": map[string]interface{}{
+						"type":        "string",
+						"description": "submit_query 返回的任务 ID",
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "上一页返回的 cursor，为空时从第一行开始",
+					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "每页行数，默认 100，不超过 MYSQL_MAX_ROWS",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+				},
+				Required: []string{"job_id"},
+			},
+		},
+		{
+			Name:        "cancel_query",
+			Description: "取消仍在运行的 submit_query 任务，服务端正在执行的查询会被终止",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"job_id": map[string]interface{}{
+						"type":        "string",
+						"description": "submit_query 返回的任务 ID",
+					},
+				},
+				Required: []string{"job_id"},
+			},
+		},
+		{
+			Name:        "truncate_table",
+			Description: "清空表数据（需要 MYSQL_READ_ONLY=false 且 MYSQL_ALLOW_TRUNCATE=true，并二次确认表名）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "必须为 true",
+					},
+					"confirm_table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "再次输入表名，必须与 table_name 完全一致",
+					},
+				},
+				Required: []string{"table_name", "confirm", "confirm_table_name"},
+			},
+		},
+		{
+			Name:        "insert_row",
+			Description: "向表中插入一行（需要 --allow-writes 或 MYSQL_ALLOW_WRITES=true），返回影响行数和自增 ID",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"values": map[string]interface{}{
+						"type":        "object",
+						"description": "列名->值",
+					},
+				},
+				Required: []string{"table_name", "values"},
+			},
+		},
+		{
+			Name:        "update_rows",
+			Description: "按过滤条件更新表中的行（需要 --allow-writes 或 MYSQL_ALLOW_WRITES=true），返回影响行数",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"set": map[string]interface{}{
+						"type":        "object",
+						"description": "要更新的列名->新值",
+					},
+					"filters": map[string]interface{}{
+						"type":        "object",
+						"description": "过滤条件，列名->值，多个条件之间为 AND；值为 null 时匹配 IS NULL；不能为空",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"table_name", "set", "filters"},
+			},
+		},
+		{
+			Name:        "delete_rows",
+			Description: "按过滤条件删除表中的行（需要 --allow-writes 或 MYSQL_ALLOW_WRITES=true），返回影响行数",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"filters": map[string]interface{}{
+						"type":        "object",
+						"description": "过滤条件，列名->值，多个条件之间为 AND；值为 null 时匹配 IS NULL；不能为空",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"table_name", "filters"},
+			},
+		},
+		{
+			Name:        "execute_transaction",
+			Description: "在一个事务中按顺序执行多条 SELECT/INSERT/UPDATE/DELETE/REPLACE 语句，任一语句失败即整体回滚（需要 --allow-writes 或 MYSQL_ALLOW_WRITES=true），返回每条语句的结果。UPDATE/DELETE 必须带 WHERE 条件，执行前会列出全部语句请求用户确认",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"statements": map[string]interface{}{
+						"type":        "array",
+						"description": "按顺序执行的语句",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"sql": map[string]interface{}{
+									"type":        "string",
+									"description": "SQL 语句",
+								},
+								"params": map[string]interface{}{
+									"type":        "array",
+									"description": "按顺序绑定到 ? 占位符的值",
+								},
+							},
+							"required": []string{"sql"},
+						},
+					},
+					"isolation_level": map[string]interface{}{
+						"type":        "string",
+						"description": "事务隔离级别，默认使用服务器设置",
+						"enum":        []string{"READ UNCOMMITTED", "READ COMMITTED", "REPEATABLE READ", "SERIALIZABLE"},
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"statements"},
+			},
+		},
+		{
+			Name:        "create_table",
+			Description: "创建表（需要 --allow-ddl 或 MYSQL_ALLOW_DDL=true）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"columns": map[string]interface{}{
+						"type":        "array",
+						"description": "列定义",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":           map[string]interface{}{"type": "string"},
+								"type":           map[string]interface{}{"type": "string", "description": "列类型，如 INT UNSIGNED、VARCHAR(255)、DECIMAL(10,2)"},
+								"nullable":       map[string]interface{}{"type": "boolean", "description": "默认 true"},
+								"default":        map[string]interface{}{"description": "默认值（可选）"},
+								"auto_increment": map[string]interface{}{"type": "boolean"},
+								"comment":        map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"name", "type"},
+						},
+					},
+					"primary_key": map[string]interface{}{
+						"type":        "array",
+						"description": "主键列（可选）",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"if_not_exists": map[string]interface{}{
+						"type":        "boolean",
+						"description": "表已存在时不报错",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "表注释（可选）",
+					},
+				},
+				Required: []string{"table_name", "columns"},
+			},
+		},
+		{
+			Name:        "alter_table",
+			Description: "修改表结构：添加、修改或删除列（需要 --allow-ddl 或 MYSQL_ALLOW_DDL=true）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"add_columns": map[string]interface{}{
+						"type":        "array",
+						"description": "要添加的列",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":           map[string]interface{}{"type": "string"},
+								"type":           map[string]interface{}{"type": "string", "description": "列类型，如 INT UNSIGNED、VARCHAR(255)、DECIMAL(10,2)"},
+								"nullable":       map[string]interface{}{"type": "boolean", "description": "默认 true"},
+								"default":        map[string]interface{}{"description": "默认值（可选）"},
+								"auto_increment": map[string]interface{}{"type": "boolean"},
+								"comment":        map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"name", "type"},
+						},
+					},
+					"modify_columns": map[string]interface{}{
+						"type":        "array",
+						"description": "要修改的列（按新定义整体替换）",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":           map[string]interface{}{"type": "string"},
+								"type":           map[string]interface{}{"type": "string", "description": "列类型，如 INT UNSIGNED、VARCHAR(255)、DECIMAL(10,2)"},
+								"nullable":       map[string]interface{}{"type": "boolean", "description": "默认 true"},
+								"default":        map[string]interface{}{"description": "默认值（可选）"},
+								"auto_increment": map[string]interface{}{"type": "boolean"},
+								"comment":        map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"name", "type"},
+						},
+					},
+					"drop_columns": map[string]interface{}{
+						"type":        "array",
+						"description": "要删除的列名",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "drop_table",
+			Description: "删除表（需要 --allow-ddl 或 MYSQL_ALLOW_DDL=true，并二次确认表名）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"confirm_table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "再次输入表名，必须与 table_name 完全一致",
+					},
+					"if_exists": map[string]interface{}{
+						"type":        "boolean",
+						"description": "表不存在时不报错",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"table_name", "confirm_table_name"},
+			},
+		},
+		{
+			Name:        "create_index",
+			Description: "在表上创建索引（需要 --allow-ddl 或 MYSQL_ALLOW_DDL=true）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+					"index_name": map[string]interface{}{
+						"type":        "string",
+						"description": "索引名",
+					},
+					"columns": map[string]interface{}{
+						"type":        "array",
+						"description": "索引列，按顺序组成联合索引",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"unique": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否唯一索引",
+					},
+				},
+				Required: []string{"table_name", "index_name", "columns"},
+			},
+		},
+		{
+			Name:        "server_status",
+			Description: "查看 MySQL 服务器状态计数器（运行时间、连接数、查询数等）；传入 like 查看全部状态需要 MYSQL_ALLOW_ADMIN=true",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"like": map[string]interface{}{
+						"type":        "string",
+						"description": "状态变量名过滤（LIKE 语法），如 Innodb_%（可选）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "replication_status",
+			Description: "查看复制状态：作为副本时各通道的 IO/SQL 线程状态、延迟秒数、GTID 集合和最近错误，以及本实例的二进制日志位置",
+			InputSchema: ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "innodb_status",
+			Description: "查看 SHOW ENGINE INNODB STATUS 并按节拆分（最近死锁、事务、缓冲池、行操作等），用于排查死锁和锁等待；未设置 MYSQL_ALLOW_ADMIN=true 时语句中的字面量会脱敏",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"sections": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "只返回这些节，如 [\"LATEST DETECTED DEADLOCK\", \"BUFFER POOL AND MEMORY\", \"ROW OPERATIONS\"]，默认全部",
+					},
+				},
+			},
+		},
+		{
+			Name:         "statement_digest",
+			Description:  "汇总 performance_schema 中按语句模板（digest）统计的负载：总耗时、平均/最大耗时、扫描行数与返回行数之比、临时表和未使用索引的次数，找出最值得优化的查询",
+			OutputSchema: queryResultOutputSchema,
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"order_by": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"total_latency", "avg_latency", "exec_count", "rows_examined", "tmp_disk_tables"},
+						"description": "排序方式，默认 total_latency",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "返回的语句数，默认 10，最多 100",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "json"},
+						"description": "输出格式，默认 text",
+					},
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "show_variables",
+			Description: "查看 MySQL 系统变量（配置项），支持按名称过滤，如 innodb%",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"like": map[string]interface{}{
+						"type":        "string",
+						"description": "变量名过滤（LIKE 语法），如 innodb_buffer%，默认全部",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"global", "session"},
+						"description": "全局变量或会话变量，默认 global",
+					},
+				},
+			},
+		},
+		{
+			Name:        "show_processlist",
+			Description: "查看 MySQL 当前的连接和正在执行的语句，按执行时间降序；未设置 MYSQL_ALLOW_ADMIN=true 时语句中的字面量会脱敏",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"user": map[string]interface{}{
+						"type":        "string",
+						"description": "只显示该用户的连接（可选）",
+					},
+					"db": map[string]interface{}{
+						"type":        "string",
+						"description": "只显示使用该数据库的连接（可选）",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "按状态过滤（LIKE 语法），如 Sending%（可选）",
+					},
+					"include_idle": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否包含空闲（Sleep）连接，默认 false",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_users",
+			Description: "列出 MySQL 账号及其权限（SHOW GRANTS），用于安全审查；需要 MYSQL_ALLOW_ADMIN=true 且连接账号能读取 mysql.user",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"user": map[string]interface{}{
+						"type":        "string",
+						"description": "用户名过滤（LIKE 语法）（可选）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "kill_query",
+			Description: "终止指定连接正在执行的语句（KILL QUERY），连接本身保留；连接 ID 可通过 show_processlist 查看。需要 MYSQL_ALLOW_ADMIN=true",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"process_id": map[string]interface{}{
+						"type":        "integer",
+						"description": "连接 ID（show_processlist 的 ID 列）",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"process_id"},
+			},
+		},
+		{
+			Name:        "kill_connection",
+			Description: "断开指定连接（KILL CONNECTION），其未提交的事务会回滚；连接 ID 可通过 show_processlist 查看。需要 MYSQL_ALLOW_ADMIN=true",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"process_id": map[string]interface{}{
+						"type":        "integer",
+						"description": "连接 ID（show_processlist 的 ID 列）",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "客户端不支持 elicitation 时必须为 true，表示调用方已确认执行",
+					},
+				},
+				Required: []string{"process_id"},
+			},
+		},
+		{
+			Name:        "connection_test",
+			Description: "多次 ping 数据库，报告最小/平均/最大往返延迟以及服务器版本和当前数据库",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "ping 次数，默认 5，最多 20",
+					},
+				},
+			},
+		},
+		{
+			Name:        "sql_mode",
+			Description: "查看当前会话的 sql_mode，拆分为各个标志并说明影响 GROUP BY 和零日期处理的选项",
+			InputSchema: ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "collation_audit",
+			Description: "检查字符集/排序规则与表或库默认值不一致的表和列（如 utf8mb4 库中的 latin1 列），提前发现 JOIN 隐式转换和 Illegal mix of collations 问题",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "compact_schema",
+			Description: "以紧凑格式返回所有表结构，每张表一行，如 users(id PK, name, email UQ)，节省上下文",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "export_schema",
+			Description: "导出数据库的完整 DDL 脚本：表（按外键依赖排序）、视图、存储过程/函数和触发器，可用于初始化开发库或纳入版本控制比对",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "diff_schemas",
+			Description: "逐列、逐索引比较两个数据库的结构（同一服务器上的两个库，或 MYSQL_DIFF_CONNECTIONS 中配置的其他连接），返回差异和使目标库与源库一致的候选 ALTER 语句",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"source_database": map[string]interface{}{
+						"type":        "string",
+						"description": "源数据库，默认当前数据库",
+					},
+					"target_database": map[string]interface{}{
+						"type":        "string",
+						"description": "目标数据库，默认与源数据库同名",
+					},
+					"target_connection": map[string]interface{}{
+						"type":        "string",
+						"description": "目标所在的连接名（MYSQL_DIFF_CONNECTIONS 中配置），为空表示当前连接",
+					},
+				},
+			},
+		},
+		{
+			Name:        "dump_schema",
+			Description: "以 JSON 一次性导出当前数据库的完整结构：表、列、索引和外键",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "index_coverage",
+			Description: "列出表中已被索引和未被索引的列，以及组合索引的前导列",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "columns_detailed",
+			Description: "按序号列出表的列及完整类型（如 int(10) unsigned）、是否为空、键、默认值和额外信息",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+					"table_name": map[string]interface{}{
+						"type":        "string",
+						"description": "表名",
+					},
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "save_schema_snapshot",
+			Description: "把当前数据库结构保存为快照文件，供之后 diff_schema_snapshot 比较",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "快照名称（字母、数字、下划线）",
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			Name:        "diff_schema_snapshot",
+			Description: "比较已保存的结构快照与当前数据库结构，列出新增/删除/变更的表和列",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "快照名称",
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			Name:        "list_all_indexes",
+			Description: "列出当前数据库所有表的索引及其包含的列",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "数据库名，默认 MYSQL_DATABASE（需在 MYSQL_ALLOWED_DATABASES 中）",
+					},
+				},
+			},
+		},
+		{
+			Name:        "generate_sql",
+			Description: "根据自然语言问题生成 SELECT 查询：通过 sampling 请客户端的模型结合当前表结构生成 SQL，并用 EXPLAIN 验证（需要客户端支持 sampling）",
+			InputSchema: ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"question": map[string]interface{}{
+						"type":        "string",
+						"description": "用自然语言描述要查询的内容",
+					},
+				},
+				Required: []string{"question"},
+			},
+		},
+	}
+}
+
+// 依赖 information_schema 的元数据工具
+var informationSchemaTools = map[string]bool{
+	"check_fk":             true,
+	"collation_audit":      true,
+	"columns_detailed":     true,
+	"compact_schema":       true,
+	"count_rows":           true,
+	"diff_schema_snapshot": true,
+	"diff_schemas":         true,
+	"dump_schema":          true,
+	"export_schema":        true,
+	"generate_er_diagram":  true,
+	"generate_sql":         true,
+	"index_coverage":       true,
+	"list_foreign_keys":    true,
+	"list_all_indexes":     true,
+	"list_events":          true,
+	"list_routines":        true,
+	"list_triggers":        true,
+	"partition_info":       true,
+	"list_views":           true,
+	"describe_view":        true,
+	"save_schema_snapshot": true,
+	"search_columns":       true,
+	"search_in_table":      true,
+	"show_routine":         true,
+	"table_stats":          true,
+}
+
+// 判断工具在当前配置下是否可用
+func (s *MCPServer) toolEnabled(name string) bool {
+	if informationSchemaTools[name] && !s.config.AllowInformationSchema {
+		return false
+	}
+	if writeTools[name] && s.isReadOnly() {
+		return false
+	}
+	if rowWriteTools[name] && !s.config.AllowWrites {
+		return false
+	}
+	if ddlTools[name] && !s.config.AllowDDL {
+		return false
+	}
+	if adminTools[name] && !s.config.AllowAdmin {
+		return false
+	}
+	return true
+}
+
+// 返回当前配置下可用的工具
+func (s *MCPServer) listTools() []Tool {
+	var tools []Tool
+	for _, tool := range s.toolDefinitions() {
+		if s.toolEnabled(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (s *MCPServer) handleToolCall(ctx context.Context, req MCPRequest) MCPResponse {
+	var params struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+		Meta      map[string]interface{} `json:"_meta"`
+	}
+
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	if !s.toolEnabled(params.Name) {
+		return s.errorResponse(req.ID, fmt.Sprintf("工具 %s 已禁用", params.Name))
+	}
+
+	if tool, ok := s.findTool(params.Name); ok {
+		if schema, ok := tool.InputSchema.(ToolInputSchema); ok {
+			if err := validateArguments(schema, params.Arguments); err != nil {
+				return MCPResponse{
+					Jsonrpc: "2.0",
+					ID:      req.ID,
+					Error: &MCPError{
+						Code:    -32602,
+						Message: fmt.Sprintf("Invalid params: %v", err),
+						Data:    map[string]interface{}{"path": err.Path},
+					},
+				}
+			}
 		}
 	}
 
-	switch params.Name {
+	ctx, release, limitErr := s.admitToolCall(ctx)
+	if limitErr != nil {
+		return MCPResponse{Jsonrpc: "2.0", ID: req.ID, Error: limitErr}
+	}
+	defer release()
+
+	ctx = withToolName(ctx, params.Name)
+	if token, ok := params.Meta["progressToken"]; ok && token != nil {
+		ctx = withProgressToken(ctx, token)
+	}
+	if ms, ok := params.Arguments["timeout_ms"].(float64); ok && ms > 0 {
+		ctx = withQueryTimeout(ctx, time.Duration(ms)*time.Millisecond)
+	}
+	trace := &callTrace{}
+	ctx = withCallTrace(ctx, trace)
+
+	resp := s.callTool(ctx, req, params.Name, params.Arguments)
+	return withResponseMeta(resp, params.Meta, trace)
+}
+
+// 按工具名分派调用
+func (s *MCPServer) callTool(ctx context.Context, req MCPRequest, name string, args map[string]interface{}) MCPResponse {
+
+	switch name {
 	case "list_tables":
-		return s.listTables(req.ID)
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.listTables(ctx, req.ID, database)
+	case "list_databases":
+		return s.listDatabases(ctx, req.ID)
+	case "use_database":
+		return s.useDatabase(ctx, req.ID, args)
+	case "show_create_table":
+		return s.showCreateTableTool(ctx, req.ID, args)
+	case "list_foreign_keys":
+		return s.listForeignKeys(ctx, req.ID, args)
+	case "generate_er_diagram":
+		return s.generateERDiagram(ctx, req.ID, args)
+	case "search_columns":
+		return s.searchColumns(ctx, req.ID, args)
+	case "count_rows":
+		return s.countRows(ctx, req.ID, args)
+	case "column_histogram":
+		return s.columnHistogram(ctx, req.ID, args)
+	case "profile_column":
+		return s.profileColumn(ctx, req.ID, args)
+	case "sample_rows":
+		return s.sampleRows(ctx, req.ID, args)
+	case "list_routines":
+		return s.listRoutines(ctx, req.ID, args)
+	case "show_routine":
+		return s.showRoutine(ctx, req.ID, args)
+	case "list_views":
+		return s.listViews(ctx, req.ID, args)
+	case "describe_view":
+		return s.describeView(ctx, req.ID, args)
+	case "list_triggers":
+		return s.listTriggers(ctx, req.ID, args)
+	case "list_events":
+		return s.listEvents(ctx, req.ID, args)
+	case "call_procedure":
+		return s.callProcedure(ctx, req.ID, args)
+	case "partition_info":
+		return s.partitionInfo(ctx, req.ID, args)
+	case "table_stats":
+		return s.tableStats(ctx, req.ID, args)
 	case "describe_table":
-		tableName, ok := params.Arguments["table_name"].(string)
+		tableName, ok := args["table_name"].(string)
 		if !ok {
 			return s.errorResponse(req.ID, "table_name is required")
 		}
-		return s.describeTable(req.ID, tableName)
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.describeTable(ctx, req.ID, database, tableName)
 	case "query_table":
-		return s.queryTable(req.ID, params.Arguments)
+		return s.queryTable(ctx, req.ID, args)
 	case "execute_query":
-		query, ok := params.Arguments["query"].(string)
+		query, ok := args["query"].(string)
 		if !ok {
 			return s.errorResponse(req.ID, "query is required")
 		}
-		return s.executeQuery(req.ID, query)
+		format, _ := args["format"].(string)
+		raw, _ := args["params"].([]interface{})
+		queryArgs, err := bindParams(raw)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		pageSize, offset, paged, err := s.pageArgs(args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		if paged {
+			return s.executeQueryPage(ctx, req.ID, query, format, pageSize, offset, queryArgs...)
+		}
+		return s.executeQuery(ctx, req.ID, query, format, queryArgs...)
+	case "explain_query":
+		return s.explainQuery(ctx, req.ID, args)
+	case "explain_analyze":
+		return s.explainAnalyze(ctx, req.ID, args)
 	case "show_table_indexes":
-		tableName, ok := params.Arguments["table_name"].(string)
+		tableName, ok := args["table_name"].(string)
+		if !ok {
+			return s.errorResponse(req.ID, "table_name is required")
+		}
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.showTableIndexes(ctx, req.ID, database, tableName)
+	case "query_matching_tables":
+		return s.queryMatchingTables(ctx, req.ID, args)
+	case "check_unique":
+		return s.checkUnique(ctx, req.ID, args)
+	case "find_duplicates":
+		return s.findDuplicates(ctx, req.ID, args)
+	case "distinct_count":
+		return s.distinctCount(ctx, req.ID, args)
+	case "assert_query":
+		return s.assertQuery(ctx, req.ID, args)
+	case "search_in_table":
+		return s.searchInTable(ctx, req.ID, args)
+	case "pluck":
+		return s.pluck(ctx, req.ID, args)
+	case "exists":
+		return s.exists(ctx, req.ID, args)
+	case "check_fk":
+		return s.checkFK(ctx, req.ID, args)
+	case "pivot":
+		return s.pivot(ctx, req.ID, args)
+	case "join_count":
+		return s.joinCount(ctx, req.ID, args)
+	case "keyset_page":
+		return s.keysetPage(ctx, req.ID, args)
+	case "truncate_table":
+		return s.truncateTable(ctx, req.ID, args)
+	case "insert_row":
+		return s.insertRow(ctx, req.ID, args)
+	case "update_rows":
+		return s.updateRows(ctx, req.ID, args)
+	case "delete_rows":
+		return s.deleteRows(ctx, req.ID, args)
+	case "execute_transaction":
+		return s.executeTransaction(ctx, req.ID, args)
+	case "submit_query":
+		return s.submitQuery(ctx, req.ID, args)
+	case "query_status":
+		return s.queryStatus(ctx, req.ID, args)
+	case "fetch_results":
+		return s.fetchResults(ctx, req.ID, args)
+	case "cancel_query":
+		return s.cancelQuery(ctx, req.ID, args)
+	case "create_table":
+		return s.createTable(ctx, req.ID, args)
+	case "alter_table":
+		return s.alterTable(ctx, req.ID, args)
+	case "drop_table":
+		return s.dropTable(ctx, req.ID, args)
+	case "create_index":
+		return s.createIndex(ctx, req.ID, args)
+	case "server_status":
+		return s.serverStatus(ctx, req.ID, args)
+	case "replication_status":
+		return s.replicationStatus(ctx, req.ID, args)
+	case "innodb_status":
+		return s.innodbStatus(ctx, req.ID, args)
+	case "statement_digest":
+		return s.statementDigest(ctx, req.ID, args)
+	case "show_variables":
+		return s.showVariables(ctx, req.ID, args)
+	case "show_processlist":
+		return s.showProcesslist(ctx, req.ID, args)
+	case "list_users":
+		return s.listUsers(ctx, req.ID, args)
+	case "kill_query":
+		return s.killProcess(ctx, req.ID, args, false)
+	case "kill_connection":
+		return s.killProcess(ctx, req.ID, args, true)
+	case "connection_test":
+		return s.connectionTest(ctx, req.ID, args)
+	case "sql_mode":
+		return s.sqlMode(ctx, req.ID)
+	case "collation_audit":
+		return s.collationAudit(ctx, req.ID, args)
+	case "compact_schema":
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.compactSchema(ctx, req.ID, database)
+	case "export_schema":
+		return s.exportSchema(ctx, req.ID, args)
+	case "diff_schemas":
+		return s.diffSchemasTool(ctx, req.ID, args)
+	case "dump_schema":
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.dumpSchema(ctx, req.ID, database)
+	case "index_coverage":
+		tableName, ok := args["table_name"].(string)
+		if !ok {
+			return s.errorResponse(req.ID, "table_name is required")
+		}
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.indexCoverage(ctx, req.ID, database, tableName)
+	case "columns_detailed":
+		tableName, ok := args["table_name"].(string)
 		if !ok {
 			return s.errorResponse(req.ID, "table_name is required")
 		}
-		return s.showTableIndexes(req.ID, tableName)
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.columnsDetailed(ctx, req.ID, database, tableName)
+	case "save_schema_snapshot":
+		name, ok := args["name"].(string)
+		if !ok {
+			return s.errorResponse(req.ID, "name is required")
+		}
+		return s.saveSchemaSnapshot(ctx, req.ID, name)
+	case "diff_schema_snapshot":
+		name, ok := args["name"].(string)
+		if !ok {
+			return s.errorResponse(req.ID, "name is required")
+		}
+		return s.diffSchemaSnapshot(ctx, req.ID, name)
+	case "list_all_indexes":
+		database, err := s.databaseArg(ctx, args)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		return s.listAllIndexes(ctx, req.ID, database)
+	case "generate_sql":
+		return s.generateSQL(ctx, req.ID, args)
 	default:
 		return s.errorResponse(req.ID, "Unknown tool")
 	}
 }
 
-func (s *MCPServer) listTables(id interface{}) MCPResponse {
-	rows, err := s.db.Query("SHOW TABLES")
+func (s *MCPServer) listTables(ctx context.Context, id interface{}, database string) MCPResponse {
+	rows, err := s.db.QueryContext(ctx, "SHOW FULL TABLES FROM "+quoteIdentifier(database))
 	if err != nil {
-		return s.errorResponse(id, fmt.Sprintf("Database error: %v", err))
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
 	}
 	defer rows.Close()
 
 	var tables []string
 	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var tableName, tableType string
+		if err := rows.Scan(&tableName, &tableType); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
 			continue
 		}
+		// 视图单独标出，避免被当作普通表
+		if tableType == "VIEW" {
+			tableName += " (视图)"
+		}
 		tables = append(tables, tableName)
 	}
 
@@ -373,17 +2548,21 @@ func (s *MCPServer) listTables(id interface{}) MCPResponse {
 			"content": []map[string]interface{}{
 				{
 					"type": "text",
-					"text": fmt.Sprintf("数据库 '%s' 中的表: %s", s.config.Database, strings.Join(tables, ", ")),
+					"text": fmt.Sprintf("数据库 '%s' 中的表: %s", database, strings.Join(tables, ", ")),
 				},
 			},
 		},
 	}
 }
 
-func (s *MCPServer) describeTable(id interface{}, tableName string) MCPResponse {
-	rows, err := s.db.Query("DESCRIBE " + tableName)
+func (s *MCPServer) describeTable(ctx context.Context, id interface{}, database, tableName string) MCPResponse {
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "DESCRIBE "+qualifiedTable(database, tableName))
 	if err != nil {
-		return s.errorResponse(id, fmt.Sprintf("Database error: %v", err))
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
 	}
 	defer rows.Close()
 
@@ -429,10 +2608,14 @@ func (s *MCPServer) describeTable(id interface{}, tableName string) MCPResponse
 	}
 }
 
-func (s *MCPServer) showTableIndexes(id interface{}, tableName string) MCPResponse {
-	rows, err := s.db.Query("SHOW INDEX FROM " + tableName)
+func (s *MCPServer) showTableIndexes(ctx context.Context, id interface{}, database, tableName string) MCPResponse {
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SHOW INDEX FROM "+qualifiedTable(database, tableName))
 	if err != nil {
-		return s.errorResponse(id, fmt.Sprintf("Database error: %v", err))
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
 	}
 	defer rows.Close()
 
@@ -479,53 +2662,311 @@ func (s *MCPServer) showTableIndexes(id interface{}, tableName string) MCPRespon
 	}
 }
 
-func (s *MCPServer) queryTable(id interface{}, args map[string]interface{}) MCPResponse {
+func (s *MCPServer) queryTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
 	tableName, ok := args["table_name"].(string)
 	if !ok {
 		return s.errorResponse(id, "table_name is required")
 	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
 
-	limit := 10
-	if l, ok := args["limit"]; ok {
-		if lf, ok := l.(float64); ok {
-			limit = int(lf)
+	limit := s.limitArg(args)
+
+	// 空间列以 WKB 二进制返回，无法直接阅读，这里改写为 ST_AsText(col) 以 WKT 文本输出
+	selectList := "*"
+	if s.config.AllowInformationSchema {
+		if geometryColumns, err := s.geometryColumns(ctx, database, tableName); err == nil && len(geometryColumns) > 0 {
+			selectList, err = s.selectListWithWKT(ctx, database, tableName, geometryColumns)
+			if err != nil {
+				return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+			}
 		}
 	}
 
-	query := fmt.Sprintf("SELECT * FROM `%s`", tableName)
+	query := fmt.Sprintf("SELECT %s FROM %s", selectList, qualifiedTable(database, tableName))
 
 	if whereClause, ok := args["where_clause"].(string); ok && whereClause != "" {
 		query += " WHERE " + whereClause
 	}
 
+	format, _ := args["format"].(string)
+	pageSize, offset, paged, err := s.pageArgs(args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if paged {
+		// 按主键排序，保证各页不重不漏
+		keys, err := s.primaryKeyColumns(ctx, qualifiedTable(database, tableName))
+		if err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+		if len(keys) == 0 {
+			return s.queryErrorResponse(id, &argumentError{Path: "page_size", Reason: fmt.Sprintf("表 '%s' 没有主键，无法稳定分页，请使用 limit", tableName)})
+		}
+		quoted := make([]string, len(keys))
+		for i, key := range keys {
+			quoted[i] = quoteIdentifier(key)
+		}
+		query += " ORDER BY " + strings.Join(quoted, ", ")
+		return s.executeQueryPage(ctx, id, query, format, pageSize, offset)
+	}
+
 	query += " LIMIT " + strconv.Itoa(limit)
+	return s.executeQuery(ctx, id, query, format)
+}
+
+// 读取 limit 参数，未传时使用 MYSQL_DEFAULT_LIMIT，并以 MYSQL_MAX_ROWS 为上限
+func (s *MCPServer) limitArg(args map[string]interface{}) int {
+	limit := s.config.DefaultLimit
+	if l, ok := args["limit"]; ok {
+		if lf, ok := l.(float64); ok {
+			limit = int(lf)
+		}
+	}
+	if s.config.MaxRows > 0 && limit > s.config.MaxRows {
+		limit = s.config.MaxRows
+	}
+	return limit
+}
+
+func (s *MCPServer) executeQuery(ctx context.Context, id interface{}, query string, format string, args ...interface{}) MCPResponse {
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+
+	limitApplied := false
+	if s.config.AutoLimit > 0 {
+		query, limitApplied = addDefaultLimit(query, s.config.AutoLimit)
+	}
+
+	result, err := s.runQuery(ctx, query, args...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if limitApplied {
+		result.LimitApplied = s.config.AutoLimit
+	}
+
+	return s.queryResultResponse(id, result, format)
+}
+
+// 分页执行查询：多取一行判断是否还有下一页，有则返回下一页的 cursor
+func (s *MCPServer) executeQueryPage(ctx context.Context, id interface{}, query string, format string, pageSize, offset int, args ...interface{}) MCPResponse {
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+	pageQuery, err := pagedQuery(query, pageSize+1, offset)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	result, err := s.runQuery(ctx, pageQuery, args...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var nextCursor string
+	if result.Count > pageSize {
+		result.Rows = result.Rows[:pageSize]
+		result.Count = pageSize
+		nextCursor = encodeCursor(offset + pageSize)
+	}
+	return withNextCursorNote(s.queryResultResponse(id, result, format), nextCursor)
+}
+
+// 按 format 输出查询结果，默认文本表格
+func (s *MCPServer) queryResultResponse(id interface{}, result *QueryResult, format string) MCPResponse {
+	switch format {
+	case "", "text":
+		blocks := formatQueryResultBlocks(result, s.config.RowsPerBlock)
+		blocks[len(blocks)-1] += truncationNote(result) + limitNote(result) + s.timingNote(result)
+		return withStructuredContent(s.textBlocksResponse(id, blocks), s.structuredQueryResult(result))
+	case "json":
+		data, err := json.MarshalIndent(s.jsonQueryResult(result), "", "  ")
+		if err != nil {
+			return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+		}
+		return withStructuredContent(s.textResponse(id, string(data)+"\n"+truncationNote(result)+limitNote(result)+s.timingNote(result)), s.structuredQueryResult(result))
+	default:
+		return s.errorResponse(id, fmt.Sprintf("不支持的输出格式: %s", format))
+	}
+}
 
-	return s.executeQuery(id, query)
+// 生成 JSON 输出用的结果副本，MYSQL_BOOL_COLUMNS 中的 TINYINT 列转换为 true/false
+func (s *MCPServer) jsonQueryResult(result *QueryResult) *QueryResult {
+	var boolColumns []string
+	for i, col := range result.Columns {
+		if i < len(result.ColumnTypes) && result.ColumnTypes[i] == "TINYINT" && s.isBoolColumn(col) {
+			boolColumns = append(boolColumns, col)
+		}
+	}
+	if len(boolColumns) == 0 {
+		return result
+	}
+
+	converted := *result
+	converted.Rows = make([]map[string]interface{}, len(result.Rows))
+	for i, row := range result.Rows {
+		newRow := make(map[string]interface{}, len(row))
+		for col, val := range row {
+			newRow[col] = val
+		}
+		for _, col := range boolColumns {
+			switch v := newRow[col].(type) {
+			case int64:
+				newRow[col] = v != 0
+			case string:
+				newRow[col] = v != "0"
+			}
+		}
+		converted.Rows[i] = newRow
+	}
+	return &converted
 }
 
-func (s *MCPServer) executeQuery(id interface{}, query string) MCPResponse {
-	// 安全检查：只允许SELECT语句和SHOW语句
+func (s *MCPServer) isBoolColumn(column string) bool {
+	for _, name := range s.config.BoolColumns {
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// 安全检查：只允许SELECT语句和SHOW语句
+func checkReadOnlyQuery(query string) error {
 	upperQuery := strings.ToUpper(strings.TrimSpace(query))
+	// WITH 之后也可以是 UPDATE、DELETE，只接受主语句为 SELECT 的 CTE 查询
+	if hasKeywordAt(upperQuery, 0, "WITH") {
+		pos := findTopLevelSelect(upperQuery, len("WITH"))
+		if pos < 0 {
+			return &rejectedError{"只允许执行SELECT、SHOW、DESCRIBE查询"}
+		}
+		for _, keyword := range []string{"UPDATE", "DELETE"} {
+			if p := findTopLevelKeyword(upperQuery, len("WITH"), keyword); p >= 0 && p < pos {
+				return &rejectedError{"只允许执行SELECT、SHOW、DESCRIBE查询"}
+			}
+		}
+		return nil
+	}
 	if !strings.HasPrefix(upperQuery, "SELECT") &&
 		!strings.HasPrefix(upperQuery, "SHOW") &&
 		!strings.HasPrefix(upperQuery, "DESCRIBE") &&
 		!strings.HasPrefix(upperQuery, "DESC") {
-		return s.errorResponse(id, "只允许执行SELECT、SHOW、DESCRIBE查询")
+		return &rejectedError{"只允许执行SELECT、SHOW、DESCRIBE查询"}
+	}
+	return nil
+}
+
+// 从连接池取一个执行查询的连接，返回的 release 必须调用
+func (s *MCPServer) queryConn(ctx context.Context) (*sql.Conn, func(), error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, func() { conn.Close() }, nil
+}
+
+// 本次查询的超时：工具参数 timeout_ms 优先，但不超过 MYSQL_QUERY_TIMEOUT_MS
+func (s *MCPServer) queryTimeout(ctx context.Context) time.Duration {
+	timeout := time.Duration(s.config.QueryTimeoutMs) * time.Millisecond
+	if d := queryTimeoutFrom(ctx); d > 0 && (timeout == 0 || d < timeout) {
+		timeout = d
+	}
+	return timeout
+}
+
+// 执行查询并把结果扫描为 QueryResult
+func (s *MCPServer) runQuery(ctx context.Context, query string, args ...interface{}) (*QueryResult, error) {
+	if s.config.MaxExecutionTimeMs > 0 {
+		query = addMaxExecutionTimeHint(query, s.config.MaxExecutionTimeMs)
+	}
+
+	if err := s.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	timeout := s.queryTimeout(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 使用独立连接执行，请求被取消时通过 KILL QUERY 终止服务端仍在运行的查询
+	conn, release, err := s.queryConn(ctx)
+	if err != nil {
+		s.logEvent(ctx, "error", "获取数据库连接错误: %v", err)
+		return nil, fmt.Errorf("获取数据库连接错误: %w", err)
+	}
+	defer release()
+	var connID int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		return nil, fmt.Errorf("获取连接 ID 错误: %v", err)
+	}
+	stopKill := context.AfterFunc(ctx, func() { s.killQuery(connID) })
+	defer stopKill()
+
+	restoreDatabase, err := s.useSessionDatabase(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer restoreDatabase()
+
+	// 服务端同时按 MAX_EXECUTION_TIME 终止超时的 SELECT，结束后恢复默认值，避免影响连接的后续使用者
+	if timeout > 0 {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION MAX_EXECUTION_TIME = %d", timeout.Milliseconds())); err == nil {
+			defer conn.ExecContext(context.Background(), "SET SESSION MAX_EXECUTION_TIME = DEFAULT")
+		}
 	}
 
-	rows, err := s.db.Query(query)
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
-		return s.errorResponse(id, fmt.Sprintf("查询错误: %v", err))
+		s.logQuery(ctx, query, time.Since(start), 0, err)
+		return nil, queryTimeoutError(ctx, fmt.Errorf("查询错误: %w", &statementError{Statement: query, Err: err}), timeout)
 	}
 	defer rows.Close()
 
 	columns, err := rows.Columns()
 	if err != nil {
-		return s.errorResponse(id, fmt.Sprintf("获取列信息错误: %v", err))
+		return nil, fmt.Errorf("获取列信息错误: %v", err)
+	}
+
+	// 超宽结果只保留前 MaxColumns 列
+	keep := len(columns)
+	if s.config.MaxColumns > 0 && keep > s.config.MaxColumns {
+		keep = s.config.MaxColumns
 	}
 
-	var results []map[string]interface{}
+	result := &QueryResult{
+		Columns:        columns[:keep],
+		OmittedColumns: len(columns) - keep,
+	}
+	progress := newProgressReporter(ctx)
+	var bytesRead int64
+	if columnTypes, err := rows.ColumnTypes(); err == nil {
+		for _, ct := range columnTypes[:keep] {
+			result.ColumnTypes = append(result.ColumnTypes, ct.DatabaseTypeName())
+		}
+	}
+	// 逐行读取，达到行数或字节上限后只计数，不再保留行数据
+	totalRows := 0
+	var resultBytes int64
 	for rows.Next() {
+		totalRows++
+		if result.Truncated || (s.config.ResultMaxRows > 0 && len(result.Rows) >= s.config.ResultMaxRows) {
+			result.Truncated = true
+			progress.update(totalRows, fmt.Sprintf("已读取 %d 行（结果已截断）", totalRows))
+			continue
+		}
+
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
 		for i := range values {
@@ -533,80 +2974,214 @@ func (s *MCPServer) executeQuery(id interface{}, query string) MCPResponse {
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
+			if s.config.PartialOnError {
+				result.PartialError = err.Error()
+				break
+			}
 			continue
 		}
 
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
+		var rowBytes int64
+		for _, val := range values {
 			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
+				rowBytes += int64(len(b))
 			}
 		}
-		results = append(results, row)
-	}
-
-	// 格式化输出
-	resultText := fmt.Sprintf("查询结果 (%d 行):\n\n", len(results))
-	if len(results) > 0 {
-		// 计算每列的最大宽度
-		colWidths := make(map[string]int)
-		for _, col := range columns {
-			colWidths[col] = len(col)
+		bytesRead += rowBytes
+		if s.config.ResultMaxBytes > 0 && resultBytes+rowBytes > s.config.ResultMaxBytes {
+			result.Truncated = true
+			continue
 		}
-		for _, row := range results {
-			for _, col := range columns {
-				value := row[col]
-				valueStr := "NULL"
-				if value != nil {
-					valueStr = fmt.Sprintf("%v", value)
-				}
-				if len(valueStr) > colWidths[col] {
-					colWidths[col] = len(valueStr)
-				}
+		resultBytes += rowBytes
+
+		row := make(map[string]interface{})
+		for i, col := range result.Columns {
+			dbType := ""
+			if i < len(result.ColumnTypes) {
+				dbType = result.ColumnTypes[i]
 			}
+			row[col] = convertValue(dbType, values[i])
+		}
+		result.Rows = append(result.Rows, row)
+		progress.update(len(result.Rows), fmt.Sprintf("已读取 %d 行，%d 字节", len(result.Rows), bytesRead))
+	}
+	if err := rows.Err(); err != nil {
+		// 读取中途出错：开启 MYSQL_PARTIAL_ON_ERROR 时保留已读取的行
+		if !s.config.PartialOnError {
+			s.logQuery(ctx, query, time.Since(start), len(result.Rows), err)
+			return nil, queryTimeoutError(ctx, fmt.Errorf("查询错误: %w", &statementError{Statement: query, Err: err}), timeout)
 		}
+		result.PartialError = err.Error()
+	}
+	result.Count = len(result.Rows)
+	if result.Truncated {
+		result.TotalRows = totalRows
+	}
+	result.Duration = time.Since(start)
+	s.logQuery(ctx, query, result.Duration, result.Count, nil)
+
+	// 关闭结果集后才能在同一连接上执行 EXPLAIN 和统计查询
+	rows.Close()
+	s.explainIfSlow(ctx, conn, query, args, result.Duration)
+
+	if trace := callTraceFrom(ctx); trace != nil {
+		trace.add(tracedQuery{
+			SQL:          query,
+			DurationMs:   float64(result.Duration.Microseconds()) / 1000,
+			Rows:         result.Count,
+			RowsExamined: s.rowsExamined(ctx, conn),
+		})
+	}
 
-		// 表头
-		for _, col := range columns {
-			width := colWidths[col]
-			if width < 8 {
-				width = 8
+	return result, nil
+}
+
+// 按列顺序把 runQuery 结果第 row 行的值写入 dest，用于统计查询读取单个数值
+func scanResultRow(result *QueryResult, row int, dest ...sql.Scanner) error {
+	if row >= len(result.Rows) {
+		return sql.ErrNoRows
+	}
+	if len(dest) > len(result.Columns) {
+		return fmt.Errorf("结果只有 %d 列，需要 %d 列", len(result.Columns), len(dest))
+	}
+	for i, d := range dest {
+		if err := d.Scan(result.Rows[row][result.Columns[i]]); err != nil {
+			return fmt.Errorf("读取列 %s 错误: %v", result.Columns[i], err)
+		}
+	}
+	return nil
+}
+
+// 把驱动返回的值转换为便于展示的类型：
+// []byte 转为字符串，YEAR 转为整数年份，TIME 统一为 HH:MM:SS 字符串
+func convertValue(dbType string, val interface{}) interface{} {
+	if b, ok := val.([]byte); ok {
+		val = string(b)
+	}
+
+	switch dbType {
+	case "YEAR":
+		switch v := val.(type) {
+		case string:
+			if year, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return year
 			}
-			if width > 30 {
-				width = 30
+		case int64:
+			return v
+		}
+	case "TIME":
+		if v, ok := val.(string); ok {
+			// 去掉全为 0 的小数秒，例如 12:30:00.000000
+			if dot := strings.IndexByte(v, '.'); dot >= 0 && strings.Trim(v[dot+1:], "0") == "" {
+				v = v[:dot]
 			}
-			resultText += fmt.Sprintf("%-*s ", width, col)
+			return v
 		}
-		resultText += "\n"
+	}
+	return val
+}
+
+// 开启 MYSQL_INCLUDE_TIMING 时返回执行耗时说明，不混入结果行
+func (s *MCPServer) timingNote(result *QueryResult) string {
+	if !s.config.IncludeTiming {
+		return ""
+	}
+	return fmt.Sprintf("\n执行耗时: %s，返回 %d 行\n", result.Duration.Round(time.Microsecond), result.Count)
+}
+
+// 结果被截断时提示实际行数
+func truncationNote(result *QueryResult) string {
+	if !result.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("\n结果已截断：查询共返回 %d 行，只显示前 %d 行，请添加 LIMIT 或使用分页\n", result.TotalRows, result.Count)
+}
+
+// 自动添加了 LIMIT 时提示，结果可能不完整
+func limitNote(result *QueryResult) string {
+	if result.LimitApplied == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n查询未指定 LIMIT，已自动添加 LIMIT %d（MYSQL_AUTO_LIMIT）\n", result.LimitApplied)
+}
+
+// 把查询结果格式化为文本表格
+func formatQueryResult(result *QueryResult) string {
+	return formatQueryResultBlocks(result, 0)[0]
+}
 
-		// 分隔线
-		totalWidth := 0
+// 把查询结果格式化为一个或多个文本表格块。
+// rowsPerBlock > 0 且行数超过它时，每块最多 rowsPerBlock 行并重复表头，各块列宽一致。
+func formatQueryResultBlocks(result *QueryResult, rowsPerBlock int) []string {
+	columns := result.Columns
+	results := result.Rows
+
+	if len(results) == 0 {
+		text := fmt.Sprintf("查询结果 (%d 行):\n\n", len(results)) + "没有找到数据\n"
+		return []string{text + resultNotes(result)}
+	}
+
+	// 计算每列的显示宽度（8 到 30 之间）
+	colWidths := make(map[string]int)
+	for _, col := range columns {
+		colWidths[col] = len(col)
+	}
+	for _, row := range results {
 		for _, col := range columns {
-			width := colWidths[col]
-			if width < 8 {
-				width = 8
+			value := row[col]
+			valueStr := "NULL"
+			if value != nil {
+				valueStr = fmt.Sprintf("%v", value)
 			}
-			if width > 30 {
-				width = 30
+			if len(valueStr) > colWidths[col] {
+				colWidths[col] = len(valueStr)
 			}
-			totalWidth += width + 1
 		}
-		resultText += strings.Repeat("-", totalWidth) + "\n"
+	}
+	totalWidth := 0
+	for _, col := range columns {
+		width := colWidths[col]
+		if width < 8 {
+			width = 8
+		}
+		if width > 30 {
+			width = 30
+		}
+		colWidths[col] = width
+		totalWidth += width + 1
+	}
+
+	// 表头和分隔线
+	header := ""
+	for _, col := range columns {
+		header += fmt.Sprintf("%-*s ", colWidths[col], col)
+	}
+	header += "\n" + strings.Repeat("-", totalWidth) + "\n"
+
+	if rowsPerBlock <= 0 || len(results) <= rowsPerBlock {
+		rowsPerBlock = len(results)
+	}
+	blockCount := (len(results) + rowsPerBlock - 1) / rowsPerBlock
+
+	var blocks []string
+	for start := 0; start < len(results); start += rowsPerBlock {
+		end := start + rowsPerBlock
+		if end > len(results) {
+			end = len(results)
+		}
+
+		var text string
+		if blockCount == 1 {
+			text = fmt.Sprintf("查询结果 (%d 行):\n\n", len(results))
+		} else {
+			text = fmt.Sprintf("查询结果 (%d 行，第 %d/%d 块，第 %d-%d 行):\n\n",
+				len(results), len(blocks)+1, blockCount, start+1, end)
+		}
+		text += header
 
 		// 数据行
-		for _, row := range results {
+		for _, row := range results[start:end] {
 			for _, col := range columns {
-				width := colWidths[col]
-				if width < 8 {
-					width = 8
-				}
-				if width > 30 {
-					width = 30
-				}
-
 				value := row[col]
 				valueStr := "NULL"
 				if value != nil {
@@ -615,14 +3190,31 @@ func (s *MCPServer) executeQuery(id interface{}, query string) MCPResponse {
 						valueStr = valueStr[:27] + "..."
 					}
 				}
-				resultText += fmt.Sprintf("%-*s ", width, valueStr)
+				text += fmt.Sprintf("%-*s ", colWidths[col], valueStr)
 			}
-			resultText += "\n"
+			text += "\n"
 		}
-	} else {
-		resultText += "没有找到数据\n"
+		blocks = append(blocks, text)
+	}
+	blocks[len(blocks)-1] += resultNotes(result)
+
+	return blocks
+}
+
+// 结果表格之后的附加说明：省略的列、部分结果的错误
+func resultNotes(result *QueryResult) string {
+	notes := ""
+	if result.OmittedColumns > 0 {
+		notes += fmt.Sprintf("\n（结果共 %d 列，另有 %d 列未显示，可通过 MYSQL_MAX_COLUMNS 调整）\n",
+			len(result.Columns)+result.OmittedColumns, result.OmittedColumns)
+	}
+	if result.PartialError != "" {
+		notes += fmt.Sprintf("\n（读取第 %d 行时出错，以上为部分结果: %s）\n", len(result.Rows)+1, result.PartialError)
 	}
+	return notes
+}
 
+func (s *MCPServer) textResponse(id interface{}, text string) MCPResponse {
 	return MCPResponse{
 		Jsonrpc: "2.0",
 		ID:      id,
@@ -630,13 +3222,31 @@ func (s *MCPServer) executeQuery(id interface{}, query string) MCPResponse {
 			"content": []map[string]interface{}{
 				{
 					"type": "text",
-					"text": resultText,
+					"text": text,
 				},
 			},
 		},
 	}
 }
 
+// 返回包含多个文本块的结果
+func (s *MCPServer) textBlocksResponse(id interface{}, texts []string) MCPResponse {
+	content := make([]map[string]interface{}, 0, len(texts))
+	for _, text := range texts {
+		content = append(content, map[string]interface{}{
+			"type": "text",
+			"text": text,
+		})
+	}
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Result: map[string]interface{}{
+			"content": content,
+		},
+	}
+}
+
 func (s *MCPServer) errorResponse(id interface{}, message string) MCPResponse {
 	return MCPResponse{
 		Jsonrpc: "2.0",
@@ -648,36 +3258,148 @@ func (s *MCPServer) errorResponse(id interface{}, message string) MCPResponse {
 	}
 }
 
-func (s *MCPServer) run() {
+// 从 stdin 读取请求直到 EOF 或 shutdown 结束；返回前在宽限期内等待进行中的请求完成
+func (s *MCPServer) run(shutdown context.Context) error {
 	decoder := json.NewDecoder(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
+	// 请求并发处理，以便在查询执行期间仍能收到取消通知
+	var encodeMu sync.Mutex
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = withInflight(ctx, newInflightRequests())
+	ctx = withLogLevel(ctx, newClientLogLevel())
+	ctx = withClientRequests(ctx, newClientRequests())
+	ctx = withSessionLimiter(ctx, newSessionLimiter())
+	ctx = withSessionDatabase(ctx, &sessionDatabase{})
+	ctx = withNotifier(ctx, func(msg interface{}) {
+		encodeMu.Lock()
+		defer encodeMu.Unlock()
+		if err := encoder.Encode(msg); err != nil {
+			log.Printf("编码通知错误: %v", err)
+		}
+	})
+	ctx = s.startSubscriptions(ctx)
+	defer s.watchToolList(notifierFrom(ctx))()
+
+	// 在单独的 goroutine 中读取 stdin，以便收到退出信号时不再等待下一条消息
+	requests := make(chan MCPRequest)
+	go func() {
+		defer close(requests)
+		for {
+			var req MCPRequest
+			if err := decoder.Decode(&req); err != nil {
+				if err.Error() == "EOF" {
+					return
+				}
+				log.Printf("解码请求错误: %v", err)
+				continue
+			}
+			select {
+			case requests <- req:
+			case <-shutdown.Done():
+				return
+			}
+		}
+	}()
+
 	for {
 		var req MCPRequest
-		if err := decoder.Decode(&req); err != nil {
-			if err.Error() == "EOF" {
-				break
+		select {
+		case r, ok := <-requests:
+			if !ok {
+				wg.Wait()
+				return nil
 			}
-			log.Printf("解码请求错误: %v", err)
-			continue
+			req = r
+		case <-shutdown.Done():
+			log.Printf("收到退出信号，等待进行中的请求完成（最多 %s）", s.shutdownGrace())
+			if !waitWithTimeout(&wg, s.shutdownGrace()) {
+				return errors.New("等待请求完成超时")
+			}
+			return nil
 		}
 
-		response := s.handleRequest(req)
-		if err := encoder.Encode(response); err != nil {
-			log.Printf("编码响应错误: %v", err)
+		// 通知和客户端的响应不需要回复
+		if !req.expectsResponse() {
+			s.handleRequest(ctx, req)
+			continue
 		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := s.handleRequest(ctx, req)
+
+			encodeMu.Lock()
+			defer encodeMu.Unlock()
+			if err := encoder.Encode(response); err != nil {
+				log.Printf("编码响应错误: %v", err)
+			}
+		}()
 	}
 }
 
 func main() {
+	transport := flag.String("transport", "stdio", "传输方式: stdio、http、sse 或 ws")
+	listen := flag.String("listen", "127.0.0.1:8080", "http/sse/ws 传输的监听地址")
+	allowWrites := flag.Bool("allow-writes", false, "启用 insert_row、update_rows、delete_rows 并关闭只读模式")
+	allowDDL := flag.Bool("allow-ddl", false, "启用 create_table、alter_table、drop_table、create_index 并关闭只读模式")
+	flag.Parse()
+
 	server := NewMCPServer()
 
 	if err := server.initDatabase(); err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
-	defer server.db.Close()
+	if *allowWrites {
+		server.config.AllowWrites = true
+		server.config.ReadOnly = false
+		server.readOnly.Store(false)
+	}
+	if *allowDDL {
+		server.config.AllowDDL = true
+		server.config.ReadOnly = false
+		server.readOnly.Store(false)
+	}
+
+	server.watchModeSignals()
 
 	log.Printf("MySQL MCP Server 启动...")
 	log.Printf("连接到: %s:%d/%s", server.config.Host, server.config.Port, server.config.Database)
-	server.run()
+
+	// 收到 SIGINT/SIGTERM 后停止接收新请求，等待进行中的请求完成后退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *transport != "stdio" {
+		server.warnIfExposed(*listen)
+	}
+
+	var err error
+	switch *transport {
+	case "stdio":
+		err = server.run(ctx)
+	case "http":
+		if err = server.serveHTTP(ctx, *listen); err != nil {
+			err = fmt.Errorf("HTTP 服务错误: %v", err)
+		}
+	case "sse":
+		if err = server.serveSSE(ctx, *listen); err != nil {
+			err = fmt.Errorf("SSE 服务错误: %v", err)
+		}
+	case "ws":
+		if err = server.serveWebSocket(ctx, *listen); err != nil {
+			err = fmt.Errorf("WebSocket 服务错误: %v", err)
+		}
+	default:
+		err = fmt.Errorf("不支持的传输方式: %s", *transport)
+	}
+
+	server.close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("MySQL MCP Server 已退出")
 }
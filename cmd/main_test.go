@@ -0,0 +1,618 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 测试使用的默认数据库
+const testDatabase = "testdb"
+
+// 测试的默认配置：默认数据库 testdb，允许读取 information_schema，不自动添加 LIMIT
+func testConfig() MySQLConfig {
+	return MySQLConfig{
+		Database:               testDatabase,
+		DefaultLimit:           10,
+		MaxRows:                1000,
+		AllowInformationSchema: true,
+	}
+}
+
+// 基于 sqlmock 创建服务。performance_schema 标记为不可用，查询后不再读取 rows_examined。
+func newTestServer(t *testing.T, cfg MySQLConfig) (*MCPServer, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("创建 sqlmock 失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := NewMCPServerWithDB(db, cfg)
+	s.perfSchemaUnavailable.Store(true)
+	return s, mock
+}
+
+// 通过 handleToolCall 调用工具，参数经过一次 JSON 编码，与客户端请求一致
+func invokeTool(t *testing.T, s *MCPServer, ctx context.Context, name string, args map[string]interface{}) MCPResponse {
+	t.Helper()
+	params, err := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	if err != nil {
+		t.Fatalf("编码参数失败: %v", err)
+	}
+	return s.handleToolCall(ctx, MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+}
+
+// 拼接响应中的全部文本块
+func responseText(resp MCPResponse) string {
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	var texts []string
+	for _, block := range content {
+		if text, ok := block["text"].(string); ok {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "")
+}
+
+// 响应中文本块的个数
+func contentBlocks(resp MCPResponse) int {
+	result, _ := resp.Result.(map[string]interface{})
+	content, _ := result["content"].([]map[string]interface{})
+	return len(content)
+}
+
+// 响应中 structuredContent 的 JSON 编码
+func structuredJSON(t *testing.T, resp MCPResponse) string {
+	t.Helper()
+	result, _ := resp.Result.(map[string]interface{})
+	data, err := json.Marshal(result["structuredContent"])
+	if err != nil {
+		t.Fatalf("编码 structuredContent 失败: %v", err)
+	}
+	return string(data)
+}
+
+// 检查 structuredContent 的 JSON 编码
+func wantStructured(want string) func(t *testing.T, resp MCPResponse) {
+	return func(t *testing.T, resp MCPResponse) {
+		t.Helper()
+		if got := structuredJSON(t, resp); got != want {
+			t.Errorf("structuredContent = %s，期望 %s", got, want)
+		}
+	}
+}
+
+// runQuery 执行语句前先读取连接 ID
+func expectConnectionID(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CONNECTION_ID()")).
+		WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+}
+
+// 一个工具的测试用例：按 expect 设置的 SQL 期望执行，检查响应文本包含 want 中的每一项，
+// 或者错误信息包含 wantErr
+type toolCase struct {
+	name    string
+	config  func(cfg *MySQLConfig)
+	ctx     func(ctx context.Context) context.Context
+	args    map[string]interface{}
+	expect  func(mock sqlmock.Sqlmock)
+	want    []string
+	wantErr string
+	check   func(t *testing.T, resp MCPResponse)
+}
+
+func runToolCases(t *testing.T, tool string, cases []toolCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tc.config != nil {
+				tc.config(&cfg)
+			}
+			s, mock := newTestServer(t, cfg)
+			if tc.expect != nil {
+				tc.expect(mock)
+			}
+			ctx := context.Background()
+			if tc.ctx != nil {
+				ctx = tc.ctx(ctx)
+			}
+
+			resp := invokeTool(t, s, ctx, tool, tc.args)
+			if tc.wantErr != "" {
+				if resp.Error == nil {
+					t.Fatalf("期望错误 %q，实际成功: %s", tc.wantErr, responseText(resp))
+				}
+				if !strings.Contains(resp.Error.Message, tc.wantErr) {
+					t.Fatalf("错误信息 %q 不包含 %q", resp.Error.Message, tc.wantErr)
+				}
+			} else if resp.Error != nil {
+				t.Fatalf("意外的错误: %s", resp.Error.Message)
+			}
+			text := responseText(resp)
+			for _, want := range tc.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("响应中缺少 %q:\n%s", want, text)
+				}
+			}
+			if tc.check != nil {
+				tc.check(t, resp)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestListTables(t *testing.T) {
+	runToolCases(t, "list_tables", []toolCase{
+		{
+			name: "marks views",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW FULL TABLES FROM `testdb`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Tables_in_testdb", "Table_type"}).
+						AddRow("orders", "BASE TABLE").
+						AddRow("active_users", "VIEW"))
+			},
+			want: []string{"数据库 'testdb' 中的表: orders, active_users (视图)"},
+		},
+		{
+			name:   "filters by allowed tables",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders"} },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW FULL TABLES FROM `testdb`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Tables_in_testdb", "Table_type"}).
+						AddRow("orders", "BASE TABLE").
+						AddRow("secrets", "BASE TABLE"))
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				if strings.Contains(responseText(resp), "secrets") {
+					t.Errorf("不应列出白名单外的表: %s", responseText(resp))
+				}
+			},
+		},
+		{
+			name:    "rejects database outside allowlist",
+			args:    map[string]interface{}{"database": "other"},
+			wantErr: "不允许访问数据库 'other'",
+		},
+	})
+}
+
+func TestDescribeTable(t *testing.T) {
+	runToolCases(t, "describe_table", []toolCase{
+		{
+			name: "lists columns",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("DESCRIBE `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}).
+						AddRow("id", "int", "NO", "PRI", nil, "auto_increment").
+						AddRow("name", "varchar(64)", "YES", "", "anon", ""))
+			},
+			want: []string{"表 'users' 的结构", "id", "auto_increment", "varchar(64)", "anon"},
+		},
+		{
+			name:   "qualifies table with database argument",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"shop"} },
+			args:   map[string]interface{}{"table_name": "users", "database": "shop"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("DESCRIBE `shop`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Field", "Type", "Null", "Key", "Default", "Extra"}))
+			},
+			want: []string{"表 'users' 的结构"},
+		},
+		{
+			name:    "rejects table outside allowlist",
+			config:  func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders"} },
+			args:    map[string]interface{}{"table_name": "users"},
+			wantErr: "不允许访问表 'users'",
+		},
+	})
+}
+
+func TestShowTableIndexes(t *testing.T) {
+	indexColumns := []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Collation",
+		"Cardinality", "Sub_part", "Packed", "Null", "Index_type", "Comment", "Index_comment"}
+	runToolCases(t, "show_table_indexes", []toolCase{
+		{
+			name: "lists indexes",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW INDEX FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows(indexColumns).
+						AddRow("users", "0", "PRIMARY", "1", "id", "A", 10, nil, nil, "", "BTREE", "", "").
+						AddRow("users", "1", "idx_name", "1", "name", "A", 8, nil, nil, "YES", "BTREE", "", ""))
+			},
+			want: []string{"表 'users' 的索引信息", "PRIMARY", "idx_name", "BTREE"},
+		},
+		{
+			name:    "requires table_name",
+			args:    map[string]interface{}{},
+			wantErr: "table_name",
+		},
+	})
+}
+
+func TestQueryTable(t *testing.T) {
+	noGeometry := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("DATA_TYPE IN").WithArgs(testDatabase, "users").
+			WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+	}
+	runToolCases(t, "query_table", []toolCase{
+		{
+			name: "applies default limit and where clause",
+			args: map[string]interface{}{"table_name": "users", "where_clause": "id > 1"},
+			expect: func(mock sqlmock.Sqlmock) {
+				noGeometry(mock)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` WHERE id > 1 LIMIT 10")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "bob"))
+			},
+			want: []string{"查询结果 (1 行)", "bob"},
+		},
+		{
+			name: "pages by primary key",
+			args: map[string]interface{}{"table_name": "users", "page_size": 1},
+			expect: func(mock sqlmock.Sqlmock) {
+				noGeometry(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW KEYS FROM `testdb`.`users` WHERE Key_name = 'PRIMARY'")).
+					WillReturnRows(sqlmock.NewRows([]string{"Table", "Key_name", "Column_name"}).AddRow("users", "PRIMARY", "id"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` ORDER BY `id`\nLIMIT 2 OFFSET 0")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+			},
+			want: []string{"查询结果 (1 行)", "cursor=\"" + base64.StdEncoding.EncodeToString([]byte(cursorPrefix+"1")) + "\""},
+		},
+		{
+			name: "spatial columns wrapped in ST_AsText",
+			args: map[string]interface{}{"table_name": "places"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DATA_TYPE IN").WithArgs(testDatabase, "places").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("location"))
+				mock.ExpectQuery("ORDER BY ORDINAL_POSITION").WithArgs(testDatabase, "places").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id").AddRow("location").AddRow("name"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, ST_AsText(`location`) AS `location`, `name` FROM `testdb`.`places` LIMIT 10")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "location", "name"}).AddRow(1, "POINT(116.4 39.9)", "北京"))
+			},
+			want: []string{"POINT(116.4 39.9)"},
+		},
+		{
+			name:   "no spatial lookup without information_schema",
+			config: func(cfg *MySQLConfig) { cfg.AllowInformationSchema = false },
+			args:   map[string]interface{}{"table_name": "places"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`places` LIMIT 10")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+		},
+		{
+			name:    "rejects table outside allowlist",
+			config:  func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders"} },
+			args:    map[string]interface{}{"table_name": "users"},
+			wantErr: "不允许访问表 'users'",
+		},
+	})
+}
+
+func TestExecuteQuery(t *testing.T) {
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name: "binds params",
+			args: map[string]interface{}{"query": "SELECT name FROM users WHERE id = ?", "params": []interface{}{7}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT name FROM users WHERE id = ?")).WithArgs(int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+			},
+			want: []string{"查询结果 (1 行)", "alice"},
+		},
+		{
+			name: "json format",
+			args: map[string]interface{}{"query": "SELECT 1 AS one", "format": "json"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 AS one")).
+					WillReturnRows(sqlmock.NewRows([]string{"one"}).AddRow(1))
+			},
+			want: []string{`"one": 1`},
+		},
+		{
+			name:    "rejects writes",
+			args:    map[string]interface{}{"query": "DELETE FROM users"},
+			wantErr: "只允许执行SELECT、SHOW、DESCRIBE查询",
+		},
+		{
+			name:    "paging requires ORDER BY",
+			args:    map[string]interface{}{"query": "SELECT * FROM users", "page_size": 5},
+			wantErr: "ORDER BY",
+		},
+		{
+			name:    "rejects qualified table in a database outside the allowlist",
+			args:    map[string]interface{}{"query": "SELECT User FROM mysql.user"},
+			wantErr: "不允许访问数据库 'mysql'",
+		},
+		{
+			name:   "qualified table in an allowed database",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"shop"} },
+			args:   map[string]interface{}{"query": "SELECT COUNT(*) AS n FROM shop.orders"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS n FROM shop.orders")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(3))
+			},
+			want: []string{"查询结果 (1 行)"},
+		},
+	})
+}
+
+func TestMaxColumns(t *testing.T) {
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "wide result is truncated with a note",
+			config: func(cfg *MySQLConfig) { cfg.MaxColumns = 2 },
+			args:   map[string]interface{}{"query": "SELECT * FROM wide"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM wide")).
+					WillReturnRows(sqlmock.NewRows([]string{"a", "b", "c", "d"}).AddRow("va", "vb", "vc", "vd"))
+			},
+			want: []string{"va", "vb", "（结果共 4 列，另有 2 列未显示，可通过 MYSQL_MAX_COLUMNS 调整）"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if text := responseText(resp); strings.Contains(text, "vc") || strings.Contains(text, "vd") {
+					t.Errorf("超出 MYSQL_MAX_COLUMNS 的列不应显示:\n%s", text)
+				}
+			},
+		},
+	})
+}
+
+func TestIncludeTiming(t *testing.T) {
+	expectOneRow := func(mock sqlmock.Sqlmock) {
+		expectConnectionID(mock)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 AS one")).WillReturnRows(sqlmock.NewRows([]string{"one"}).AddRow(1))
+	}
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "timing line when enabled",
+			config: func(cfg *MySQLConfig) { cfg.IncludeTiming = true },
+			args:   map[string]interface{}{"query": "SELECT 1 AS one"},
+			expect: expectOneRow,
+			check: func(t *testing.T, resp MCPResponse) {
+				if !regexp.MustCompile(`\n执行耗时: \S+，返回 1 行\n`).MatchString(responseText(resp)) {
+					t.Errorf("缺少耗时说明:\n%s", responseText(resp))
+				}
+			},
+		},
+		{
+			name:   "no timing by default",
+			args:   map[string]interface{}{"query": "SELECT 1 AS one"},
+			expect: expectOneRow,
+			check: func(t *testing.T, resp MCPResponse) {
+				if strings.Contains(responseText(resp), "执行耗时") {
+					t.Errorf("未开启 MYSQL_INCLUDE_TIMING 时不应有耗时说明:\n%s", responseText(resp))
+				}
+			},
+		},
+	})
+}
+
+func TestDefaultLimit(t *testing.T) {
+	expectUsers := func(limit string) func(mock sqlmock.Sqlmock) {
+		return func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("DATA_TYPE IN").WithArgs(testDatabase, "users").
+				WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+			expectConnectionID(mock)
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` "+limit) + "$").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		}
+	}
+	runToolCases(t, "query_table", []toolCase{
+		{
+			name:   "configured default limit",
+			config: func(cfg *MySQLConfig) { cfg.DefaultLimit = 25 },
+			args:   map[string]interface{}{"table_name": "users"},
+			expect: expectUsers("LIMIT 25"),
+		},
+		{
+			name:   "explicit limit overrides the default",
+			config: func(cfg *MySQLConfig) { cfg.DefaultLimit = 25 },
+			args:   map[string]interface{}{"table_name": "users", "limit": 3},
+			expect: expectUsers("LIMIT 3"),
+		},
+		{
+			name:   "limit is capped by MYSQL_MAX_ROWS",
+			config: func(cfg *MySQLConfig) { cfg.MaxRows = 50 },
+			args:   map[string]interface{}{"table_name": "users", "limit": 500},
+			expect: expectUsers("LIMIT 50"),
+		},
+	})
+}
+
+func TestBoolColumns(t *testing.T) {
+	expectFlags := func(mock sqlmock.Sqlmock) {
+		expectConnectionID(mock)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, active FROM users")).
+			WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("id").OfType("INT", int64(0)),
+				sqlmock.NewColumn("active").OfType("TINYINT", int64(0)),
+			).AddRow(int64(1), int64(1)).AddRow(int64(2), int64(0)))
+	}
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "configured TINYINT(1) column rendered as boolean",
+			config: func(cfg *MySQLConfig) { cfg.BoolColumns = []string{"ACTIVE"} },
+			args:   map[string]interface{}{"query": "SELECT id, active FROM users", "format": "json"},
+			expect: expectFlags,
+			want:   []string{`"active": true`, `"active": false`, `"id": 2`},
+		},
+		{
+			name:   "unconfigured column stays numeric",
+			args:   map[string]interface{}{"query": "SELECT id, active FROM users", "format": "json"},
+			expect: expectFlags,
+			want:   []string{`"active": 1`, `"active": 0`},
+		},
+	})
+}
+
+func TestRowsPerBlock(t *testing.T) {
+	expectFiveRows := func(mock sqlmock.Sqlmock) {
+		expectConnectionID(mock)
+		rows := sqlmock.NewRows([]string{"id"})
+		for i := 1; i <= 5; i++ {
+			rows.AddRow(i)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users")).WillReturnRows(rows)
+	}
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "rows split across content blocks",
+			config: func(cfg *MySQLConfig) { cfg.RowsPerBlock = 2 },
+			args:   map[string]interface{}{"query": "SELECT id FROM users"},
+			expect: expectFiveRows,
+			check: func(t *testing.T, resp MCPResponse) {
+				content, _ := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+				if len(content) != 3 {
+					t.Fatalf("文本块数量 = %d，期望 3", len(content))
+				}
+				for i, want := range []string{
+					"查询结果 (5 行，第 1/3 块，第 1-2 行)",
+					"查询结果 (5 行，第 2/3 块，第 3-4 行)",
+					"查询结果 (5 行，第 3/3 块，第 5-5 行)",
+				} {
+					if text, _ := content[i]["text"].(string); !strings.Contains(text, want) {
+						t.Errorf("第 %d 块缺少 %q:\n%s", i+1, want, text)
+					}
+				}
+			},
+		},
+		{
+			name:   "single block when rows fit",
+			config: func(cfg *MySQLConfig) { cfg.RowsPerBlock = 10 },
+			args:   map[string]interface{}{"query": "SELECT id FROM users"},
+			expect: expectFiveRows,
+			want:   []string{"查询结果 (5 行):"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if n := contentBlocks(resp); n != 1 {
+					t.Errorf("文本块数量 = %d，期望 1", n)
+				}
+			},
+		},
+	})
+}
+
+// 关闭 MYSQL_ALLOW_INFORMATION_SCHEMA 后，依赖 information_schema 的工具不出现在列表中，调用时被拒绝
+func TestAllowInformationSchemaDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.AllowInformationSchema = false
+	s, _ := newTestServer(t, cfg)
+
+	listed := make(map[string]bool)
+	for _, tool := range s.listTools() {
+		listed[tool.Name] = true
+	}
+	for name := range informationSchemaTools {
+		if listed[name] {
+			t.Errorf("工具 %s 不应出现在工具列表中", name)
+		}
+	}
+	if !listed["list_tables"] || !listed["execute_query"] {
+		t.Errorf("不依赖 information_schema 的工具应保留: %v", listed)
+	}
+
+	runToolCases(t, "dump_schema", []toolCase{
+		{
+			name:    "metadata tool rejected",
+			config:  func(cfg *MySQLConfig) { cfg.AllowInformationSchema = false },
+			wantErr: "工具 dump_schema 已禁用",
+		},
+	})
+	runToolCases(t, "search_in_table", []toolCase{
+		{
+			name:    "search relies on information_schema",
+			config:  func(cfg *MySQLConfig) { cfg.AllowInformationSchema = false },
+			args:    map[string]interface{}{"table_name": "users", "search_term": "x"},
+			wantErr: "工具 search_in_table 已禁用",
+		},
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	cases := []struct {
+		name   string
+		dbType string
+		val    interface{}
+		want   interface{}
+	}{
+		{"YEAR from text protocol", "YEAR", []byte("2024"), int64(2024)},
+		{"YEAR from binary protocol", "YEAR", int64(1999), int64(1999)},
+		{"TIME drops zero fraction", "TIME", []byte("12:30:00.000000"), "12:30:00"},
+		{"TIME keeps real fraction", "TIME", []byte("12:30:00.250000"), "12:30:00.250000"},
+		{"TIME beyond 24 hours", "TIME", []byte("-838:59:59"), "-838:59:59"},
+		{"other bytes become string", "VARCHAR", []byte("abc"), "abc"},
+		{"NULL unchanged", "YEAR", nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := convertValue(tc.dbType, tc.val); got != tc.want {
+				t.Errorf("convertValue(%q, %v) = %#v，期望 %#v", tc.dbType, tc.val, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestYearAndTimeColumns(t *testing.T) {
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name: "YEAR as number and TIME without zero fraction in JSON",
+			args: map[string]interface{}{"query": "SELECT built, opens FROM shops", "format": "json"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT built, opens FROM shops")).
+					WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+						sqlmock.NewColumn("built").OfType("YEAR", []byte{}),
+						sqlmock.NewColumn("opens").OfType("TIME", []byte{}),
+					).AddRow([]byte("2015"), []byte("09:00:00.000000")))
+			},
+			want: []string{`"built": 2015`, `"opens": "09:00:00"`},
+		},
+	})
+}
+
+func TestPartialOnError(t *testing.T) {
+	// 第 3 行读取失败
+	expectBrokenRows := func(mock sqlmock.Sqlmock) {
+		expectConnectionID(mock)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM events")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).
+				RowError(2, errors.New("connection reset by peer")))
+	}
+	runToolCases(t, "execute_query", []toolCase{
+		{
+			name:   "rows read before the error are returned with a note",
+			config: func(cfg *MySQLConfig) { cfg.PartialOnError = true },
+			args:   map[string]interface{}{"query": "SELECT id FROM events"},
+			expect: expectBrokenRows,
+			want:   []string{"查询结果 (2 行)", "（读取第 3 行时出错，以上为部分结果: connection reset by peer）"},
+		},
+		{
+			name:    "error returned by default",
+			args:    map[string]interface{}{"query": "SELECT id FROM events"},
+			expect:  expectBrokenRows,
+			wantErr: "connection reset by peer",
+		},
+	})
+}
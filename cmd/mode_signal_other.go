@@ -0,0 +1,6 @@
+//go:build !unix
+
+package main
+
+// 非 Unix 平台没有 SIGUSR1/SIGUSR2，只读模式只能通过 MYSQL_READ_ONLY 在启动时设置
+func (s *MCPServer) watchModeSignals() {}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SIGUSR1 切换到读写模式，SIGUSR2 切换回只读模式
+func (s *MCPServer) watchModeSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range signals {
+			s.setReadOnly(sig == syscall.SIGUSR2)
+		}
+	}()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// list 类方法每页返回的最大条目数
+const listPageSize = 50
+
+const cursorPrefix = "offset:"
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// 读取请求参数中的 cursor，返回本页条目和下一页的 cursor（没有下一页时为空）
+func paginate[T any](params json.RawMessage, items []T) ([]T, string, error) {
+	var p struct {
+		Cursor string `json:"cursor"`
+	}
+	json.Unmarshal(params, &p)
+
+	start := 0
+	if p.Cursor != "" {
+		offset, err := decodeCursor(p.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = min(offset, len(items))
+	}
+	end := min(start+listPageSize, len(items))
+
+	var nextCursor string
+	if end < len(items) {
+		nextCursor = encodeCursor(end)
+	}
+	return items[start:end], nextCursor, nil
+}
+
+// 分页结果；nextCursor 仅在还有下一页时返回
+func pagedResult(key string, items interface{}, nextCursor string) map[string]interface{} {
+	result := map[string]interface{}{key: items}
+	if nextCursor != "" {
+		result["nextCursor"] = nextCursor
+	}
+	return result
+}
+
+func invalidCursorResponse(id interface{}) MCPResponse {
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Error: &MCPError{
+			Code:    -32602,
+			Message: "Invalid params: invalid cursor",
+		},
+	}
+}
+
+// 在工具结果末尾追加一段提示下一页 cursor 的文本，没有下一页时原样返回
+func withNextCursorNote(resp MCPResponse, nextCursor string) MCPResponse {
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok || nextCursor == "" {
+		return resp
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	result["content"] = append(content, map[string]interface{}{
+		"type": "text",
+		"text": fmt.Sprintf("还有更多行，传入 cursor=%q 获取下一页\n", nextCursor),
+	})
+	return resp
+}
+
+// 读取工具的 page_size 和 cursor 参数；未传 page_size 时 paged 为 false
+func (s *MCPServer) pageArgs(args map[string]interface{}) (pageSize, offset int, paged bool, err error) {
+	size, ok := args["page_size"].(float64)
+	if !ok {
+		if _, ok := args["cursor"]; ok {
+			return 0, 0, false, &argumentError{Path: "cursor", Reason: "需要与 page_size 一起使用"}
+		}
+		return 0, 0, false, nil
+	}
+	if size < 1 {
+		return 0, 0, false, &argumentError{Path: "page_size", Reason: "必须大于 0"}
+	}
+	pageSize = int(size)
+	if s.config.MaxRows > 0 && pageSize > s.config.MaxRows {
+		pageSize = s.config.MaxRows
+	}
+	if cursor, _ := args["cursor"].(string); cursor != "" {
+		if offset, err = decodeCursor(cursor); err != nil {
+			return 0, 0, false, &argumentError{Path: "cursor", Reason: "无效的 cursor"}
+		}
+	}
+	return pageSize, offset, true, nil
+}
+
+// 给 SELECT 加上 LIMIT/OFFSET。没有 ORDER BY 时各页之间的顺序不确定，可能重复或遗漏行，
+// 因此要求查询带顶层 ORDER BY。查询本身没有顶层 LIMIT 时直接追加（另起一行，避免被末尾的
+// -- 注释吞掉），否则包装为派生表，此时结果列名不能重复。
+func pagedQuery(query string, limit, offset int) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	if !strings.HasPrefix(strings.ToUpper(query), "SELECT") {
+		return "", &argumentError{Path: "page_size", Reason: "分页仅支持 SELECT 语句"}
+	}
+	if findTopLevelKeyword(query, 0, "ORDER") < 0 {
+		return "", &argumentError{Path: "page_size", Reason: "分页需要查询带 ORDER BY，否则各页可能重复或遗漏行"}
+	}
+	if findTopLevelKeyword(query, 0, "LIMIT") < 0 {
+		return fmt.Sprintf("%s\nLIMIT %d OFFSET %d", query, limit, offset), nil
+	}
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS page_rows LIMIT %d OFFSET %d", query, limit, offset), nil
+}
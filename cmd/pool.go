@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// 预热时的并发上限和单个连接的超时时间
+const (
+	warmupConcurrency = 4
+	warmupTimeout     = 5 * time.Second
+)
+
+// 并发建立并 ping n 个连接，全部完成后再归还连接池，使其成为空闲连接。
+// 失败只记录日志，不影响启动。返回成功建立的连接数。
+func (s *MCPServer) warmupPool(ctx context.Context, n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	var mu sync.Mutex
+	conns := make([]*sql.Conn, 0, n)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(warmupConcurrency)
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			connCtx, cancel := context.WithTimeout(ctx, warmupTimeout)
+			defer cancel()
+
+			conn, err := s.db.Conn(connCtx)
+			if err != nil {
+				log.Printf("连接池预热: 建立连接失败: %v", err)
+				return nil
+			}
+			if err := conn.PingContext(connCtx); err != nil {
+				log.Printf("连接池预热: ping 失败: %v", err)
+				conn.Close()
+				return nil
+			}
+
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+
+	// 同时持有所有连接直到这里，保证预热的是 n 个不同的连接
+	for _, conn := range conns {
+		conn.Close()
+	}
+	log.Printf("连接池预热完成: %d/%d 个连接", len(conns), n)
+
+	return len(conns)
+}
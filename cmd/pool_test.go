@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWarmupPool(t *testing.T) {
+	cases := []struct {
+		name      string
+		n         int
+		failPings int
+		want      int
+	}{
+		{name: "all connections warmed", n: 3, want: 3},
+		{name: "failed ping is not counted", n: 3, failPings: 1, want: 2},
+		{name: "zero disables warm-up", n: 0, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatalf("创建 sqlmock 失败: %v", err)
+			}
+			defer db.Close()
+			db.SetMaxIdleConns(tc.n)
+			for i := 0; i < tc.n; i++ {
+				if i < tc.failPings {
+					mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+				} else {
+					mock.ExpectPing()
+				}
+			}
+
+			s := NewMCPServerWithDB(db, testConfig())
+			if got := s.warmupPool(context.Background(), tc.n); got != tc.want {
+				t.Errorf("warmupPool(%d) = %d，期望 %d", tc.n, got, tc.want)
+			}
+			if idle := db.Stats().Idle; idle != tc.want {
+				t.Errorf("预热后空闲连接数 = %d，期望 %d", idle, tc.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// 请求执行超过 progressDelay 后才开始发送进度，之后每 progressInterval 最多发送一次
+const (
+	progressDelay    = 2 * time.Second
+	progressInterval = time.Second
+)
+
+// 按 progressToken 向客户端发送 notifications/progress
+type progressReporter struct {
+	token  interface{}
+	notify notifier
+	start  time.Time
+	last   time.Time
+}
+
+// 请求没有 progressToken 或传输层不支持通知时返回 nil，nil 上调用 update 不做任何事
+func newProgressReporter(ctx context.Context) *progressReporter {
+	token := progressTokenFrom(ctx)
+	notify := notifierFrom(ctx)
+	if token == nil || notify == nil {
+		return nil
+	}
+	return &progressReporter{token: token, notify: notify, start: time.Now()}
+}
+
+// progress 必须单调递增，这里使用已处理的行数
+func (p *progressReporter) update(progress int, message string) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay || now.Sub(p.last) < progressInterval {
+		return
+	}
+	p.last = now
+
+	p.notify(MCPNotification{
+		Jsonrpc: "2.0",
+		Method:  "notifications/progress",
+		Params: map[string]interface{}{
+			"progressToken": p.token,
+			"progress":      progress,
+			"message":       message,
+		},
+	})
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Prompt 内置提示模板
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+type PromptMessage struct {
+	Role    string                 `json:"role"`
+	Content map[string]interface{} `json:"content"`
+}
+
+func promptDefinitions() []Prompt {
+	return []Prompt{
+		{
+			Name:        "analyze_schema",
+			Description: "分析当前数据库的表结构，指出设计问题和改进建议",
+		},
+		{
+			Name:        "optimize_query",
+			Description: "结合执行计划和相关表结构优化一条 SELECT 查询",
+			Arguments: []PromptArgument{
+				{Name: "query", Description: "要优化的 SQL 查询", Required: true},
+			},
+		},
+		{
+			Name:        "explain_table_relationships",
+			Description: "说明一张表与其他表之间的外键关系",
+			Arguments: []PromptArgument{
+				{Name: "table_name", Description: "表名", Required: true},
+			},
+		},
+	}
+}
+
+// 需要读取 information_schema 的提示模板，MYSQL_ALLOW_INFORMATION_SCHEMA=false 时不提供
+var informationSchemaPrompts = map[string]bool{
+	"analyze_schema":              true,
+	"explain_table_relationships": true,
+}
+
+// 判断提示模板在当前配置下是否可用
+func (s *MCPServer) promptEnabled(name string) bool {
+	return !informationSchemaPrompts[name] || s.config.AllowInformationSchema
+}
+
+func (s *MCPServer) listPrompts(req MCPRequest) MCPResponse {
+	prompts := []Prompt{}
+	for _, prompt := range promptDefinitions() {
+		if s.promptEnabled(prompt.Name) {
+			prompts = append(prompts, prompt)
+		}
+	}
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"prompts": prompts,
+		},
+	}
+}
+
+func (s *MCPServer) getPrompt(ctx context.Context, req MCPRequest) MCPResponse {
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	for _, prompt := range promptDefinitions() {
+		if prompt.Name != params.Name {
+			continue
+		}
+		if !s.promptEnabled(prompt.Name) {
+			return s.errorResponse(req.ID, fmt.Sprintf("提示模板 %s 已禁用：需要 MYSQL_ALLOW_INFORMATION_SCHEMA=true", prompt.Name))
+		}
+		for _, arg := range prompt.Arguments {
+			if arg.Required && params.Arguments[arg.Name] == "" {
+				return MCPResponse{
+					Jsonrpc: "2.0",
+					ID:      req.ID,
+					Error: &MCPError{
+						Code:    -32602,
+						Message: fmt.Sprintf("Missing required argument: %s", arg.Name),
+					},
+				}
+			}
+		}
+
+		var text string
+		var err error
+		switch prompt.Name {
+		case "analyze_schema":
+			text, err = s.analyzeSchemaPrompt(ctx)
+		case "optimize_query":
+			text, err = s.optimizeQueryPrompt(ctx, params.Arguments["query"])
+		case "explain_table_relationships":
+			text, err = s.tableRelationshipsPrompt(ctx, params.Arguments["table_name"])
+		}
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Result: map[string]interface{}{
+				"description": prompt.Description,
+				"messages": []PromptMessage{
+					{
+						Role: "user",
+						Content: map[string]interface{}{
+							"type": "text",
+							"text": text,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Error: &MCPError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Unknown prompt: %s", params.Name),
+		},
+	}
+}
+
+func (s *MCPServer) analyzeSchemaPrompt(ctx context.Context) (string, error) {
+	dump, err := s.loadSchema(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Database error: %v", err)
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("编码结果错误: %v", err)
+	}
+
+	return fmt.Sprintf("请分析数据库 %s 的表结构：指出缺失的主键或索引、可疑的数据类型、"+
+		"命名不一致以及应当存在却缺失的外键，并给出具体的改进建议。\n\n表结构（JSON）：\n%s\n",
+		dump.Database, data), nil
+}
+
+func (s *MCPServer) optimizeQueryPrompt(ctx context.Context, query string) (string, error) {
+	if err := s.checkReadQuery(query); err != nil {
+		return "", err
+	}
+	plan, err := s.runQuery(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", fmt.Errorf("EXPLAIN 失败: %v", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "请优化下面的 SQL 查询，说明执行计划中的瓶颈，并给出改写后的查询和需要新增的索引。\n\n查询：\n%s\n\n执行计划：\n%s", query, formatQueryResult(plan))
+
+	// 附上执行计划中涉及的表结构
+	seen := make(map[string]bool)
+	for _, row := range plan.Rows {
+		tableName, _ := row["table"].(string)
+		if tableName == "" || seen[tableName] || validateIdentifier(tableName) != nil || !s.isTableAllowed(tableName) {
+			continue
+		}
+		seen[tableName] = true
+		if ddl, err := s.showCreateTable(ctx, s.currentDatabase(ctx), tableName); err == nil {
+			fmt.Fprintf(&b, "\n%s;\n", ddl)
+		}
+	}
+	return b.String(), nil
+}
+
+func (s *MCPServer) tableRelationshipsPrompt(ctx context.Context, tableName string) (string, error) {
+	if err := validateIdentifier(tableName); err != nil {
+		return "", err
+	}
+	if !s.isTableAllowed(tableName) {
+		return "", fmt.Errorf("不允许访问表 '%s'", tableName)
+	}
+	ddl, err := s.showCreateTable(ctx, s.currentDatabase(ctx), tableName)
+	if err != nil {
+		return "", fmt.Errorf("Database error: %v", err)
+	}
+	dump, err := s.loadSchema(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Database error: %v", err)
+	}
+
+	var outgoing, incoming []string
+	for _, table := range dump.Tables {
+		for _, fk := range table.ForeignKeys {
+			relation := fmt.Sprintf("%s(%s) -> %s(%s)", table.Name, strings.Join(fk.Columns, ", "),
+				fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+			if table.Name == tableName {
+				outgoing = append(outgoing, relation)
+			} else if fk.ReferencedTable == tableName {
+				incoming = append(incoming, relation)
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "请说明表 %s 在业务上的含义，以及它与其他表之间的关系（一对一、一对多、多对多），"+
+		"并指出可能缺失的关联。\n\n表定义：\n%s;\n", tableName, ddl)
+	b.WriteString("\n引用的表：\n")
+	if len(outgoing) == 0 {
+		b.WriteString("（无）\n")
+	}
+	for _, relation := range outgoing {
+		b.WriteString(relation + "\n")
+	}
+	b.WriteString("\n被以下表引用：\n")
+	if len(incoming) == 0 {
+		b.WriteString("（无）\n")
+	}
+	for _, relation := range incoming {
+		b.WriteString(relation + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 关闭 information_schema 后，依赖表结构的提示模板不出现在列表中，获取时返回错误且不查询数据库
+func TestPromptsWithoutInformationSchema(t *testing.T) {
+	cfg := testConfig()
+	cfg.AllowInformationSchema = false
+	s, _ := newTestServer(t, cfg)
+
+	listed := s.listPrompts(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "prompts/list"})
+	prompts := listed.Result.(map[string]interface{})["prompts"].([]Prompt)
+	if len(prompts) != 1 || prompts[0].Name != "optimize_query" {
+		t.Errorf("prompts/list = %+v，期望只有 optimize_query", prompts)
+	}
+
+	for name, args := range map[string]map[string]string{
+		"analyze_schema":              nil,
+		"explain_table_relationships": {"table_name": "users"},
+	} {
+		params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+		resp := s.getPrompt(context.Background(), MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "prompts/get", Params: params})
+		if resp.Error == nil || !strings.Contains(resp.Error.Message, "MYSQL_ALLOW_INFORMATION_SCHEMA") {
+			t.Errorf("prompts/get %s 应返回错误，得到 %+v", name, resp)
+		}
+	}
+}
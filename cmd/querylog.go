@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// 一条查询审计日志
+type queryLogEntry struct {
+	Time       string  `json:"time"`
+	Tool       string  `json:"tool"`
+	DurationMs float64 `json:"duration_ms"`
+	Rows       int     `json:"rows"`
+	SQL        string  `json:"sql"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// 以 JSON Lines 写入查询日志，超过 maxBytes 时把当前文件重命名为 .1 并重新打开
+type queryLogger struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+func newQueryLogger(path string, maxMB int) (*queryLogger, error) {
+	l := &queryLogger{path: path, maxBytes: int64(maxMB) * 1024 * 1024}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *queryLogger) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// 轮转失败时重新打开原文件继续写入，下次写入时再尝试轮转
+func (l *queryLogger) rotate() error {
+	l.file.Close()
+	renameErr := os.Rename(l.path, l.path+".1")
+	if err := l.open(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+func (l *queryLogger) write(entry queryLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxBytes > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			log.Printf("轮转查询日志失败: %v", err)
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *queryLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// 匹配 SQL 中的字符串字面量
+var sqlStringLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"`)
+
+// 把 SQL 中的字符串字面量替换为 '?'，避免日志中出现敏感数据
+func redactSQL(query string) string {
+	return sqlStringLiteral.ReplaceAllString(query, "'?'")
+}
+
+// 记录一条已执行的查询；未配置 MYSQL_QUERY_LOG_FILE 时不做任何事
+func (s *MCPServer) logQuery(ctx context.Context, query string, duration time.Duration, rows int, queryErr error) {
+	if s.queryLog == nil {
+		return
+	}
+
+	entry := queryLogEntry{
+		Time:       time.Now().Format(time.RFC3339Nano),
+		Tool:       toolNameFrom(ctx),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+		Rows:       rows,
+		SQL:        redactSQL(query),
+	}
+	if queryErr != nil {
+		entry.Error = queryErr.Error()
+	}
+	if err := s.queryLog.write(entry); err != nil {
+		log.Printf("写入查询日志失败: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 读取查询日志中的全部记录
+func readQueryLog(t *testing.T, path string) []queryLogEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开查询日志失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []queryLogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry queryLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("解析日志行失败: %v\n%s", err, scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestQueryLogLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	s, mock := newTestServer(t, testConfig())
+	logger, err := newQueryLogger(path, 100)
+	if err != nil {
+		t.Fatalf("创建查询日志失败: %v", err)
+	}
+	defer logger.Close()
+	s.queryLog = logger
+
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE email = 'a@x'")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM missing")).
+		WillReturnError(errors.New("Table 'testdb.missing' doesn't exist"))
+
+	invokeTool(t, s, context.Background(), "execute_query", map[string]interface{}{"query": "SELECT id FROM users WHERE email = 'a@x'"})
+	invokeTool(t, s, context.Background(), "execute_query", map[string]interface{}{"query": "SELECT * FROM missing"})
+
+	entries := readQueryLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("日志记录数 = %d，期望 2", len(entries))
+	}
+
+	ok := entries[0]
+	if _, err := time.Parse(time.RFC3339Nano, ok.Time); err != nil {
+		t.Errorf("time 字段格式不正确: %q", ok.Time)
+	}
+	if ok.Tool != "execute_query" || ok.Rows != 2 || ok.DurationMs < 0 || ok.Error != "" {
+		t.Errorf("成功查询的日志不正确: %+v", ok)
+	}
+	if ok.SQL != "SELECT id FROM users WHERE email = '?'" {
+		t.Errorf("sql = %q，字符串字面量应被替换", ok.SQL)
+	}
+
+	failed := entries[1]
+	if failed.Tool != "execute_query" || failed.Rows != 0 || !strings.Contains(failed.Error, "doesn't exist") {
+		t.Errorf("失败查询的日志不正确: %+v", failed)
+	}
+}
+
+func TestQueryLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	logger, err := newQueryLogger(path, 1)
+	if err != nil {
+		t.Fatalf("创建查询日志失败: %v", err)
+	}
+	defer logger.Close()
+
+	// 每条约 1 KB，写满 1 MB 后轮转
+	entry := queryLogEntry{Tool: "execute_query", SQL: strings.Repeat("x", 1000)}
+	for i := 0; i < 1100; i++ {
+		if err := logger.write(entry); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+
+	rotated, err := os.Stat(path + ".1")
+	if err != nil {
+		t.Fatalf("没有生成轮转文件: %v", err)
+	}
+	if rotated.Size() > 1024*1024 {
+		t.Errorf("轮转文件大小 %d 超过上限", rotated.Size())
+	}
+	if n := len(readQueryLog(t, path)) + len(readQueryLog(t, path+".1")); n != 1100 {
+		t.Errorf("两个文件共 %d 条记录，期望 1100", n)
+	}
+}
+
+func TestRedactSQL(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM users WHERE name = 'alice' AND note = \"x\"", "SELECT * FROM users WHERE name = '?' AND note = '?'"},
+		{"SELECT 'it''s', 'a\\'b'", "SELECT '?', '?'"},
+	}
+	for _, tc := range cases {
+		if got := redactSQL(tc.query); got != tc.want {
+			t.Errorf("redactSQL(%q) = %q，期望 %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+// 数据工具的统计查询同样经由 runQuery，写入查询日志
+func TestQueryLogDataTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	s, mock := newTestServer(t, testConfig())
+	logger, err := newQueryLogger(path, 100)
+	if err != nil {
+		t.Fatalf("创建查询日志失败: %v", err)
+	}
+	defer logger.Close()
+	s.queryLog = logger
+
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM `testdb`.`users` WHERE `email` = ? LIMIT 1)")).
+		WithArgs("a@x").WillReturnRows(sqlmock.NewRows([]string{"e"}).AddRow(true))
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`orders` AS l JOIN `testdb`.`users` AS r")).
+		WillReturnError(errors.New("Query execution was interrupted"))
+
+	invokeTool(t, s, context.Background(), "exists", map[string]interface{}{
+		"table_name": "users", "filters": map[string]interface{}{"email": "a@x"}})
+	invokeTool(t, s, context.Background(), "join_count", map[string]interface{}{
+		"left_table": "orders", "left_column": "user_id", "right_table": "users", "right_column": "id"})
+
+	entries := readQueryLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("日志记录数 = %d，期望 2", len(entries))
+	}
+	if entries[0].Tool != "exists" || entries[0].Rows != 1 || !strings.HasPrefix(entries[0].SQL, "SELECT EXISTS(") {
+		t.Errorf("exists 的日志不正确: %+v", entries[0])
+	}
+	if entries[1].Tool != "join_count" || !strings.Contains(entries[1].Error, "interrupted") {
+		t.Errorf("join_count 的日志不正确: %+v", entries[1])
+	}
+}
+
+// 存储过程调用与写操作一样写入查询日志，行数为各结果集的行数之和
+func TestQueryLogCallProcedure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	cfg := testConfig()
+	allowWrites(&cfg)
+	s, mock := newTestServer(t, cfg)
+	logger, err := newQueryLogger(path, 100)
+	if err != nil {
+		t.Fatalf("创建查询日志失败: %v", err)
+	}
+	defer logger.Close()
+	s.queryLog = logger
+
+	expectConnectionID(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("CALL `testdb`.`report`(?)")).WithArgs("2024-01").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"month"}).AddRow(1).AddRow(2),
+			sqlmock.NewRows([]string{"total"}).AddRow(99),
+		)
+
+	invokeTool(t, s, context.Background(), "call_procedure", map[string]interface{}{
+		"procedure_name": "report", "params": []interface{}{"2024-01"}, "confirm": true})
+
+	entries := readQueryLog(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("日志记录数 = %d，期望 1", len(entries))
+	}
+	if e := entries[0]; e.Tool != "call_procedure" || e.Rows != 3 || e.SQL != "CALL `testdb`.`report`(?)" || e.Error != "" {
+		t.Errorf("call_procedure 的日志不正确: %+v", e)
+	}
+}
+
+// KILL QUERY 和 KILL CONNECTION 都写入查询日志
+func TestQueryLogKill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	cfg := testConfig()
+	allowAdmin(&cfg)
+	s, mock := newTestServer(t, cfg)
+	logger, err := newQueryLogger(path, 100)
+	if err != nil {
+		t.Fatalf("创建查询日志失败: %v", err)
+	}
+	defer logger.Close()
+	s.queryLog = logger
+
+	mock.ExpectExec(regexp.QuoteMeta("KILL QUERY 12")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("KILL CONNECTION 13")).WillReturnError(errors.New("Unknown thread id: 13"))
+
+	invokeTool(t, s, context.Background(), "kill_query", map[string]interface{}{"process_id": 12, "confirm": true})
+	invokeTool(t, s, context.Background(), "kill_connection", map[string]interface{}{"process_id": 13, "confirm": true})
+
+	entries := readQueryLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("日志记录数 = %d，期望 2", len(entries))
+	}
+	if e := entries[0]; e.Tool != "kill_query" || e.SQL != "KILL QUERY 12" || e.Error != "" {
+		t.Errorf("kill_query 的日志不正确: %+v", e)
+	}
+	if e := entries[1]; e.Tool != "kill_connection" || e.SQL != "KILL CONNECTION 13" || !strings.Contains(e.Error, "Unknown thread id") {
+		t.Errorf("kill_connection 的日志不正确: %+v", e)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MCP 中资源不存在的错误码
+const resourceNotFoundCode = -32002
+
+// Resource 资源描述
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate 参数化资源模板
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents 资源内容
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// 每张表对应两个资源：
+//
+//	mysql://<database>/<table>/schema  表的 CREATE TABLE 语句
+//	mysql://<database>/<table>/rows    表的前 MYSQL_DEFAULT_LIMIT 行（JSON）
+func tableResourceURI(database, tableName, kind string) string {
+	return fmt.Sprintf("mysql://%s/%s/%s", database, tableName, kind)
+}
+
+// 列出数据库 database 中允许访问的表
+func (s *MCPServer) allowedTables(ctx context.Context, database string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SHOW TABLES FROM "+quoteIdentifier(database))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			continue
+		}
+		if s.isTableAllowed(tableName) {
+			tables = append(tables, tableName)
+		}
+	}
+	return tables, rows.Err()
+}
+
+func (s *MCPServer) listResources(ctx context.Context, req MCPRequest) MCPResponse {
+	database := s.currentDatabase(ctx)
+	tables, err := s.allowedTables(ctx, database)
+	if err != nil {
+		return s.queryErrorResponse(req.ID, fmt.Errorf("Database error: %w", err))
+	}
+
+	resources := make([]Resource, 0, len(tables)*2)
+	for _, tableName := range tables {
+		resources = append(resources,
+			Resource{
+				URI:         tableResourceURI(database, tableName, "schema"),
+				Name:        tableName + " schema",
+				Description: fmt.Sprintf("表 %s 的 CREATE TABLE 语句", tableName),
+				MimeType:    "text/plain",
+			},
+			Resource{
+				URI:         tableResourceURI(database, tableName, "rows"),
+				Name:        tableName + " rows",
+				Description: fmt.Sprintf("表 %s 的前 %d 行数据", tableName, s.config.DefaultLimit),
+				MimeType:    "application/json",
+			},
+		)
+	}
+
+	page, nextCursor, err := paginate(req.Params, resources)
+	if err != nil {
+		return invalidCursorResponse(req.ID)
+	}
+
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Result:  pagedResult("resources", page, nextCursor),
+	}
+}
+
+// 解析 mysql://<database>/<table>/<kind>，也支持模板形式 mysql://<database>/<table>?limit=<n>
+func parseTableResourceURI(uri string) (database, tableName, kind string, query url.Values, ok bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "mysql" || u.Host == "" {
+		return "", "", "", nil, false
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	switch len(parts) {
+	case 1:
+		kind = "rows"
+	case 2:
+		kind = parts[1]
+	default:
+		return "", "", "", nil, false
+	}
+	return u.Host, parts[0], kind, u.Query(), true
+}
+
+// 资源 URI 中的数据库和表是否允许访问
+func (s *MCPServer) isResourceTableAllowed(database, tableName string) bool {
+	return validateIdentifier(database) == nil && s.isDatabaseAllowed(database) &&
+		validateIdentifier(tableName) == nil && s.isTableAllowed(tableName)
+}
+
+// 资源模板，客户端可据此直接构造表的读取请求
+func (s *MCPServer) listResourceTemplates(req MCPRequest) MCPResponse {
+	templates := []ResourceTemplate{
+		{
+			URITemplate: "mysql://{database}/{table}/schema",
+			Name:        "table schema",
+			Description: "表的 CREATE TABLE 语句",
+			MimeType:    "text/plain",
+		},
+		{
+			URITemplate: "mysql://{database}/{table}/rows",
+			Name:        "table rows",
+			Description: fmt.Sprintf("表的前 %d 行数据", s.config.DefaultLimit),
+			MimeType:    "application/json",
+		},
+		{
+			URITemplate: "mysql://{database}/{table}?limit={n}",
+			Name:        "table rows with limit",
+			Description: fmt.Sprintf("表的前 n 行数据（最多 %d 行）", s.config.MaxRows),
+			MimeType:    "application/json",
+		},
+	}
+
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"resourceTemplates": templates,
+		},
+	}
+}
+
+func (s *MCPServer) readResource(ctx context.Context, req MCPRequest) MCPResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	database, tableName, kind, query, ok := parseTableResourceURI(params.URI)
+	if !ok || !s.isResourceTableAllowed(database, tableName) {
+		return s.resourceNotFound(req.ID, params.URI)
+	}
+
+	var contents ResourceContents
+	switch kind {
+	case "schema":
+		ddl, err := s.showCreateTable(ctx, database, tableName)
+		if err != nil {
+			return s.resourceNotFound(req.ID, params.URI)
+		}
+		contents = ResourceContents{URI: params.URI, MimeType: "text/plain", Text: ddl}
+
+	case "rows":
+		limit := s.config.DefaultLimit
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return s.resourceNotFound(req.ID, params.URI)
+			}
+			limit = n
+		}
+		if s.config.MaxRows > 0 && limit > s.config.MaxRows {
+			limit = s.config.MaxRows
+		}
+		sqlQuery := fmt.Sprintf("SELECT * FROM %s LIMIT %d", qualifiedTable(database, tableName), limit)
+		result, err := s.runQuery(ctx, sqlQuery)
+		if err != nil {
+			return s.queryErrorResponse(req.ID, err)
+		}
+		data, err := json.MarshalIndent(s.jsonQueryResult(result), "", "  ")
+		if err != nil {
+			return s.errorResponse(req.ID, fmt.Sprintf("编码结果错误: %v", err))
+		}
+		contents = ResourceContents{URI: params.URI, MimeType: "application/json", Text: string(data)}
+
+	default:
+		return s.resourceNotFound(req.ID, params.URI)
+	}
+
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"contents": []ResourceContents{contents},
+		},
+	}
+}
+
+// 读取表的 CREATE TABLE 语句，调用方负责校验库名和表名。
+// 视图返回 4 列（名称、CREATE VIEW、字符集、排序规则），这里统一取第二列。
+func (s *MCPServer) showCreateTable(ctx context.Context, database, tableName string) (string, error) {
+	return s.showCreate(ctx, "SHOW CREATE TABLE "+qualifiedTable(database, tableName), 1)
+}
+
+// 执行 SHOW CREATE ... 语句，返回第一行第 column 列（从 0 开始）
+func (s *MCPServer) showCreate(ctx context.Context, query string, column int) (string, error) {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+	values := make([]sql.NullString, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return "", err
+	}
+	if len(values) <= column {
+		return "", fmt.Errorf("%s 返回了 %d 列", query, len(values))
+	}
+	return values[column].String, nil
+}
+
+func (s *MCPServer) showCreateTableTool(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	ddl, err := s.showCreateTable(ctx, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	return s.textResponse(id, ddl+";\n")
+}
+
+func (s *MCPServer) resourceNotFound(id interface{}, uri string) MCPResponse {
+	return MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Error: &MCPError{
+			Code:    resourceNotFoundCode,
+			Message: fmt.Sprintf("Resource not found: %s", uri),
+		},
+	}
+}
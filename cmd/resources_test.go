@@ -0,0 +1,28 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestShowCreateTable(t *testing.T) {
+	runToolCases(t, "show_create_table", []toolCase{
+		{
+			name: "returns the DDL",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE TABLE `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).
+						AddRow("users", "CREATE TABLE `users` (\n  `id` int NOT NULL\n)"))
+			},
+			want: []string{"CREATE TABLE `users`", ");\n"},
+		},
+		{
+			name:    "rejects invalid identifiers",
+			args:    map[string]interface{}{"table_name": "users; DROP TABLE x"},
+			wantErr: "users; DROP TABLE x",
+		},
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// generate_sql 最多请求客户端生成 SQL 的次数（EXPLAIN 失败时带上错误重试）
+const maxGenerateSQLAttempts = 3
+
+// sampling 请求的最大 token 数
+const samplingMaxTokens = 1024
+
+// 通过 sampling 请客户端的模型生成文本
+func (s *MCPServer) createMessage(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	c := clientRequestsFrom(ctx)
+	if c == nil || !c.supports("sampling") {
+		return "", fmt.Errorf("客户端不支持 sampling")
+	}
+
+	result, err := s.requestClient(ctx, "sampling/createMessage", map[string]interface{}{
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": prompt,
+				},
+			},
+		},
+		"systemPrompt": systemPrompt,
+		"maxTokens":    samplingMaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var message struct {
+		Content struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(result, &message); err != nil {
+		return "", fmt.Errorf("解析 sampling 响应失败: %v", err)
+	}
+	if message.Content.Type != "text" {
+		return "", fmt.Errorf("sampling 返回了非文本内容: %s", message.Content.Type)
+	}
+	return message.Content.Text, nil
+}
+
+// 从模型回复中取出 SQL：去掉 Markdown 代码块标记和结尾分号
+func extractSQL(text string) string {
+	text = strings.TrimSpace(text)
+	if start := strings.Index(text, "```"); start >= 0 {
+		text = text[start+3:]
+		if newline := strings.Index(text, "\n"); newline >= 0 {
+			text = text[newline+1:]
+		}
+		if end := strings.Index(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSpace(text), ";")
+}
+
+func (s *MCPServer) generateSQL(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	question, _ := args["question"].(string)
+	if strings.TrimSpace(question) == "" {
+		return s.errorResponse(id, "question is required")
+	}
+
+	schema, err := s.compactSchemaText(ctx, s.currentDatabase(ctx))
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	systemPrompt := "你是 MySQL 专家。只输出一条可直接执行的 MySQL SELECT 语句，不要解释。"
+	prompt := fmt.Sprintf("数据库表结构（PK 主键，UQ 唯一索引，IDX 普通索引）：\n%s\n问题：%s\n", schema, question)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxGenerateSQLAttempts; attempt++ {
+		text, err := s.createMessage(ctx, systemPrompt, prompt)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		query := extractSQL(text)
+
+		// 只读检查和 EXPLAIN 都通过才返回，否则把错误反馈给模型重新生成
+		lastErr = s.checkReadQuery(query)
+		if lastErr == nil {
+			_, lastErr = s.runQuery(ctx, "EXPLAIN "+query)
+		}
+		if lastErr == nil {
+			return s.textResponse(id, query+"\n")
+		}
+		prompt += fmt.Sprintf("\n上一次生成的 SQL：\n%s\n执行 EXPLAIN 失败：%v\n请修正后重新输出。\n", query, lastErr)
+	}
+
+	return s.errorResponse(id, fmt.Sprintf("%d 次尝试后仍未生成有效的 SQL: %v", maxGenerateSQLAttempts, lastErr))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 模拟声明了 sampling 能力的客户端，对每个 sampling 请求回复 reply
+func withSamplingClient(reply string) func(ctx context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		c := newClientRequests()
+		c.setCapabilities(map[string]interface{}{"sampling": map[string]interface{}{}})
+		ctx = withClientRequests(ctx, c)
+		return withNotifier(ctx, func(msg interface{}) {
+			req, ok := msg.(outgoingRequest)
+			if !ok || req.Method != "sampling/createMessage" {
+				return
+			}
+			result, _ := json.Marshal(map[string]interface{}{
+				"role":    "assistant",
+				"content": map[string]interface{}{"type": "text", "text": reply},
+			})
+			c.deliver(MCPRequest{Jsonrpc: "2.0", ID: req.ID, Result: result})
+		})
+	}
+}
+
+func TestGenerateSQL(t *testing.T) {
+	expectSchema := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT TABLE_NAME, COLUMN_NAME, COLUMN_KEY").WithArgs(testDatabase).
+			WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_KEY"}).
+				AddRow("users", "id", "PRI").
+				AddRow("users", "name", ""))
+	}
+	runToolCases(t, "generate_sql", []toolCase{
+		{
+			name: "returns SQL that passes EXPLAIN",
+			ctx:  withSamplingClient("```sql\nSELECT name FROM users;\n```"),
+			args: map[string]interface{}{"question": "所有用户名"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectSchema(mock)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN SELECT name FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "table"}).AddRow(1, "users"))
+			},
+			want: []string{"SELECT name FROM users\n"},
+		},
+		{
+			name:    "gives up after repeated write statements",
+			ctx:     withSamplingClient("DELETE FROM users"),
+			args:    map[string]interface{}{"question": "清空用户"},
+			expect:  expectSchema,
+			wantErr: "3 次尝试后仍未生成有效的 SQL",
+		},
+		{
+			name:    "client without sampling",
+			args:    map[string]interface{}{"question": "所有用户名"},
+			expect:  expectSchema,
+			wantErr: "客户端不支持 sampling",
+		},
+	})
+}
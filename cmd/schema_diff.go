@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// 列定义片段，用于 ADD/MODIFY COLUMN
+func columnDefinition(c *schemaColumn) string {
+	def := quoteIdentifier(c.Name) + " " + c.Type
+	if !c.Nullable {
+		def += " NOT NULL"
+	}
+	if c.Default != nil {
+		if strings.HasPrefix(strings.ToUpper(*c.Default), "CURRENT_TIMESTAMP") {
+			def += " DEFAULT " + *c.Default
+		} else {
+			def += " DEFAULT " + quoteString(*c.Default)
+		}
+	}
+	return def
+}
+
+func addIndexClause(idx *schemaIndex) string {
+	columns := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		columns[i] = quoteIdentifier(col)
+	}
+	switch {
+	case idx.Name == "PRIMARY":
+		return "ADD PRIMARY KEY (" + strings.Join(columns, ", ") + ")"
+	case idx.Unique:
+		return fmt.Sprintf("ADD UNIQUE INDEX %s (%s)", quoteIdentifier(idx.Name), strings.Join(columns, ", "))
+	default:
+		return fmt.Sprintf("ADD INDEX %s (%s)", quoteIdentifier(idx.Name), strings.Join(columns, ", "))
+	}
+}
+
+func dropIndexClause(idx *schemaIndex) string {
+	if idx.Name == "PRIMARY" {
+		return "DROP PRIMARY KEY"
+	}
+	return "DROP INDEX " + quoteIdentifier(idx.Name)
+}
+
+// 生成使 target 与 source 一致的候选语句。缺少的整张表只给出提示，需用 show_create_table 获取 DDL
+func alterStatements(source, target *schemaDump) []string {
+	targetTables := make(map[string]*schemaTable)
+	for _, t := range target.Tables {
+		targetTables[t.Name] = t
+	}
+	sourceTables := make(map[string]*schemaTable)
+	for _, t := range source.Tables {
+		sourceTables[t.Name] = t
+	}
+
+	var stmts []string
+	for _, t := range source.Tables {
+		old, ok := targetTables[t.Name]
+		if !ok {
+			stmts = append(stmts, fmt.Sprintf("-- 目标库缺少表 %s，请用 show_create_table 获取源库的 DDL", t.Name))
+			continue
+		}
+
+		var clauses []string
+		oldColumns := make(map[string]*schemaColumn)
+		for _, c := range old.Columns {
+			oldColumns[c.Name] = c
+		}
+		curColumns := make(map[string]bool)
+		for _, c := range t.Columns {
+			curColumns[c.Name] = true
+			oc, ok := oldColumns[c.Name]
+			switch {
+			case !ok:
+				clauses = append(clauses, "ADD COLUMN "+columnDefinition(c))
+			case describeColumn(oc) != describeColumn(c):
+				clauses = append(clauses, "MODIFY COLUMN "+columnDefinition(c))
+			}
+		}
+		for _, c := range old.Columns {
+			if !curColumns[c.Name] {
+				clauses = append(clauses, "DROP COLUMN "+quoteIdentifier(c.Name))
+			}
+		}
+
+		oldIndexes := make(map[string]*schemaIndex)
+		for _, idx := range old.Indexes {
+			oldIndexes[idx.Name] = idx
+		}
+		curIndexes := make(map[string]bool)
+		for _, idx := range t.Indexes {
+			curIndexes[idx.Name] = true
+			oi, ok := oldIndexes[idx.Name]
+			switch {
+			case !ok:
+				clauses = append(clauses, addIndexClause(idx))
+			case describeIndex(oi) != describeIndex(idx):
+				clauses = append(clauses, dropIndexClause(oi), addIndexClause(idx))
+			}
+		}
+		for _, idx := range old.Indexes {
+			if !curIndexes[idx.Name] {
+				clauses = append(clauses, dropIndexClause(idx))
+			}
+		}
+
+		if len(clauses) > 0 {
+			stmts = append(stmts, "ALTER TABLE "+quoteIdentifier(t.Name)+"\n  "+strings.Join(clauses, ",\n  ")+";")
+		}
+	}
+	for _, t := range target.Tables {
+		if _, ok := sourceTables[t.Name]; !ok {
+			stmts = append(stmts, fmt.Sprintf("-- DROP TABLE %s;  -- 源库中没有该表，确认后再删除", quoteIdentifier(t.Name)))
+		}
+	}
+	return stmts
+}
+
+func (s *MCPServer) diffSchemasTool(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	sourceDatabase, err := s.databaseArg(ctx, map[string]interface{}{"database": args["source_database"]})
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	targetDB := s.db
+	targetDatabase, _ := args["target_database"].(string)
+	if targetDatabase == "" {
+		targetDatabase = sourceDatabase
+	}
+	targetLabel := targetDatabase
+	if name, _ := args["target_connection"].(string); name != "" {
+		dsn, ok := s.config.DiffConnections[name]
+		if !ok {
+			return s.queryErrorResponse(id, &argumentError{Path: "target_connection", Reason: "未在 MYSQL_DIFF_CONNECTIONS 中配置"})
+		}
+		if err := validateIdentifier(targetDatabase); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("连接 %s 失败: %w", name, err))
+		}
+		defer db.Close()
+		targetDB = db
+		targetLabel = name + "/" + targetDatabase
+	} else {
+		if targetDatabase, err = s.databaseArg(ctx, map[string]interface{}{"database": targetDatabase}); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		if targetDatabase == sourceDatabase {
+			return s.errorResponse(id, "源库和目标库相同，请指定 target_database 或 target_connection")
+		}
+	}
+
+	source, err := s.loadSchemaFrom(ctx, s.db, sourceDatabase)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	target, err := s.loadSchemaFrom(ctx, targetDB, targetDatabase)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("读取目标库 %s 结构失败: %w", targetLabel, err))
+	}
+
+	changes := diffSchemas(target, source)
+	if len(changes) == 0 {
+		return s.textResponse(id, fmt.Sprintf("%s 与 %s 的结构一致\n", sourceDatabase, targetLabel))
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s（源）与 %s（目标）的差异 (%d 处，+ 表示目标缺少，- 表示目标多出):\n\n", sourceDatabase, targetLabel, len(changes)))
+	sb.WriteString(strings.Join(changes, "\n") + "\n")
+	sb.WriteString("\n使目标与源一致的候选语句（执行前请确认）:\n\n")
+	sb.WriteString(strings.Join(alterStatements(source, target), "\n\n") + "\n")
+	return s.textResponse(id, sb.String())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	runToolCases(t, "diff_schemas", []toolCase{
+		{
+			name:   "target is missing a column",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"staging"} },
+			args:   map[string]interface{}{"target_database": "staging"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectLoadSchema(mock, testDatabase,
+					sqlmock.NewRows(schemaColumnColumns).
+						AddRow("users", "id", "int", "NO", nil, "PRI").
+						AddRow("users", "email", "varchar(255)", "YES", nil, ""),
+					sqlmock.NewRows(schemaIndexColumns),
+					sqlmock.NewRows(schemaFKColumns))
+				expectLoadSchema(mock, "staging",
+					sqlmock.NewRows(schemaColumnColumns).AddRow("users", "id", "int", "NO", nil, "PRI"),
+					sqlmock.NewRows(schemaIndexColumns),
+					sqlmock.NewRows(schemaFKColumns))
+			},
+			want: []string{"testdb（源）与 staging（目标）的差异 (1 处",
+				"+ 列 users.email (varchar(255))",
+				"ALTER TABLE `users`\n  ADD COLUMN `email` varchar(255);"},
+		},
+		{
+			name:    "source and target are the same",
+			wantErr: "源库和目标库相同",
+		},
+		{
+			name:    "unknown target connection",
+			args:    map[string]interface{}{"target_connection": "prod"},
+			wantErr: "target_connection: 未在 MYSQL_DIFF_CONNECTIONS 中配置",
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 收到退出信号后等待正在执行的请求完成的最长时间
+func (s *MCPServer) shutdownGrace() time.Duration {
+	return time.Duration(s.config.ShutdownGraceSeconds) * time.Second
+}
+
+// 等待 wg 完成，超时返回 false
+func waitWithTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// 启动 HTTP 服务，ctx 结束后停止接收新连接，并在宽限期内等待进行中的请求完成。
+// hijacked 跟踪已被接管（如 WebSocket）的连接，http.Server.Shutdown 不会等待这些连接。
+func (s *MCPServer) listenAndServe(ctx context.Context, srv *http.Server, hijacked *sync.WaitGroup) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("收到退出信号，等待进行中的请求完成（最多 %s）", s.shutdownGrace())
+	deadline := time.Now().Add(s.shutdownGrace())
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("等待请求完成超时: %v", err)
+	}
+	if hijacked != nil && !waitWithTimeout(hijacked, time.Until(deadline)) {
+		return errors.New("等待连接关闭超时")
+	}
+	return nil
+}
+
+// 关闭数据库连接池和查询日志
+func (s *MCPServer) close() {
+	s.jobs.cancelAll()
+	if s.db != nil {
+		s.db.Close()
+	}
+	if s.queryLog != nil {
+		s.queryLog.Close()
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQL 中的一个词法单元：关键字/标识符或单个标点；反引号标识符去掉了引号
+type sqlToken struct {
+	text   string
+	quoted bool
+	punct  bool
+}
+
+func (t sqlToken) isWord() bool {
+	return !t.punct
+}
+
+// 判断未加引号的词是否为关键字 keyword（不区分大小写）
+func (t sqlToken) is(keyword string) bool {
+	return !t.punct && !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// 返回从 query[start]（引号字符）开始的字符串或反引号标识符之后的位置
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && quote != '`':
+			i++
+		case query[i] == quote:
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// 若 query[pos:] 是注释，返回注释之后的位置，否则返回 -1。
+// 以 /*! 开头的可执行注释会被 MySQL 当作语句的一部分执行，不视为注释。
+func commentEnd(query string, pos int) int {
+	rest := query[pos:]
+	switch {
+	case strings.HasPrefix(rest, "#"),
+		strings.HasPrefix(rest, "--") && (len(rest) == 2 || rest[2] == ' ' || rest[2] == '\t' || rest[2] == '\n' || rest[2] == '\r'):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return pos + end + 1
+		}
+		return len(query)
+	case strings.HasPrefix(rest, "/*") && !strings.HasPrefix(rest, "/*!"):
+		if end := strings.Index(rest[2:], "*/"); end >= 0 {
+			return pos + 2 + end + 2
+		}
+		return len(query)
+	}
+	return -1
+}
+
+// 把 SQL 切分为词法单元，跳过字符串和注释
+func sqlTokens(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		if end := commentEnd(query, i); end >= 0 {
+			i = end
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(query, i)
+		case c == '`':
+			end := skipQuoted(query, i)
+			name := strings.TrimSuffix(query[i+1:end], "`")
+			tokens = append(tokens, sqlToken{text: name, quoted: true})
+			i = end
+		case strings.HasPrefix(query[i:], "/*!"):
+			// 可执行注释：跳过 /*! 和版本号，内容按普通 SQL 处理
+			i += 3
+			for i < len(query) && query[i] >= '0' && query[i] <= '9' {
+				i++
+			}
+		case isWordChar(c):
+			j := i
+			for j < len(query) && isWordChar(query[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: query[i:j]})
+			i = j
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			tokens = append(tokens, sqlToken{text: string(c), punct: true})
+			i++
+		}
+	}
+	return tokens
+}
+
+// SQL 中引用的表，Database 为空表示未限定
+type tableRef struct {
+	Database string
+	Table    string
+}
+
+// 不能作为表名的关键字
+var nonTableKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "LATERAL": true, "DUAL": true, "SET": true, "VALUES": true,
+	"VALUE": true, "WHERE": true, "TABLE": true,
+}
+
+// 结束 FROM/UPDATE 表列表的子句关键字，之后的逗号不再分隔表
+var tableListEnd = map[string]bool{
+	"WHERE": true, "SET": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true,
+	"WINDOW": true, "UNION": true, "EXCEPT": true, "INTERSECT": true, "FOR": true, "LOCK": true,
+	"INTO": true, "VALUES": true, "VALUE": true, "SELECT": true,
+}
+
+// INSERT/REPLACE/UPDATE 与表名之间可能出现的修饰词
+var statementModifiers = map[string]bool{
+	"LOW_PRIORITY": true, "DELAYED": true, "HIGH_PRIORITY": true, "IGNORE": true,
+}
+
+// 尽力找出 SQL 引用的表：FROM、JOIN、UPDATE、INTO、TABLE 之后以及 FROM/UPDATE 表列表中
+// 逗号之后的表名，INSERT/REPLACE 省略 INTO 时的表名，DESCRIBE 的表名，排除 WITH 定义的公用表表达式。
+// 这是词法层面的近似，不能替代数据库账号的权限控制。
+func referencedTables(query string) []tableRef {
+	tokens := sqlTokens(query)
+	ctes := cteNames(tokens)
+	var refs []tableRef
+	addTable := func(pos int) {
+		if ref, ok := parseTableName(tokens, pos); ok && (ref.Database != "" || !ctes[strings.ToLower(ref.Table)]) {
+			refs = append(refs, ref)
+		}
+	}
+	skipModifiers := func(pos int) int {
+		for pos < len(tokens) && !tokens[pos].punct && !tokens[pos].quoted && statementModifiers[strings.ToUpper(tokens[pos].text)] {
+			pos++
+		}
+		return pos
+	}
+
+	// 每层括号的状态：是否为函数调用（EXTRACT(YEAR FROM d) 中的 FROM 不是表），是否处于表列表中
+	type level struct{ function, tableList bool }
+	levels := []level{{}}
+	for i, t := range tokens {
+		cur := &levels[len(levels)-1]
+		if t.punct {
+			switch t.text {
+			case "(":
+				call := i > 0 && tokens[i-1].isWord() && i+1 < len(tokens) && !tokens[i+1].is("SELECT") && !tokens[i+1].is("WITH")
+				levels = append(levels, level{function: call})
+			case ")":
+				if len(levels) > 1 {
+					levels = levels[:len(levels)-1]
+				}
+			case ",":
+				if cur.tableList {
+					addTable(i + 1)
+				}
+			}
+			continue
+		}
+		if t.quoted || cur.function {
+			continue
+		}
+		switch keyword := strings.ToUpper(t.text); {
+		case i == 0 && (keyword == "DESCRIBE" || keyword == "DESC"):
+			addTable(1)
+		case keyword == "FROM" || keyword == "JOIN" || keyword == "STRAIGHT_JOIN" || keyword == "UPDATE":
+			cur.tableList = true
+			addTable(skipModifiers(i + 1))
+		case keyword == "INSERT" || keyword == "REPLACE":
+			if pos := skipModifiers(i + 1); pos < len(tokens) && !tokens[pos].is("INTO") {
+				addTable(pos)
+			}
+		case keyword == "INTO":
+			cur.tableList = false
+			if i+1 < len(tokens) && !tokens[i+1].is("OUTFILE") && !tokens[i+1].is("DUMPFILE") {
+				addTable(i + 1)
+			}
+		case keyword == "TABLE":
+			addTable(i + 1)
+		case tableListEnd[keyword]:
+			cur.tableList = false
+		}
+	}
+	return refs
+}
+
+// 解析 tokens[pos] 开始的 table 或 db.table
+func parseTableName(tokens []sqlToken, pos int) (tableRef, bool) {
+	if pos >= len(tokens) || !tokens[pos].isWord() {
+		return tableRef{}, false
+	}
+	if !tokens[pos].quoted && nonTableKeywords[strings.ToUpper(tokens[pos].text)] {
+		return tableRef{}, false
+	}
+	if pos+2 < len(tokens) && tokens[pos+1].punct && tokens[pos+1].text == "." && tokens[pos+2].isWord() {
+		return tableRef{Database: tokens[pos].text, Table: tokens[pos+2].text}, true
+	}
+	return tableRef{Table: tokens[pos].text}, true
+}
+
+// WITH 定义的公用表表达式名（name AS (...) 或 name (cols) AS (...)），小写
+func cteNames(tokens []sqlToken) map[string]bool {
+	names := make(map[string]bool)
+	for i := 1; i+1 < len(tokens); i++ {
+		if !tokens[i].is("AS") || tokens[i+1].text != "(" || !tokens[i+1].punct {
+			continue
+		}
+		name := i - 1
+		if tokens[name].punct && tokens[name].text == ")" {
+			// 向前找到列名列表的左括号
+			depth := 0
+			for ; name >= 0; name-- {
+				if tokens[name].punct && tokens[name].text == ")" {
+					depth++
+				} else if tokens[name].punct && tokens[name].text == "(" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			name--
+		}
+		if name >= 0 && tokens[name].isWord() {
+			names[strings.ToLower(tokens[name].text)] = true
+		}
+	}
+	return names
+}
+
+// 检查 SQL 引用的表是否都在 MYSQL_ALLOWED_TABLES 范围内，限定了数据库时还检查
+// MYSQL_ALLOWED_DATABASES
+func (s *MCPServer) checkQueryTables(query string) error {
+	for _, ref := range referencedTables(query) {
+		if ref.Database != "" && !s.isDatabaseAllowed(ref.Database) {
+			return &rejectedError{fmt.Sprintf("不允许访问数据库 '%s'", ref.Database)}
+		}
+		if !s.isTableAllowed(ref.Table) {
+			return &rejectedError{fmt.Sprintf("不允许访问表 '%s'", ref.Table)}
+		}
+	}
+	return nil
+}
+
+// 只读查询的检查：checkReadOnlyQuery 通过后，还要求限定的数据库在 MYSQL_ALLOWED_DATABASES 内，
+// 配置了 MYSQL_ALLOWED_TABLES 时引用的表也要在白名单内（见 referencedTables 的局限）
+func (s *MCPServer) checkReadQuery(query string) error {
+	if err := checkReadOnlyQuery(query); err != nil {
+		return err
+	}
+	return s.checkQueryTables(query)
+}
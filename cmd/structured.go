@@ -0,0 +1,212 @@
+package main
+
+// 查询类工具的 outputSchema，对应 structuredQueryResult
+var queryResultOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"columns": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "string"},
+		},
+		"column_types": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "MySQL 列类型，与 columns 一一对应",
+		},
+		"rows": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "object"},
+			"description": "每行以列名为键，数值、布尔和 NULL 保留原始类型",
+		},
+		"row_count": map[string]interface{}{
+			"type": "integer",
+		},
+		"duration_ms": map[string]interface{}{
+			"type": "number",
+		},
+		"omitted_columns": map[string]interface{}{
+			"type":        "integer",
+			"description": "超过 MYSQL_MAX_COLUMNS 而省略的列数",
+		},
+		"partial_error": map[string]interface{}{
+			"type":        "string",
+			"description": "查询中途出错时的错误信息，此时 rows 为部分结果",
+		},
+		"limit_applied": map[string]interface{}{
+			"type":        "integer",
+			"description": "查询未指定 LIMIT 时自动添加的 LIMIT 值（MYSQL_AUTO_LIMIT）",
+		},
+		"truncated": map[string]interface{}{
+			"type":        "boolean",
+			"description": "结果超过 MYSQL_RESULT_MAX_ROWS 或 MYSQL_RESULT_MAX_BYTES，rows 只包含前面的行",
+		},
+		"total_rows": map[string]interface{}{
+			"type":        "integer",
+			"description": "截断时查询实际返回的行数",
+		},
+	},
+	"required": []string{"columns", "rows", "row_count", "duration_ms"},
+}
+
+// 随文本结果一起返回的 structuredContent
+type structuredQueryResult struct {
+	Columns        []string                 `json:"columns"`
+	ColumnTypes    []string                 `json:"column_types,omitempty"`
+	Rows           []map[string]interface{} `json:"rows"`
+	RowCount       int                      `json:"row_count"`
+	DurationMs     float64                  `json:"duration_ms"`
+	OmittedColumns int                      `json:"omitted_columns,omitempty"`
+	PartialError   string                   `json:"partial_error,omitempty"`
+	LimitApplied   int                      `json:"limit_applied,omitempty"`
+	Truncated      bool                     `json:"truncated,omitempty"`
+	TotalRows      int                      `json:"total_rows,omitempty"`
+}
+
+func (s *MCPServer) structuredQueryResult(result *QueryResult) *structuredQueryResult {
+	converted := s.jsonQueryResult(result)
+	rows := converted.Rows
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	columns := result.Columns
+	if columns == nil {
+		columns = []string{}
+	}
+	return &structuredQueryResult{
+		Columns:        columns,
+		ColumnTypes:    result.ColumnTypes,
+		Rows:           rows,
+		RowCount:       result.Count,
+		DurationMs:     float64(result.Duration.Microseconds()) / 1000,
+		OmittedColumns: result.OmittedColumns,
+		PartialError:   result.PartialError,
+		LimitApplied:   result.LimitApplied,
+		Truncated:      result.Truncated,
+		TotalRows:      result.TotalRows,
+	}
+}
+
+// 在工具结果中附加 structuredContent
+func withStructuredContent(resp MCPResponse, content interface{}) MCPResponse {
+	if result, ok := resp.Result.(map[string]interface{}); ok {
+		result["structuredContent"] = content
+	}
+	return resp
+}
+
+// 在 base 的基础上增加属性，生成新的 outputSchema，base 本身不变
+func extendOutputSchema(base map[string]interface{}, properties map[string]interface{}, required ...string) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for name, prop := range base["properties"].(map[string]interface{}) {
+		merged[name] = prop
+	}
+	for name, prop := range properties {
+		merged[name] = prop
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": merged,
+		"required":   append(append([]string{}, base["required"].([]string)...), required...),
+	}
+}
+
+// keyset_page 的 outputSchema：查询结果加上下一页的起点
+var keysetPageOutputSchema = extendOutputSchema(queryResultOutputSchema, map[string]interface{}{
+	"next_after": map[string]interface{}{
+		"description": "下一页作为 after 传入的键值，保留原始类型；null 表示已到最后一页",
+	},
+}, "next_after")
+
+type structuredKeysetPage struct {
+	*structuredQueryResult
+	NextAfter interface{} `json:"next_after"`
+}
+
+// exists 的 outputSchema
+var existsOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"exists": map[string]interface{}{"type": "boolean"},
+	},
+	"required": []string{"exists"},
+}
+
+// check_unique 的 outputSchema
+var checkUniqueOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"count": map[string]interface{}{
+			"type":        "integer",
+			"description": "已有的相同记录数，候选值含 NULL 时不查询，为 0",
+		},
+		"conflict": map[string]interface{}{
+			"type":        "boolean",
+			"description": "插入是否会违反唯一约束",
+		},
+	},
+	"required": []string{"count", "conflict"},
+}
+
+// check_fk 的 outputSchema
+var checkFKOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"referenced_database": map[string]interface{}{"type": "string"},
+		"referenced_table":    map[string]interface{}{"type": "string"},
+		"referenced_column":   map[string]interface{}{"type": "string"},
+		"exists": map[string]interface{}{
+			"type":        "boolean",
+			"description": "候选值在被引用表中是否存在，false 表示插入将违反外键约束",
+		},
+	},
+	"required": []string{"referenced_database", "referenced_table", "referenced_column", "exists"},
+}
+
+type structuredFKCheck struct {
+	ReferencedDatabase string `json:"referenced_database"`
+	ReferencedTable    string `json:"referenced_table"`
+	ReferencedColumn   string `json:"referenced_column"`
+	Exists             bool   `json:"exists"`
+}
+
+// count_rows 的 outputSchema
+var countRowsOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"mode": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"estimated", "exact"},
+		},
+		"tables": map[string]interface{}{
+			"type":        "array",
+			"items":       tableRowCountSchema,
+			"description": "每张表的行数，estimated 模式为预估值",
+		},
+		"skipped": map[string]interface{}{
+			"type":        "array",
+			"items":       tableRowCountSchema,
+			"description": "exact 模式下因预估行数过大而跳过的表，rows 为预估值",
+		},
+	},
+	"required": []string{"mode", "tables"},
+}
+
+var tableRowCountSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"table": map[string]interface{}{"type": "string"},
+		"rows":  map[string]interface{}{"type": "integer"},
+	},
+	"required": []string{"table", "rows"},
+}
+
+type tableRowCount struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+type structuredRowCounts struct {
+	Mode    string          `json:"mode"`
+	Tables  []tableRowCount `json:"tables"`
+	Skipped []tableRowCount `json:"skipped,omitempty"`
+}
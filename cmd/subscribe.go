@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// 一个连接上的资源订阅：URI -> 最近一次的表结构校验和
+type resourceSubscriptions struct {
+	mu   sync.Mutex
+	uris map[string]string
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{uris: make(map[string]string)}
+}
+
+// 为连接开启资源订阅，并在 ctx 结束前按 MYSQL_SUBSCRIPTION_POLL_SECONDS 轮询表结构变化
+func (s *MCPServer) startSubscriptions(ctx context.Context) context.Context {
+	subs := newResourceSubscriptions()
+	ctx = withSubscriptions(ctx, subs)
+	if s.config.SubscriptionPollSeconds > 0 {
+		go s.watchSubscriptions(ctx, subs)
+	}
+	return ctx
+}
+
+// 以 SHOW CREATE TABLE 的哈希作为表结构校验和，表不存在时返回空串
+func (s *MCPServer) tableSchemaChecksum(ctx context.Context, database, tableName string) string {
+	ddl, err := s.showCreateTable(ctx, database, tableName)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(ddl))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *MCPServer) subscribeResource(ctx context.Context, req MCPRequest) MCPResponse {
+	uri, errResp := s.subscriptionURI(ctx, req)
+	if errResp != nil {
+		return *errResp
+	}
+	database, tableName, _, _, _ := parseTableResourceURI(uri)
+	checksum := s.tableSchemaChecksum(ctx, database, tableName)
+
+	subs := subscriptionsFrom(ctx)
+	subs.mu.Lock()
+	subs.uris[uri] = checksum
+	subs.mu.Unlock()
+
+	return MCPResponse{Jsonrpc: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+func (s *MCPServer) unsubscribeResource(ctx context.Context, req MCPRequest) MCPResponse {
+	uri, errResp := s.subscriptionURI(ctx, req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	subs := subscriptionsFrom(ctx)
+	subs.mu.Lock()
+	delete(subs.uris, uri)
+	subs.mu.Unlock()
+
+	return MCPResponse{Jsonrpc: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+// 解析并校验订阅请求中的 uri
+func (s *MCPServer) subscriptionURI(ctx context.Context, req MCPRequest) (string, *MCPResponse) {
+	if subscriptionsFrom(ctx) == nil {
+		resp := s.errorResponse(req.ID, "当前传输不支持资源订阅")
+		return "", &resp
+	}
+
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return "", &MCPResponse{
+			Jsonrpc: "2.0",
+			ID:      req.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	database, tableName, _, _, ok := parseTableResourceURI(params.URI)
+	if !ok || !s.isResourceTableAllowed(database, tableName) {
+		resp := s.resourceNotFound(req.ID, params.URI)
+		return "", &resp
+	}
+	return params.URI, nil
+}
+
+// 定期比较已订阅表的结构校验和，变化时发送 notifications/resources/updated
+func (s *MCPServer) watchSubscriptions(ctx context.Context, subs *resourceSubscriptions) {
+	notify := notifierFrom(ctx)
+	if notify == nil {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.config.SubscriptionPollSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		subs.mu.Lock()
+		uris := make(map[string]string, len(subs.uris))
+		for uri, checksum := range subs.uris {
+			uris[uri] = checksum
+		}
+		subs.mu.Unlock()
+
+		// 同一张表的多个资源只查询一次
+		checksums := make(map[string]string)
+		for uri, old := range uris {
+			database, tableName, _, _, _ := parseTableResourceURI(uri)
+			key := qualifiedTable(database, tableName)
+			current, ok := checksums[key]
+			if !ok {
+				current = s.tableSchemaChecksum(ctx, database, tableName)
+				checksums[key] = current
+			}
+			if current == old {
+				continue
+			}
+
+			subs.mu.Lock()
+			_, stillSubscribed := subs.uris[uri]
+			if stillSubscribed {
+				subs.uris[uri] = current
+			}
+			subs.mu.Unlock()
+			if !stillSubscribed {
+				continue
+			}
+
+			notify(MCPNotification{
+				Jsonrpc: "2.0",
+				Method:  "notifications/resources/updated",
+				Params: map[string]interface{}{
+					"uri": uri,
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// connection_test 每次 ping 的超时时间和最大次数
+const (
+	pingTimeout  = 5 * time.Second
+	maxPingCount = 20
+)
+
+// server_status 默认返回的状态变量
+var defaultStatusVariables = []string{
+	"Uptime",
+	"Threads_connected",
+	"Questions",
+	"Slow_queries",
+	"Aborted_connects",
+}
+
+// 执行 SHOW GLOBAL STATUS LIKE ?，把结果写入 values
+func (s *MCPServer) globalStatus(ctx context.Context, like string, values map[string]interface{}) error {
+	return s.showLike(ctx, "SHOW GLOBAL STATUS", like, values)
+}
+
+// 执行 SHOW ... LIKE ? 这类返回名称/值两列的语句，把结果写入 values
+func (s *MCPServer) showLike(ctx context.Context, statement, like string, values map[string]interface{}) error {
+	rows, err := s.db.QueryContext(ctx, statement+" LIKE ?", like)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			continue
+		}
+		values[name] = parseNumber(value)
+	}
+	return rows.Err()
+}
+
+// 尽量把状态值解析为数字，失败时保留原字符串
+func parseNumber(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+func (s *MCPServer) serverStatus(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	values := make(map[string]interface{})
+
+	if like, ok := args["like"].(string); ok && like != "" {
+		if !s.config.AllowAdmin {
+			return s.errorResponse(id, "查看全部状态变量需要设置 MYSQL_ALLOW_ADMIN=true")
+		}
+		if err := s.globalStatus(ctx, like, values); err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+	} else {
+		for _, name := range defaultStatusVariables {
+			if err := s.globalStatus(ctx, name, values); err != nil {
+				return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+
+	return s.textResponse(id, fmt.Sprintf("服务器状态 (%d 项):\n\n%s\n", len(values), data))
+}
+
+func (s *MCPServer) showVariables(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	like, _ := args["like"].(string)
+	if like == "" {
+		like = "%"
+	}
+	statement := "SHOW GLOBAL VARIABLES"
+	if scope, _ := args["scope"].(string); scope == "session" {
+		statement = "SHOW SESSION VARIABLES"
+	}
+
+	values := make(map[string]interface{})
+	if err := s.showLike(ctx, statement, like, values); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if len(values) == 0 {
+		return s.textResponse(id, fmt.Sprintf("没有匹配 '%s' 的变量\n", like))
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+	return s.textResponse(id, fmt.Sprintf("%s LIKE '%s' (%d 项):\n\n%s\n", statement, like, len(values), data))
+}
+
+func (s *MCPServer) connectionTest(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	count := 5
+	if c, ok := args["count"].(float64); ok && c > 0 {
+		count = int(c)
+	}
+	if count > maxPingCount {
+		count = maxPingCount
+	}
+
+	var latencies []time.Duration
+	for i := 0; i < count; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		start := time.Now()
+		err := s.db.PingContext(ctx)
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			return s.errorResponse(id, fmt.Sprintf("第 %d 次 ping 失败: %v", i+1, err))
+		}
+		latencies = append(latencies, elapsed)
+	}
+	minLatency, avgLatency, maxLatency := latencyStats(latencies)
+
+	var version string
+	if err := s.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	result := fmt.Sprintf("连接测试 (%s:%d, %d 次 ping):\n\n", s.config.Host, s.config.Port, count)
+	result += fmt.Sprintf("最小延迟: %s\n", minLatency.Round(time.Microsecond))
+	result += fmt.Sprintf("平均延迟: %s\n", avgLatency.Round(time.Microsecond))
+	result += fmt.Sprintf("最大延迟: %s\n", maxLatency.Round(time.Microsecond))
+	result += fmt.Sprintf("服务器版本: %s\n", version)
+	result += fmt.Sprintf("当前数据库: %s\n", s.currentDatabase(ctx))
+
+	return s.textResponse(id, result)
+}
+
+// 计算延迟的最小值、平均值和最大值
+func latencyStats(latencies []time.Duration) (minLatency, avgLatency, maxLatency time.Duration) {
+	if len(latencies) == 0 {
+		return 0, 0, 0
+	}
+	var total time.Duration
+	minLatency = latencies[0]
+	for _, l := range latencies {
+		total += l
+		if l < minLatency {
+			minLatency = l
+		}
+		if l > maxLatency {
+			maxLatency = l
+		}
+	}
+	return minLatency, total / time.Duration(len(latencies)), maxLatency
+}
+
+// 对查询行为影响较大的 sql_mode 标志及说明
+var sqlModeNotes = map[string]string{
+	"ONLY_FULL_GROUP_BY":  "GROUP BY: SELECT 中的非聚合列必须出现在 GROUP BY 中或函数依赖于分组列",
+	"NO_ZERO_DATE":        "零日期: 不允许 '0000-00-00'（严格模式下报错，否则警告）",
+	"NO_ZERO_IN_DATE":     "零日期: 不允许月或日为 0 的日期，如 '2024-00-10'",
+	"ALLOW_INVALID_DATES": "零日期: 只检查月 1-12、日 1-31，允许 '2024-02-31' 这类无效日期",
+	"STRICT_TRANS_TABLES": "严格模式: 事务表中非法或越界的值直接报错而不是截断",
+	"STRICT_ALL_TABLES":   "严格模式: 所有表中非法或越界的值直接报错而不是截断",
+}
+
+// 拆分 sql_mode 字符串为标志列表
+func splitSQLMode(mode string) []string {
+	var flags []string
+	for _, flag := range strings.Split(mode, ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+func (s *MCPServer) sqlMode(ctx context.Context, id interface{}) MCPResponse {
+	var mode string
+	if err := s.db.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode").Scan(&mode); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	flags := splitSQLMode(mode)
+	result := fmt.Sprintf("sql_mode: %s\n\n标志 (%d):\n", mode, len(flags))
+	var notes []string
+	for _, flag := range flags {
+		result += "  " + flag + "\n"
+		if note, ok := sqlModeNotes[flag]; ok {
+			notes = append(notes, fmt.Sprintf("  %s — %s", flag, note))
+		}
+	}
+	if !strings.Contains(","+mode+",", ",ONLY_FULL_GROUP_BY,") {
+		notes = append(notes, "  未启用 ONLY_FULL_GROUP_BY — GROUP BY 查询中的非聚合列会返回不确定的值")
+	}
+	if len(notes) > 0 {
+		result += "\n需要注意:\n" + strings.Join(notes, "\n") + "\n"
+	}
+
+	return s.textResponse(id, result)
+}
+
+// show_processlist 中 INFO 列最多显示的字符数
+const maxProcessInfoLength = 200
+
+func (s *MCPServer) showProcesslist(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query := `
+		SELECT ID, USER, HOST, COALESCE(DB, ''), COMMAND, TIME, COALESCE(STATE, ''), COALESCE(INFO, '')
+		FROM information_schema.PROCESSLIST WHERE 1 = 1`
+	var queryArgs []interface{}
+	if user, ok := args["user"].(string); ok && user != "" {
+		query += " AND USER = ?"
+		queryArgs = append(queryArgs, user)
+	}
+	if db, ok := args["db"].(string); ok && db != "" {
+		query += " AND DB = ?"
+		queryArgs = append(queryArgs, db)
+	}
+	if state, ok := args["state"].(string); ok && state != "" {
+		query += " AND STATE LIKE ?"
+		queryArgs = append(queryArgs, state)
+	}
+	if includeIdle, _ := args["include_idle"].(bool); !includeIdle {
+		query += " AND COMMAND <> 'Sleep'"
+	}
+	query += " ORDER BY TIME DESC, ID"
+
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	count := 0
+	for rows.Next() {
+		var processID, seconds int64
+		var user, host, db, command, state, info string
+		if err := rows.Scan(&processID, &user, &host, &db, &command, &seconds, &state, &info); err != nil {
+			continue
+		}
+		// 语句中的字面量可能包含敏感数据，未开启 MYSQL_ALLOW_ADMIN 时脱敏显示
+		if !s.config.AllowAdmin {
+			info = redactSQL(info)
+		}
+		if runes := []rune(info); len(runes) > maxProcessInfoLength {
+			info = string(runes[:maxProcessInfoLength]) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%-8d %-16s %-22s %-16s %-10s %6ds %-24s %s\n",
+			processID, user, host, db, command, seconds, state, strings.Join(strings.Fields(info), " ")))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, "没有匹配的连接\n")
+	}
+
+	header := fmt.Sprintf("%-8s %-16s %-22s %-16s %-10s %7s %-24s %s\n",
+		"ID", "USER", "HOST", "DB", "COMMAND", "TIME", "STATE", "INFO")
+	return s.textResponse(id, fmt.Sprintf("当前连接 (%d):\n\n", count)+header+sb.String())
+}
+
+// 终止连接正在执行的语句；connection 为 true 时断开整个连接
+func (s *MCPServer) killProcess(ctx context.Context, id interface{}, args map[string]interface{}, connection bool) MCPResponse {
+	n, _ := args["process_id"].(float64)
+	processID := int64(n)
+	if processID <= 0 {
+		return s.errorResponse(id, "process_id 必须是正整数")
+	}
+
+	statement, action := "KILL QUERY ", "终止连接 %d 正在执行的语句"
+	if connection {
+		statement, action = "KILL CONNECTION ", "断开连接 %d"
+	}
+	target := fmt.Sprintf(action, processID)
+	confirmed, err := s.confirmWithUser(ctx, args, fmt.Sprintf("即将%s，确认执行？", target))
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	query := statement + strconv.FormatInt(processID, 10)
+	start := time.Now()
+	_, err = s.db.ExecContext(ctx, query)
+	s.logQuery(ctx, query, time.Since(start), 0, err)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	s.logEvent(ctx, "notice", "已%s", target)
+	return s.textResponse(id, fmt.Sprintf("已%s\n", target))
+}
+
+// 执行新语法的语句，服务器版本较旧不支持时改用旧语法（如 SHOW REPLICA STATUS / SHOW SLAVE STATUS）
+func (s *MCPServer) runWithFallback(ctx context.Context, query, legacy string) (*QueryResult, error) {
+	result, err := s.runQuery(ctx, query)
+	if isMySQLError(err, mysqlParseError) {
+		return s.runQuery(ctx, legacy)
+	}
+	return result, err
+}
+
+// 按新名称和旧名称（Source/Master、Replica/Slave）依次取字段值
+func replicationField(row map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if v, ok := row[name]; ok {
+			if v == nil {
+				return "NULL"
+			}
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+func (s *MCPServer) replicationStatus(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	replica, err := s.runWithFallback(ctx, "SHOW REPLICA STATUS", "SHOW SLAVE STATUS")
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	source, err := s.runWithFallback(ctx, "SHOW BINARY LOG STATUS", "SHOW MASTER STATUS")
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	var sb strings.Builder
+	if replica.Count == 0 {
+		sb.WriteString("本实例未配置为副本\n")
+	}
+	for _, row := range replica.Rows {
+		ioRunning := replicationField(row, "Replica_IO_Running", "Slave_IO_Running")
+		sqlRunning := replicationField(row, "Replica_SQL_Running", "Slave_SQL_Running")
+		lag := replicationField(row, "Seconds_Behind_Source", "Seconds_Behind_Master")
+
+		channel := replicationField(row, "Channel_Name")
+		if channel == "" {
+			channel = "(默认)"
+		}
+		sb.WriteString(fmt.Sprintf("复制通道 %s:\n", channel))
+		sb.WriteString(fmt.Sprintf("  源库:          %s:%s\n",
+			replicationField(row, "Source_Host", "Master_Host"), replicationField(row, "Source_Port", "Master_Port")))
+		sb.WriteString(fmt.Sprintf("  IO 线程:       %s (%s)\n", ioRunning, replicationField(row, "Replica_IO_State", "Slave_IO_State")))
+		sb.WriteString(fmt.Sprintf("  SQL 线程:      %s (%s)\n", sqlRunning,
+			replicationField(row, "Replica_SQL_Running_State", "Slave_SQL_Running_State")))
+		sb.WriteString(fmt.Sprintf("  延迟秒数:      %s\n", lag))
+		sb.WriteString(fmt.Sprintf("  已接收 GTID:   %s\n", replicationField(row, "Retrieved_Gtid_Set")))
+		sb.WriteString(fmt.Sprintf("  已执行 GTID:   %s\n", replicationField(row, "Executed_Gtid_Set")))
+		for _, name := range []string{"Last_IO_Error", "Last_SQL_Error"} {
+			if msg := replicationField(row, name); msg != "" && msg != "NULL" {
+				sb.WriteString(fmt.Sprintf("  %s: %s\n", name, msg))
+			}
+		}
+		if ioRunning == "Yes" && sqlRunning == "Yes" && lag != "NULL" {
+			sb.WriteString("  状态: 正常\n")
+		} else {
+			sb.WriteString("  状态: 异常，IO/SQL 线程未运行或延迟未知\n")
+		}
+	}
+
+	if source.Count > 0 {
+		row := source.Rows[0]
+		sb.WriteString("\n二进制日志:\n")
+		sb.WriteString(fmt.Sprintf("  当前文件:      %s\n", replicationField(row, "File")))
+		sb.WriteString(fmt.Sprintf("  位置:          %s\n", replicationField(row, "Position")))
+		sb.WriteString(fmt.Sprintf("  已执行 GTID:   %s\n", replicationField(row, "Executed_Gtid_Set")))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+// MySQL 账号
+type mysqlAccount struct {
+	User string
+	Host string
+}
+
+func (s *MCPServer) listUsers(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query := "SELECT User, Host FROM mysql.user"
+	var queryArgs []interface{}
+	if like, ok := args["user"].(string); ok && like != "" {
+		query += " WHERE User LIKE ?"
+		queryArgs = append(queryArgs, like)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY User, Host", queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	var accounts []mysqlAccount
+	for rows.Next() {
+		var a mysqlAccount
+		if err := rows.Scan(&a.User, &a.Host); err != nil {
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if len(accounts) == 0 {
+		return s.textResponse(id, "没有找到账号\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("账号 (%d):\n", len(accounts)))
+	for _, a := range accounts {
+		sb.WriteString(fmt.Sprintf("\n'%s'@'%s':\n", a.User, a.Host))
+		grants, err := s.db.QueryContext(ctx, "SHOW GRANTS FOR "+quoteString(a.User)+"@"+quoteString(a.Host))
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("  读取权限失败: %v\n", err))
+			continue
+		}
+		for grants.Next() {
+			var grant string
+			if err := grants.Scan(&grant); err != nil {
+				continue
+			}
+			sb.WriteString("  " + grant + "\n")
+		}
+		grants.Close()
+	}
+	return s.textResponse(id, sb.String())
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 开启管理类工具
+func allowAdmin(cfg *MySQLConfig) { cfg.AllowAdmin = true }
+
+func TestServerStatus(t *testing.T) {
+	runToolCases(t, "server_status", []toolCase{
+		{
+			name: "default variables",
+			expect: func(mock sqlmock.Sqlmock) {
+				for _, name := range defaultStatusVariables {
+					mock.ExpectQuery(regexp.QuoteMeta("SHOW GLOBAL STATUS LIKE ?")).WithArgs(name).
+						WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow(name, "1"))
+				}
+			},
+			want: []string{"服务器状态 (5 项)", `"Uptime": 1`},
+		},
+		{
+			name:   "LIKE filter parses numbers",
+			config: allowAdmin,
+			args:   map[string]interface{}{"like": "Innodb_buffer_pool_%"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW GLOBAL STATUS LIKE ?")).WithArgs("Innodb_buffer_pool_%").
+					WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+						AddRow("Innodb_buffer_pool_pages_free", "8192").
+						AddRow("Innodb_buffer_pool_hit_rate", "0.998").
+						AddRow("Innodb_buffer_pool_load_status", "Buffer pool(s) load completed"))
+			},
+			want: []string{"服务器状态 (3 项)",
+				`"Innodb_buffer_pool_pages_free": 8192`,
+				`"Innodb_buffer_pool_hit_rate": 0.998`,
+				`"Innodb_buffer_pool_load_status": "Buffer pool(s) load completed"`},
+		},
+		{
+			name:    "LIKE filter requires admin",
+			args:    map[string]interface{}{"like": "%"},
+			wantErr: "查看全部状态变量需要设置 MYSQL_ALLOW_ADMIN=true",
+		},
+	})
+}
+
+func TestReplicationStatus(t *testing.T) {
+	runToolCases(t, "replication_status", []toolCase{
+		{
+			name: "not a replica",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+					WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State"}))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW BINARY LOG STATUS")).
+					WillReturnRows(sqlmock.NewRows([]string{"File", "Position", "Executed_Gtid_Set"}).
+						AddRow("binlog.000003", 157, ""))
+			},
+			want: []string{"本实例未配置为副本", "当前文件:      binlog.000003", "位置:          157"},
+		},
+	})
+}
+
+func TestShowVariables(t *testing.T) {
+	runToolCases(t, "show_variables", []toolCase{
+		{
+			name: "session scope",
+			args: map[string]interface{}{"like": "autocommit", "scope": "session"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW SESSION VARIABLES LIKE ?")).WithArgs("autocommit").
+					WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("autocommit", "ON"))
+			},
+			want: []string{"SHOW SESSION VARIABLES LIKE 'autocommit' (1 项)", `"autocommit": "ON"`},
+		},
+		{
+			name: "no match",
+			args: map[string]interface{}{"like": "nope%"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW GLOBAL VARIABLES LIKE ?")).WithArgs("nope%").
+					WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}))
+			},
+			want: []string{"没有匹配 'nope%' 的变量"},
+		},
+	})
+}
+
+func TestShowProcesslist(t *testing.T) {
+	processColumns := []string{"ID", "USER", "HOST", "DB", "COMMAND", "TIME", "STATE", "INFO"}
+	runToolCases(t, "show_processlist", []toolCase{
+		{
+			name: "redacts literals without admin",
+			args: map[string]interface{}{"user": "app"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AND USER = ? AND COMMAND <> 'Sleep' ORDER BY TIME DESC, ID")).WithArgs("app").
+					WillReturnRows(sqlmock.NewRows(processColumns).
+						AddRow(12, "app", "10.0.0.1:5000", "testdb", "Query", 3, "executing", "SELECT * FROM users WHERE email = 'a@x'"))
+			},
+			want: []string{"当前连接 (1)", "WHERE email = '?'"},
+		},
+		{
+			name: "no connections",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.PROCESSLIST").WillReturnRows(sqlmock.NewRows(processColumns))
+			},
+			want: []string{"没有匹配的连接"},
+		},
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	runToolCases(t, "list_users", []toolCase{
+		{
+			name:   "accounts with grants",
+			config: allowAdmin,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT User, Host FROM mysql.user ORDER BY User, Host")).
+					WillReturnRows(sqlmock.NewRows([]string{"User", "Host"}).AddRow("app", "%"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW GRANTS FOR 'app'@'%'")).
+					WillReturnRows(sqlmock.NewRows([]string{"Grants"}).AddRow("GRANT SELECT ON `testdb`.* TO `app`@`%`"))
+			},
+			want: []string{"账号 (1)", "'app'@'%':", "GRANT SELECT ON `testdb`.*"},
+		},
+		{
+			name:    "requires admin",
+			wantErr: "工具 list_users 已禁用",
+		},
+	})
+}
+
+func TestKillQuery(t *testing.T) {
+	runToolCases(t, "kill_query", []toolCase{
+		{
+			name:   "kills the statement",
+			config: allowAdmin,
+			args:   map[string]interface{}{"process_id": 12, "confirm": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("KILL QUERY 12")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"已终止连接 12 正在执行的语句"},
+		},
+		{
+			name:    "rejects non-positive id",
+			config:  allowAdmin,
+			args:    map[string]interface{}{"process_id": 0, "confirm": true},
+			wantErr: "process_id 必须是正整数",
+		},
+	})
+}
+
+func TestKillConnection(t *testing.T) {
+	runToolCases(t, "kill_connection", []toolCase{
+		{
+			name:   "kills the connection",
+			config: allowAdmin,
+			args:   map[string]interface{}{"process_id": 12, "confirm": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("KILL CONNECTION 12")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"已断开连接 12"},
+		},
+	})
+}
+
+func TestConnectionTest(t *testing.T) {
+	runToolCases(t, "connection_test", []toolCase{
+		{
+			name: "reports version and database",
+			args: map[string]interface{}{"count": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT VERSION()")).
+					WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.36"))
+			},
+			want: []string{"2 次 ping", "服务器版本: 8.0.36", "当前数据库: testdb"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if !regexp.MustCompile(`最小延迟: \S+\n平均延迟: \S+\n最大延迟: \S+\n`).MatchString(responseText(resp)) {
+					t.Errorf("缺少延迟统计:\n%s", responseText(resp))
+				}
+			},
+		},
+		{
+			name: "count capped",
+			args: map[string]interface{}{"count": 1000},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT VERSION()")).
+					WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.36"))
+			},
+			want: []string{fmt.Sprintf("%d 次 ping", maxPingCount)},
+		},
+	})
+}
+
+func TestLatencyStats(t *testing.T) {
+	ms := time.Millisecond
+	cases := []struct {
+		name          string
+		latencies     []time.Duration
+		min, avg, max time.Duration
+	}{
+		{name: "empty", latencies: nil},
+		{name: "single", latencies: []time.Duration{3 * ms}, min: 3 * ms, avg: 3 * ms, max: 3 * ms},
+		{name: "unordered", latencies: []time.Duration{4 * ms, 1 * ms, 7 * ms}, min: 1 * ms, avg: 4 * ms, max: 7 * ms},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			minLatency, avgLatency, maxLatency := latencyStats(tc.latencies)
+			if minLatency != tc.min || avgLatency != tc.avg || maxLatency != tc.max {
+				t.Errorf("latencyStats = (%s, %s, %s)，期望 (%s, %s, %s)",
+					minLatency, avgLatency, maxLatency, tc.min, tc.avg, tc.max)
+			}
+		})
+	}
+}
+
+func TestSQLMode(t *testing.T) {
+	runToolCases(t, "sql_mode", []toolCase{
+		{
+			name: "lists flags",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT @@SESSION.sql_mode")).
+					WillReturnRows(sqlmock.NewRows([]string{"mode"}).AddRow("ONLY_FULL_GROUP_BY"))
+			},
+			want: []string{"sql_mode: ONLY_FULL_GROUP_BY", "标志 (1):"},
+		},
+		{
+			name: "notes for the flags that affect queries",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT @@SESSION.sql_mode")).
+					WillReturnRows(sqlmock.NewRows([]string{"mode"}).
+						AddRow("ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_DATE,NO_ENGINE_SUBSTITUTION"))
+			},
+			want: []string{
+				"标志 (4):\n  ONLY_FULL_GROUP_BY\n  STRICT_TRANS_TABLES\n  NO_ZERO_DATE\n  NO_ENGINE_SUBSTITUTION\n",
+				"  ONLY_FULL_GROUP_BY — GROUP BY:",
+				"  STRICT_TRANS_TABLES — 严格模式:",
+				"  NO_ZERO_DATE — 零日期:",
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				text := responseText(resp)
+				if strings.Contains(text, "NO_ENGINE_SUBSTITUTION —") || strings.Contains(text, "未启用 ONLY_FULL_GROUP_BY") {
+					t.Errorf("不应出现多余的说明:\n%s", text)
+				}
+			},
+		},
+		{
+			name: "warns when ONLY_FULL_GROUP_BY is off",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT @@SESSION.sql_mode")).
+					WillReturnRows(sqlmock.NewRows([]string{"mode"}).AddRow(""))
+			},
+			want: []string{"标志 (0):", "未启用 ONLY_FULL_GROUP_BY"},
+		},
+	})
+}
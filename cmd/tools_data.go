@@ -0,0 +1,989 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// query_matching_tables 最多处理的表数量
+const maxMatchingTables = 50
+
+// 合法的表名/列名：字母、数字、下划线和 $，最长 64 个字符
+var identifierPattern = regexp.MustCompile(`^[A-Za-z0-9_$]{1,64}$`)
+
+func validateIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", errInvalidIdentifier, name)
+	}
+	return nil
+}
+
+// 用反引号包裹标识符，并转义其中的反引号
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (s *MCPServer) queryMatchingTables(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return s.errorResponse(id, "pattern is required")
+	}
+	template, ok := args["template"].(string)
+	if !ok || template == "" {
+		return s.errorResponse(id, "template is required")
+	}
+	if !strings.Contains(template, "{table}") {
+		return s.errorResponse(id, "template 中必须包含 {table} 占位符")
+	}
+	if err := s.checkReadQuery(template); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(template))
+		return s.queryErrorResponse(id, err)
+	}
+
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	matched, err := s.runQuery(ctx, "SHOW TABLES FROM "+quoteIdentifier(database)+" LIKE ?", pattern)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var tables []string
+	for i := range matched.Rows {
+		var tableName sql.NullString
+		if err := scanResultRow(matched, i, &tableName); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName.String) {
+			continue
+		}
+		tables = append(tables, tableName.String)
+	}
+
+	if len(tables) == 0 {
+		return s.textResponse(id, fmt.Sprintf("没有匹配 '%s' 的表\n", pattern))
+	}
+	if len(tables) > maxMatchingTables {
+		return s.errorResponse(id, fmt.Sprintf("匹配到 %d 张表，超过上限 %d，请缩小匹配范围", len(tables), maxMatchingTables))
+	}
+
+	// 汇总所有表的结果，第一列为来源表名
+	merged := &QueryResult{Columns: []string{"_table"}}
+	seen := map[string]bool{"_table": true}
+	for _, table := range tables {
+		query := strings.ReplaceAll(template, "{table}", qualifiedTable(database, table))
+		result, err := s.runQuery(ctx, query)
+		if err != nil {
+			return s.errorResponse(id, fmt.Sprintf("表 '%s' %v", table, err))
+		}
+
+		merged.Duration += result.Duration
+		if result.OmittedColumns > merged.OmittedColumns {
+			merged.OmittedColumns = result.OmittedColumns
+		}
+		for _, col := range result.Columns {
+			if !seen[col] {
+				seen[col] = true
+				merged.Columns = append(merged.Columns, col)
+			}
+		}
+		for _, row := range result.Rows {
+			row["_table"] = table
+			merged.Rows = append(merged.Rows, row)
+		}
+	}
+	merged.Count = len(merged.Rows)
+
+	resultText := fmt.Sprintf("匹配 '%s' 的表 (%d 张): %s\n\n", pattern, len(tables), strings.Join(tables, ", "))
+	resultText += formatQueryResult(merged) + s.timingNote(merged)
+
+	return s.textResponse(id, resultText)
+}
+
+// 从参数中读取字符串数组
+func stringSliceArg(args map[string]interface{}, key string) ([]string, bool) {
+	raw, ok := args[key].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		str, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, str)
+	}
+	return values, true
+}
+
+func (s *MCPServer) checkUnique(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	columns, ok := stringSliceArg(args, "columns")
+	if !ok || len(columns) == 0 {
+		return s.errorResponse(id, "columns is required")
+	}
+	values, ok := args["values"].([]interface{})
+	if !ok {
+		return s.errorResponse(id, "values is required")
+	}
+	if len(values) != len(columns) {
+		return s.errorResponse(id, fmt.Sprintf("values 数量 (%d) 与 columns 数量 (%d) 不一致", len(values), len(columns)))
+	}
+
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	conditions := make([]string, 0, len(columns))
+	queryArgs := make([]interface{}, 0, len(columns))
+	for i, col := range columns {
+		if err := validateIdentifier(col); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		// 唯一索引允许多个 NULL，包含 NULL 的组合不会冲突
+		if values[i] == nil {
+			return withStructuredContent(s.textResponse(id, fmt.Sprintf("列 %s 的候选值为 NULL，不会违反唯一约束\n", col)),
+				map[string]interface{}{"count": 0, "conflict": false})
+		}
+		val, ok := bindValue(values[i])
+		if !ok {
+			return s.queryErrorResponse(id, &argumentError{Path: fmt.Sprintf("values[%d]", i), Reason: "只能是字符串、数字、布尔值或 null"})
+		}
+		conditions = append(conditions, quoteIdentifier(col)+" = ?")
+		queryArgs = append(queryArgs, val)
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", qualifiedTable(database, tableName), strings.Join(conditions, " AND "))
+	result, err := s.runQuery(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var count sql.NullInt64
+	if err := scanResultRow(result, 0, &count); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	resultText := fmt.Sprintf("表 '%s' 中 (%s) = (%s) 的已有记录数: %d\n",
+		tableName, strings.Join(columns, ", "), formatValues(values), count.Int64)
+	if count.Int64 > 0 {
+		resultText += "结论: 插入会违反唯一约束\n"
+	} else {
+		resultText += "结论: 不会违反唯一约束\n"
+	}
+
+	return withStructuredContent(s.textResponse(id, resultText),
+		map[string]interface{}{"count": count.Int64, "conflict": count.Int64 > 0})
+}
+
+func formatValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *MCPServer) distinctCount(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	columnName, ok := args["column_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "column_name is required")
+	}
+	for _, name := range []string{tableName, columnName} {
+		if err := validateIdentifier(name); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s", quoteIdentifier(columnName), qualifiedTable(database, tableName))
+
+	percent, sampled := args["sample_percent"].(float64)
+	if !sampled || percent >= 100 {
+		result, err := s.runQuery(ctx, query)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		var count sql.NullInt64
+		if err := scanResultRow(result, 0, &count); err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+		return s.textResponse(id, fmt.Sprintf("表 '%s' 列 '%s' 的不同值数量: %d（精确值）\n", tableName, columnName, count.Int64))
+	}
+	if percent <= 0 {
+		return s.errorResponse(id, "sample_percent 必须在 0 到 100 之间")
+	}
+
+	// 抽样模式：统计样本中每个值出现的次数，再按出现次数的分布估算总体的不同值数量
+	fraction := percent / 100
+	freqQuery := fmt.Sprintf(
+		"SELECT cnt, COUNT(*) FROM (SELECT COUNT(*) AS cnt FROM %s WHERE RAND() < ? AND %s IS NOT NULL GROUP BY %s) AS sample GROUP BY cnt",
+		qualifiedTable(database, tableName), quoteIdentifier(columnName), quoteIdentifier(columnName))
+	freq, err := s.runQuery(ctx, freqQuery, fraction)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var sampleDistinct, singletons int64
+	for i := range freq.Rows {
+		var occurrences, values sql.NullInt64
+		if err := scanResultRow(freq, i, &occurrences, &values); err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+		sampleDistinct += values.Int64
+		if occurrences.Int64 == 1 {
+			singletons = values.Int64
+		}
+	}
+	estimate := geeDistinctEstimate(sampleDistinct, singletons, fraction)
+
+	return s.textResponse(id, fmt.Sprintf(
+		"表 '%s' 列 '%s' 的不同值数量: 约 %d（近似值，基于 %.2f%% 抽样的 GEE 估计；样本中不同值 %d 个，为下界）\n",
+		tableName, columnName, estimate, percent, sampleDistinct))
+}
+
+// GEE 估计（Charikar 等）：样本中只出现一次的值按 sqrt(1/q) 放大，出现多次的值视为已全部发现。
+// 低基数列的值在样本中都会重复出现，估计值接近样本中的不同值数量，而不会按抽样比例线性放大。
+func geeDistinctEstimate(sampleDistinct, singletons int64, fraction float64) int64 {
+	return int64(math.Round(math.Sqrt(1/fraction)*float64(singletons))) + sampleDistinct - singletons
+}
+
+// 把一行数据规范化为可比较的字符串：值统一转成文本，键按 JSON 编码排序
+func rowKey(row map[string]interface{}) string {
+	normalized := make(map[string]interface{}, len(row))
+	for col, val := range row {
+		if val == nil {
+			normalized[col] = nil
+		} else {
+			normalized[col] = fmt.Sprintf("%v", val)
+		}
+	}
+	data, _ := json.Marshal(normalized)
+	return string(data)
+}
+
+func (s *MCPServer) assertQuery(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query, ok := args["query"].(string)
+	if !ok {
+		return s.errorResponse(id, "query is required")
+	}
+	rawExpected, ok := args["expected"].([]interface{})
+	if !ok {
+		return s.errorResponse(id, "expected is required")
+	}
+	ordered, _ := args["ordered"].(bool)
+
+	expected := make([]string, 0, len(rawExpected))
+	for i, item := range rawExpected {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return s.errorResponse(id, fmt.Sprintf("expected[%d] 必须是对象", i))
+		}
+		expected = append(expected, rowKey(row))
+	}
+
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+	result, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	actual := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		actual = append(actual, rowKey(row))
+	}
+
+	var diff []string
+	if ordered {
+		for i := 0; i < len(expected) || i < len(actual); i++ {
+			switch {
+			case i >= len(actual):
+				diff = append(diff, fmt.Sprintf("第 %d 行缺失: 期望 %s", i+1, expected[i]))
+			case i >= len(expected):
+				diff = append(diff, fmt.Sprintf("第 %d 行多余: 实际 %s", i+1, actual[i]))
+			case expected[i] != actual[i]:
+				diff = append(diff, fmt.Sprintf("第 %d 行不一致:\n  期望 %s\n  实际 %s", i+1, expected[i], actual[i]))
+			}
+		}
+	} else {
+		// 按多重集合比较，忽略行顺序
+		remaining := make(map[string]int)
+		for _, key := range actual {
+			remaining[key]++
+		}
+		for _, key := range expected {
+			if remaining[key] > 0 {
+				remaining[key]--
+				continue
+			}
+			diff = append(diff, "缺失: "+key)
+		}
+		for _, key := range actual {
+			if remaining[key] > 0 {
+				remaining[key]--
+				diff = append(diff, "多余: "+key)
+			}
+		}
+	}
+
+	if len(diff) == 0 {
+		return s.textResponse(id, fmt.Sprintf("断言通过: 实际结果与期望一致 (%d 行)\n", len(actual)))
+	}
+	resultText := fmt.Sprintf("断言失败: 期望 %d 行，实际 %d 行，差异 %d 处\n\n", len(expected), len(actual), len(diff))
+	resultText += strings.Join(diff, "\n") + "\n"
+
+	return s.textResponse(id, resultText)
+}
+
+// 转义 LIKE 模式中的通配符
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+func (s *MCPServer) searchInTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	searchTerm, ok := args["search_term"].(string)
+	if !ok || searchTerm == "" {
+		return s.errorResponse(id, "search_term is required")
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+			AND DATA_TYPE IN ('char', 'varchar', 'tinytext', 'text', 'mediumtext', 'longtext', 'enum', 'set')
+		ORDER BY ORDINAL_POSITION
+	`, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	var conditions []string
+	var queryArgs []interface{}
+	pattern := "%" + escapeLike(searchTerm) + "%"
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			continue
+		}
+		conditions = append(conditions, quoteIdentifier(columnName)+" LIKE ?")
+		queryArgs = append(queryArgs, pattern)
+	}
+	rows.Close()
+
+	if len(conditions) == 0 {
+		return s.errorResponse(id, fmt.Sprintf("表 '%s' 没有可搜索的文本列", tableName))
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT %d",
+		qualifiedTable(database, tableName), strings.Join(conditions, " OR "), s.limitArg(args))
+	result, err := s.runQuery(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	return s.queryResultResponse(id, result, "text")
+}
+
+func (s *MCPServer) pluck(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	query, ok := args["query"].(string)
+	if !ok {
+		return s.errorResponse(id, "query is required")
+	}
+	format, _ := args["format"].(string)
+	if format != "" && format != "lines" && format != "json" {
+		return s.errorResponse(id, fmt.Sprintf("不支持的输出格式: %s", format))
+	}
+	if err := s.checkReadQuery(query); err != nil {
+		s.logEvent(ctx, "warning", "拒绝执行语句: %v: %s", err, redactSQL(query))
+		return s.queryErrorResponse(id, err)
+	}
+
+	result, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if total := len(result.Columns) + result.OmittedColumns; total != 1 {
+		return s.errorResponse(id, fmt.Sprintf("pluck 只支持返回一列的查询，当前查询返回 %d 列", total))
+	}
+
+	rows := result.Rows
+	truncated := 0
+	if s.config.MaxRows > 0 && len(rows) > s.config.MaxRows {
+		truncated = len(rows) - s.config.MaxRows
+		rows = rows[:s.config.MaxRows]
+	}
+
+	col := result.Columns[0]
+	values := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row[col])
+	}
+
+	var resultText string
+	if format == "json" {
+		data, err := json.Marshal(values)
+		if err != nil {
+			return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+		}
+		resultText = string(data) + "\n"
+	} else {
+		lines := make([]string, len(values))
+		for i, v := range values {
+			if v == nil {
+				lines[i] = "NULL"
+			} else {
+				lines[i] = fmt.Sprintf("%v", v)
+			}
+		}
+		resultText = strings.Join(lines, "\n") + "\n"
+	}
+	if truncated > 0 {
+		resultText += fmt.Sprintf("\n（超过 MYSQL_MAX_ROWS，另有 %d 个值未返回）\n", truncated)
+	}
+
+	return s.textResponse(id, resultText)
+}
+
+// 根据 列名->值 的过滤条件生成参数化的 WHERE 子句（不含 WHERE 关键字），
+// 列名按字典序排列以保证 SQL 稳定；值为 nil 时使用 IS NULL
+func buildFilterClause(filters map[string]interface{}) (string, []interface{}, error) {
+	columns := make([]string, 0, len(filters))
+	for col := range filters {
+		if err := validateIdentifier(col); err != nil {
+			return "", nil, err
+		}
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	conditions := make([]string, 0, len(columns))
+	var args []interface{}
+	for _, col := range columns {
+		if filters[col] == nil {
+			conditions = append(conditions, quoteIdentifier(col)+" IS NULL")
+			continue
+		}
+		val, ok := bindValue(filters[col])
+		if !ok {
+			return "", nil, &argumentError{Path: "filters." + col, Reason: "只能是字符串、数字、布尔值或 null"}
+		}
+		conditions = append(conditions, quoteIdentifier(col)+" = ?")
+		args = append(args, val)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+func (s *MCPServer) exists(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	filters, _ := args["filters"].(map[string]interface{})
+	where, queryArgs, err := buildFilterClause(filters)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	inner := "SELECT 1 FROM " + qualifiedTable(database, tableName)
+	if where != "" {
+		inner += " WHERE " + where
+	}
+	query := fmt.Sprintf("SELECT EXISTS(%s LIMIT 1)", inner)
+
+	result, err := s.runQuery(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var found sql.NullBool
+	if err := scanResultRow(result, 0, &found); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	return withStructuredContent(s.textResponse(id, fmt.Sprintf("%t\n", found.Bool)),
+		map[string]interface{}{"exists": found.Bool})
+}
+
+func (s *MCPServer) checkFK(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	columnName, ok := args["column_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "column_name is required")
+	}
+	value, ok := args["value"]
+	if !ok || value == nil {
+		return s.errorResponse(id, "value is required")
+	}
+	// 整数值按 int64 绑定，对象和数组不能作为候选值
+	boundValue, ok := bindValue(value)
+	if !ok {
+		return s.queryErrorResponse(id, &argumentError{Path: "value", Reason: "只能是字符串、数字或布尔值"})
+	}
+	for _, name := range []string{tableName, columnName} {
+		if err := validateIdentifier(name); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	var refDatabase, refTable, refColumn string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?
+			AND REFERENCED_TABLE_NAME IS NOT NULL
+		LIMIT 1
+	`, database, tableName, columnName).Scan(&refDatabase, &refTable, &refColumn)
+	if err == sql.ErrNoRows {
+		return s.errorResponse(id, fmt.Sprintf("列 %s.%s 不是外键", tableName, columnName))
+	}
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if !s.isTableAllowed(refTable) {
+		return s.tableNotAllowed(id, refTable)
+	}
+	if !s.isDatabaseAllowed(refDatabase) {
+		return s.queryErrorResponse(id, &rejectedError{fmt.Sprintf("不允许访问数据库 '%s'", refDatabase)})
+	}
+
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s = ? LIMIT 1)",
+		qualifiedTable(refDatabase, refTable), quoteIdentifier(refColumn))
+	result, err := s.runQuery(ctx, query, boundValue)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var found sql.NullBool
+	if err := scanResultRow(result, 0, &found); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	resultText := fmt.Sprintf("%s.%s 引用 %s.%s\n", tableName, columnName, refTable, refColumn)
+	if found.Bool {
+		resultText += fmt.Sprintf("值 %v 在 %s 中存在: true\n", value, refTable)
+	} else {
+		resultText += fmt.Sprintf("值 %v 在 %s 中存在: false（插入将违反外键约束）\n", value, refTable)
+	}
+
+	return withStructuredContent(s.textResponse(id, resultText), &structuredFKCheck{
+		ReferencedDatabase: refDatabase,
+		ReferencedTable:    refTable,
+		ReferencedColumn:   refColumn,
+		Exists:             found.Bool,
+	})
+}
+
+// pivot 最多生成的透视列数
+const maxPivotColumns = 30
+
+func (s *MCPServer) pivot(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	rowColumn, ok := args["row"].(string)
+	if !ok {
+		return s.errorResponse(id, "row is required")
+	}
+	pivotColumn, ok := args["column"].(string)
+	if !ok {
+		return s.errorResponse(id, "column is required")
+	}
+	agg, _ := args["agg"].(string)
+	if agg == "" {
+		agg = "count"
+	}
+	valueColumn, _ := args["value"].(string)
+	if agg != "count" && agg != "sum" {
+		return s.errorResponse(id, fmt.Sprintf("不支持的聚合方式: %s", agg))
+	}
+	if agg == "sum" && valueColumn == "" {
+		return s.errorResponse(id, "agg 为 sum 时 value is required")
+	}
+
+	names := []string{tableName, rowColumn, pivotColumn}
+	if agg == "sum" {
+		names = append(names, valueColumn)
+	}
+	for _, name := range names {
+		if err := validateIdentifier(name); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	// 先取出透视列的不同值
+	distinctQuery := fmt.Sprintf("SELECT DISTINCT %s FROM %s ORDER BY 1 LIMIT %d",
+		quoteIdentifier(pivotColumn), qualifiedTable(database, tableName), maxPivotColumns+1)
+	distinct, err := s.runQuery(ctx, distinctQuery)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if len(distinct.Rows) > maxPivotColumns {
+		return s.errorResponse(id, fmt.Sprintf("列 '%s' 的不同值超过 %d 个，无法透视", pivotColumn, maxPivotColumns))
+	}
+
+	cellValue := "1"
+	if agg == "sum" {
+		cellValue = quoteIdentifier(valueColumn)
+	}
+
+	// 结果行按列名存放，别名与分组列或已有别名重复（不区分大小写）时加后缀，如 NULL 值与字符串 'NULL'
+	used := map[string]bool{strings.ToLower(rowColumn): true}
+	uniqueAlias := func(name string) string {
+		alias := name
+		for i := 2; used[strings.ToLower(alias)]; i++ {
+			alias = fmt.Sprintf("%s_%d", name, i)
+		}
+		used[strings.ToLower(alias)] = true
+		return quoteIdentifier(alias)
+	}
+
+	selectItems := []string{quoteIdentifier(rowColumn)}
+	var queryArgs []interface{}
+	for _, row := range distinct.Rows {
+		value := row[distinct.Columns[0]]
+		if value == nil {
+			selectItems = append(selectItems, fmt.Sprintf("SUM(CASE WHEN %s IS NULL THEN %s ELSE 0 END) AS %s",
+				quoteIdentifier(pivotColumn), cellValue, uniqueAlias("NULL")))
+			continue
+		}
+		selectItems = append(selectItems, fmt.Sprintf("SUM(CASE WHEN %s = ? THEN %s ELSE 0 END) AS %s",
+			quoteIdentifier(pivotColumn), cellValue, uniqueAlias(fmt.Sprintf("%v", value))))
+		queryArgs = append(queryArgs, value)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s GROUP BY %s ORDER BY %s",
+		strings.Join(selectItems, ", "), qualifiedTable(database, tableName), quoteIdentifier(rowColumn), quoteIdentifier(rowColumn))
+	if s.config.MaxRows > 0 {
+		query += fmt.Sprintf(" LIMIT %d", s.config.MaxRows)
+	}
+	result, err := s.runQuery(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	return s.queryResultResponse(id, result, "text")
+}
+
+func (s *MCPServer) joinCount(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	var names [4]string
+	for i, key := range []string{"left_table", "left_column", "right_table", "right_column"} {
+		name, ok := args[key].(string)
+		if !ok {
+			return s.errorResponse(id, key+" is required")
+		}
+		if err := validateIdentifier(name); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		names[i] = name
+	}
+	leftTable, leftColumn, rightTable, rightColumn := names[0], names[1], names[2], names[3]
+	for _, tableName := range []string{leftTable, rightTable} {
+		if !s.isTableAllowed(tableName) {
+			return s.tableNotAllowed(id, tableName)
+		}
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	// 使用别名 l / r，支持自关联
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s AS l JOIN %s AS r ON l.%s = r.%s",
+		qualifiedTable(database, leftTable), qualifiedTable(database, rightTable), quoteIdentifier(leftColumn), quoteIdentifier(rightColumn))
+	result, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var count sql.NullInt64
+	if err := scanResultRow(result, 0, &count); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	return s.textResponse(id, fmt.Sprintf("%s.%s = %s.%s 匹配行数: %d\n", leftTable, leftColumn, rightTable, rightColumn, count.Int64))
+}
+
+func (s *MCPServer) keysetPage(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	keyColumn, ok := args["key_column"].(string)
+	if !ok {
+		return s.errorResponse(id, "key_column is required")
+	}
+	for _, name := range []string{tableName, keyColumn} {
+		if err := validateIdentifier(name); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	limit := s.limitArg(args)
+	table := qualifiedTable(database, tableName)
+
+	// 键列的值必须唯一，否则重复值跨页时会漏行；同时取得列名的实际大小写，用于从结果行中读取 next_after
+	uniqueColumns, err := s.uniqueKeyColumns(ctx, table)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	found := false
+	for _, col := range uniqueColumns {
+		if strings.EqualFold(col, keyColumn) {
+			keyColumn, found = col, true
+			break
+		}
+	}
+	if !found {
+		return s.queryErrorResponse(id, &argumentError{Path: "key_column",
+			Reason: fmt.Sprintf("列 '%s' 不是表 '%s' 的主键或单列唯一索引，不能用于 keyset 分页", keyColumn, tableName)})
+	}
+
+	query := "SELECT * FROM " + table
+	var queryArgs []interface{}
+	if rawAfter, ok := args["after"]; ok && rawAfter != nil {
+		after, ok := bindValue(rawAfter)
+		if !ok {
+			return s.queryErrorResponse(id, &argumentError{Path: "after", Reason: "只能是字符串、数字或布尔值"})
+		}
+		query += fmt.Sprintf(" WHERE %s > ?", quoteIdentifier(keyColumn))
+		queryArgs = append(queryArgs, after)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", quoteIdentifier(keyColumn), limit)
+
+	result, err := s.runQuery(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	resultText := formatQueryResult(result)
+	page := &structuredKeysetPage{structuredQueryResult: s.structuredQueryResult(result)}
+	// 本页不满说明已经到达末尾
+	if len(result.Rows) == limit && limit > 0 {
+		page.NextAfter = result.Rows[len(result.Rows)-1][keyColumn]
+		// 以 JSON 输出，原样作为下一次调用的 after 传入时类型不变
+		data, err := json.Marshal(page.NextAfter)
+		if err != nil {
+			return s.errorResponse(id, fmt.Sprintf("编码 next_after 错误: %v", err))
+		}
+		resultText += fmt.Sprintf("\nnext_after: %s\n", data)
+	} else {
+		resultText += "\nnext_after: null（已到最后一页）\n"
+	}
+
+	return withStructuredContent(s.textResponse(id, resultText+s.timingNote(result)), page)
+}
+
+// find_duplicates 默认和最多返回的重复组数，以及每组显示的主键样例数
+const (
+	defaultDuplicateGroups = 20
+	maxDuplicateGroups     = 100
+	duplicateSampleKeys    = 5
+)
+
+// SHOW KEYS 中的一行：索引名和列名
+type indexColumn struct {
+	Key    string
+	Column string
+}
+
+// 执行 SHOW KEYS FROM table WHERE ...，按结果顺序（同一索引内按列顺序）返回索引名和列名
+func (s *MCPServer) showKeys(ctx context.Context, table, where string) ([]indexColumn, error) {
+	rows, err := s.db.QueryContext(ctx, "SHOW KEYS FROM "+table+" WHERE "+where)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	keyIndex, nameIndex := -1, -1
+	for i, col := range columns {
+		switch col {
+		case "Key_name":
+			keyIndex = i
+		case "Column_name":
+			nameIndex = i
+		}
+	}
+	if keyIndex < 0 || nameIndex < 0 {
+		return nil, fmt.Errorf("SHOW KEYS 结果中没有 Key_name 或 Column_name 列")
+	}
+
+	var keys []indexColumn
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, indexColumn{Key: values[keyIndex].String, Column: values[nameIndex].String})
+	}
+	return keys, rows.Err()
+}
+
+// 按顺序返回表的主键列，没有主键时返回空
+func (s *MCPServer) primaryKeyColumns(ctx context.Context, table string) ([]string, error) {
+	keys, err := s.showKeys(ctx, table, "Key_name = 'PRIMARY'")
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		columns = append(columns, key.Column)
+	}
+	return columns, nil
+}
+
+// 返回单独构成主键或唯一索引的列，这些列上的值互不重复，可以用于 keyset 分页
+func (s *MCPServer) uniqueKeyColumns(ctx context.Context, table string) ([]string, error) {
+	keys, err := s.showKeys(ctx, table, "Non_unique = 0")
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, key := range keys {
+		counts[key.Key]++
+	}
+	var columns []string
+	for _, key := range keys {
+		if counts[key.Key] == 1 {
+			columns = append(columns, key.Column)
+		}
+	}
+	return columns, nil
+}
+
+func (s *MCPServer) findDuplicates(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	columns, ok := stringSliceArg(args, "columns")
+	if !ok || len(columns) == 0 {
+		return s.errorResponse(id, "columns is required")
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		if err := validateIdentifier(col); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		quoted[i] = quoteIdentifier(col)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	limit := defaultDuplicateGroups
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+	if limit < 1 || limit > maxDuplicateGroups {
+		return s.errorResponse(id, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxDuplicateGroups))
+	}
+	format, _ := args["format"].(string)
+	table := qualifiedTable(database, tableName)
+
+	keys, err := s.primaryKeyColumns(ctx, table)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	selectList := strings.Join(quoted, ", ") + ", COUNT(*) AS duplicate_count"
+	if len(keys) > 0 {
+		// 每组取前几个主键作为样例，复合主键的各列以逗号连接
+		quotedKeys := make([]string, len(keys))
+		for i, key := range keys {
+			quotedKeys[i] = quoteIdentifier(key)
+		}
+		keyExpr := "CONCAT_WS(',', " + strings.Join(quotedKeys, ", ") + ")"
+		selectList += fmt.Sprintf(", SUBSTRING_INDEX(GROUP_CONCAT(%s ORDER BY %s SEPARATOR ' | '), ' | ', %d) AS sample_keys",
+			keyExpr, strings.Join(quotedKeys, ", "), duplicateSampleKeys)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s GROUP BY %s HAVING COUNT(*) > 1 ORDER BY duplicate_count DESC LIMIT %d",
+		selectList, table, strings.Join(quoted, ", "), limit)
+
+	result, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.queryResultResponse(id, result, format)
+}
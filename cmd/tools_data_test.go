@@ -0,0 +1,600 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQueryMatchingTables(t *testing.T) {
+	runToolCases(t, "query_matching_tables", []toolCase{
+		{
+			name: "no matching tables",
+			args: map[string]interface{}{"pattern": "log_%", "template": "SELECT COUNT(*) FROM {table}"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW TABLES FROM `testdb` LIKE ?")).WithArgs("log_%").
+					WillReturnRows(sqlmock.NewRows([]string{"Tables_in_testdb"}))
+			},
+			want: []string{"没有匹配 'log_%' 的表"},
+		},
+		{
+			name: "template applied to each table",
+			args: map[string]interface{}{"pattern": "log_%", "template": "SELECT COUNT(*) AS n FROM {table}"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW TABLES FROM `testdb` LIKE ?")).WithArgs("log_%").
+					WillReturnRows(sqlmock.NewRows([]string{"Tables_in_testdb"}).AddRow("log_2023").AddRow("log_2024"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS n FROM `testdb`.`log_2023`")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(17))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS n FROM `testdb`.`log_2024`")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(5))
+			},
+			want: []string{"匹配 'log_%' 的表 (2 张): log_2023, log_2024", "log_2023 17", "log_2024 5"},
+		},
+		{
+			name:    "requires placeholder",
+			args:    map[string]interface{}{"pattern": "log_%", "template": "SELECT 1"},
+			wantErr: "{table}",
+		},
+	})
+}
+
+func TestCheckUnique(t *testing.T) {
+	runToolCases(t, "check_unique", []toolCase{
+		{
+			name: "existing row violates the constraint",
+			args: map[string]interface{}{"table_name": "users", "columns": []interface{}{"tenant_id", "email"},
+				"values": []interface{}{3, "a@x' OR '1'='1"}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users` WHERE `tenant_id` = ? AND `email` = ?")).
+					WithArgs(int64(3), "a@x' OR '1'='1").
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+			},
+			want:  []string{"已有记录数: 1", "结论: 插入会违反唯一约束"},
+			check: wantStructured(`{"conflict":true,"count":1}`),
+		},
+		{
+			name: "no existing row",
+			args: map[string]interface{}{"table_name": "users", "columns": []interface{}{"email"}, "values": []interface{}{"new@x"}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users` WHERE `email` = ?")).WithArgs("new@x").
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+			},
+			want:  []string{"结论: 不会违反唯一约束"},
+			check: wantStructured(`{"conflict":false,"count":0}`),
+		},
+		{
+			name: "NULL never conflicts",
+			args: map[string]interface{}{"table_name": "users", "columns": []interface{}{"email"}, "values": []interface{}{nil}},
+			want: []string{"列 email 的候选值为 NULL，不会违反唯一约束"},
+		},
+		{
+			name:    "values must match columns",
+			args:    map[string]interface{}{"table_name": "users", "columns": []interface{}{"a", "b"}, "values": []interface{}{1}},
+			wantErr: "values 数量 (1) 与 columns 数量 (2) 不一致",
+		},
+	})
+}
+
+func TestFindDuplicates(t *testing.T) {
+	runToolCases(t, "find_duplicates", []toolCase{
+		{
+			name: "groups with primary key samples",
+			args: map[string]interface{}{"table_name": "users", "columns": []interface{}{"email"}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW KEYS FROM `testdb`.`users` WHERE Key_name = 'PRIMARY'")).
+					WillReturnRows(sqlmock.NewRows([]string{"Table", "Key_name", "Column_name"}).AddRow("users", "PRIMARY", "id"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `email`, COUNT(*) AS duplicate_count, " +
+					"SUBSTRING_INDEX(GROUP_CONCAT(CONCAT_WS(',', `id`) ORDER BY `id` SEPARATOR ' | '), ' | ', 5) AS sample_keys " +
+					"FROM `testdb`.`users` GROUP BY `email` HAVING COUNT(*) > 1 ORDER BY duplicate_count DESC LIMIT 20")).
+					WillReturnRows(sqlmock.NewRows([]string{"email", "duplicate_count", "sample_keys"}).AddRow("a@x", 2, "1 | 7"))
+			},
+			want: []string{"a@x", "1 | 7"},
+		},
+		{
+			name:    "limit out of range",
+			args:    map[string]interface{}{"table_name": "users", "columns": []interface{}{"email"}, "limit": 500},
+			wantErr: "limit 必须在 1 到 100 之间",
+		},
+	})
+}
+
+func TestDistinctCount(t *testing.T) {
+	runToolCases(t, "distinct_count", []toolCase{
+		{
+			name: "exact count",
+			args: map[string]interface{}{"table_name": "users", "column_name": "country"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(DISTINCT `country`) FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(12))
+			},
+			want: []string{"表 'users' 列 'country' 的不同值数量: 12（精确值）"},
+		},
+		{
+			name: "sampled estimate",
+			args: map[string]interface{}{"table_name": "users", "column_name": "country", "sample_percent": 25},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT cnt, COUNT(*) FROM (SELECT COUNT(*) AS cnt FROM `testdb`.`users` " +
+					"WHERE RAND() < ? AND `country` IS NOT NULL GROUP BY `country`) AS sample GROUP BY cnt")).WithArgs(0.25).
+					WillReturnRows(sqlmock.NewRows([]string{"cnt", "COUNT(*)"}).AddRow(1, 3).AddRow(4, 5))
+			},
+			// GEE: sqrt(1/0.25)*3 + 8 - 3 = 11
+			want: []string{"约 11（近似值", "样本中不同值 8 个"},
+		},
+		{
+			name: "100 percent falls back to the exact count",
+			args: map[string]interface{}{"table_name": "users", "column_name": "country", "sample_percent": 100},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(DISTINCT `country`) FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(12))
+			},
+			want: []string{"（精确值）"},
+		},
+	})
+}
+
+func TestAssertQuery(t *testing.T) {
+	runToolCases(t, "assert_query", []toolCase{
+		{
+			name:    "rejects writes",
+			args:    map[string]interface{}{"query": "UPDATE users SET name = 'x'", "expected": []interface{}{}},
+			wantErr: "只允许执行SELECT、SHOW、DESCRIBE查询",
+		},
+		{
+			name: "passes ignoring row order",
+			args: map[string]interface{}{
+				"query": "SELECT id, name FROM users",
+				"expected": []interface{}{
+					map[string]interface{}{"id": float64(2), "name": "bob"},
+					map[string]interface{}{"id": float64(1), "name": "alice"},
+				},
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "bob"))
+			},
+			want: []string{"断言通过: 实际结果与期望一致 (2 行)"},
+		},
+		{
+			name: "fails with a diff",
+			args: map[string]interface{}{
+				"query": "SELECT id, name FROM users",
+				"expected": []interface{}{
+					map[string]interface{}{"id": float64(1), "name": "alice"},
+					map[string]interface{}{"id": float64(3), "name": "carol"},
+				},
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice").AddRow(2, "bob"))
+			},
+			want: []string{
+				"断言失败: 期望 2 行，实际 2 行，差异 2 处",
+				`缺失: {"id":"3","name":"carol"}`,
+				`多余: {"id":"2","name":"bob"}`,
+			},
+		},
+		{
+			name: "ordered comparison reports the row",
+			args: map[string]interface{}{
+				"query":   "SELECT id FROM users ORDER BY id",
+				"ordered": true,
+				"expected": []interface{}{
+					map[string]interface{}{"id": float64(2)},
+					map[string]interface{}{"id": float64(1)},
+				},
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users ORDER BY id")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+			},
+			want: []string{"第 1 行不一致:\n  期望 {\"id\":\"2\"}\n  实际 {\"id\":\"1\"}"},
+		},
+	})
+}
+
+func TestSearchInTable(t *testing.T) {
+	runToolCases(t, "search_in_table", []toolCase{
+		{
+			name: "no text columns",
+			args: map[string]interface{}{"table_name": "metrics", "search_term": "x"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DATA_TYPE IN").WithArgs(testDatabase, "metrics").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+			},
+			wantErr: "表 'metrics' 没有可搜索的文本列",
+		},
+		{
+			name: "OR of LIKEs over the text columns",
+			args: map[string]interface{}{"table_name": "users", "search_term": "50%_off", "limit": 5},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DATA_TYPE IN").WithArgs(testDatabase, "users").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("name").AddRow("bio"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` WHERE `name` LIKE ? OR `bio` LIKE ? LIMIT 5")+"$").
+					WithArgs(`%50\%\_off%`, `%50\%\_off%`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "bio"}).AddRow(7, "promo", "50%_off everything"))
+			},
+			want: []string{"50%_off everything"},
+		},
+	})
+}
+
+func TestPluck(t *testing.T) {
+	runToolCases(t, "pluck", []toolCase{
+		{
+			name:    "rejects writes",
+			args:    map[string]interface{}{"query": "DELETE FROM users"},
+			wantErr: "只允许执行SELECT、SHOW、DESCRIBE查询",
+		},
+		{
+			name:    "rejects unknown format",
+			args:    map[string]interface{}{"query": "SELECT id FROM users", "format": "csv"},
+			wantErr: "format: 取值必须是 [lines json] 之一",
+		},
+		{
+			name: "single column as lines",
+			args: map[string]interface{}{"query": "SELECT email FROM users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("a@x").AddRow(nil).AddRow("b@x"))
+			},
+			want: []string{"a@x\nNULL\nb@x\n"},
+		},
+		{
+			name: "single column as json",
+			args: map[string]interface{}{"query": "SELECT id FROM users", "format": "json"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+			},
+			want: []string{"[1,2,3]\n"},
+		},
+		{
+			name:   "values beyond MYSQL_MAX_ROWS are dropped",
+			config: func(cfg *MySQLConfig) { cfg.MaxRows = 2 },
+			args:   map[string]interface{}{"query": "SELECT id FROM users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+			},
+			want: []string{"1\n2\n", "另有 1 个值未返回"},
+		},
+		{
+			name: "rejects multi-column queries",
+			args: map[string]interface{}{"query": "SELECT id, email FROM users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email FROM users")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "a@x"))
+			},
+			wantErr: "pluck 只支持返回一列的查询，当前查询返回 2 列",
+		},
+	})
+}
+
+func TestExists(t *testing.T) {
+	runToolCases(t, "exists", []toolCase{
+		{
+			name: "without filters",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM `testdb`.`users` LIMIT 1)")).
+					WillReturnRows(sqlmock.NewRows([]string{"e"}).AddRow(true))
+			},
+			want:  []string{"true\n"},
+			check: wantStructured(`{"exists":true}`),
+		},
+		{
+			name: "parameterized filters in column order",
+			args: map[string]interface{}{"table_name": "users", "filters": map[string]interface{}{
+				"status": "active", "email": "a@x", "deleted_at": nil}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM `testdb`.`users` "+
+					"WHERE `deleted_at` IS NULL AND `email` = ? AND `status` = ? LIMIT 1)")).
+					WithArgs("a@x", "active").
+					WillReturnRows(sqlmock.NewRows([]string{"e"}).AddRow(false))
+			},
+			want:  []string{"false\n"},
+			check: wantStructured(`{"exists":false}`),
+		},
+		{
+			name:    "rejects invalid filter column",
+			args:    map[string]interface{}{"table_name": "users", "filters": map[string]interface{}{"id; DROP": 1}},
+			wantErr: `非法的标识符: "id; DROP"`,
+		},
+	})
+}
+
+func TestCheckFK(t *testing.T) {
+	runToolCases(t, "check_fk", []toolCase{
+		{
+			name: "column is not a foreign key",
+			args: map[string]interface{}{"table_name": "orders", "column_name": "note", "value": "x"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase, "orders", "note").
+					WillReturnRows(sqlmock.NewRows([]string{"REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}))
+			},
+			wantErr: "列 orders.note 不是外键",
+		},
+		{
+			name: "referenced value exists",
+			args: map[string]interface{}{"table_name": "orders", "column_name": "user_id", "value": 42},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase, "orders", "user_id").
+					WillReturnRows(sqlmock.NewRows([]string{"REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+						AddRow("testdb", "users", "id"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM `testdb`.`users` WHERE `id` = ? LIMIT 1)")).
+					WithArgs(int64(42)).
+					WillReturnRows(sqlmock.NewRows([]string{"e"}).AddRow(true))
+			},
+			want:  []string{"orders.user_id 引用 users.id\n", "值 42 在 users 中存在: true\n"},
+			check: wantStructured(`{"referenced_database":"testdb","referenced_table":"users","referenced_column":"id","exists":true}`),
+		},
+		{
+			name:   "missing value would violate the constraint",
+			config: func(cfg *MySQLConfig) { cfg.AllowedDatabases = []string{"accounts"} },
+			args:   map[string]interface{}{"table_name": "orders", "column_name": "user_id", "value": 99},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase, "orders", "user_id").
+					WillReturnRows(sqlmock.NewRows([]string{"REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+						AddRow("accounts", "users", "id"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM `accounts`.`users` WHERE `id` = ? LIMIT 1)")).
+					WithArgs(int64(99)).
+					WillReturnRows(sqlmock.NewRows([]string{"e"}).AddRow(false))
+			},
+			want: []string{"值 99 在 users 中存在: false（插入将违反外键约束）"},
+		},
+		{
+			name: "rejects an object value",
+			args: map[string]interface{}{"table_name": "orders", "column_name": "user_id", "value": map[string]interface{}{"id": 1}},
+			check: func(t *testing.T, resp MCPResponse) {
+				if resp.Error == nil || resp.Error.Code != -32602 {
+					t.Errorf("期望 -32602，得到 %+v", resp.Error)
+				}
+			},
+			wantErr: "value: 只能是字符串、数字或布尔值",
+		},
+		{
+			name:   "referenced table outside allowlist",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders"} },
+			args:   map[string]interface{}{"table_name": "orders", "column_name": "user_id", "value": 1},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase, "orders", "user_id").
+					WillReturnRows(sqlmock.NewRows([]string{"REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+						AddRow("testdb", "users", "id"))
+			},
+			wantErr: "不允许访问表 'users'",
+		},
+	})
+}
+
+func TestPivot(t *testing.T) {
+	runToolCases(t, "pivot", []toolCase{
+		{
+			name:    "unsupported aggregate",
+			args:    map[string]interface{}{"table_name": "orders", "row": "region", "column": "status", "agg": "avg"},
+			wantErr: "agg: 取值必须是 [count sum] 之一",
+		},
+		{
+			name:    "sum requires value",
+			args:    map[string]interface{}{"table_name": "orders", "row": "region", "column": "status", "agg": "sum"},
+			wantErr: "agg 为 sum 时 value is required",
+		},
+		{
+			name: "count CASE column per distinct value",
+			args: map[string]interface{}{"table_name": "orders", "row": "region", "column": "status"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `status` FROM `testdb`.`orders` ORDER BY 1 LIMIT 31")).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(nil).AddRow("paid").AddRow("shipped"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `region`, "+
+					"SUM(CASE WHEN `status` IS NULL THEN 1 ELSE 0 END) AS `NULL`, "+
+					"SUM(CASE WHEN `status` = ? THEN 1 ELSE 0 END) AS `paid`, "+
+					"SUM(CASE WHEN `status` = ? THEN 1 ELSE 0 END) AS `shipped` "+
+					"FROM `testdb`.`orders` GROUP BY `region` ORDER BY `region` LIMIT 1000")).
+					WithArgs("paid", "shipped").
+					WillReturnRows(sqlmock.NewRows([]string{"region", "NULL", "paid", "shipped"}).AddRow("north", 0, 4, 2))
+			},
+			want: []string{"north"},
+		},
+		{
+			name: "sum of the value column",
+			args: map[string]interface{}{"table_name": "orders", "row": "region", "column": "status", "agg": "sum", "value": "amount"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `status` FROM `testdb`.`orders`")).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paid"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `region`, SUM(CASE WHEN `status` = ? THEN `amount` ELSE 0 END) AS `paid` FROM")).
+					WithArgs("paid").
+					WillReturnRows(sqlmock.NewRows([]string{"region", "paid"}).AddRow("north", "99.50"))
+			},
+			want: []string{"99.50"},
+		},
+		{
+			name: "aliases colliding with the row column or NULL get a suffix",
+			args: map[string]interface{}{"table_name": "orders", "row": "region", "column": "label"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `label` FROM `testdb`.`orders`")).
+					WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow(nil).AddRow("NULL").AddRow("region"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `region`, "+
+					"SUM(CASE WHEN `label` IS NULL THEN 1 ELSE 0 END) AS `NULL`, "+
+					"SUM(CASE WHEN `label` = ? THEN 1 ELSE 0 END) AS `NULL_2`, "+
+					"SUM(CASE WHEN `label` = ? THEN 1 ELSE 0 END) AS `region_2` FROM")).
+					WithArgs("NULL", "region").
+					WillReturnRows(sqlmock.NewRows([]string{"region", "NULL", "NULL_2", "region_2"}).AddRow("north", 1, 2, 3))
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				if got := structuredJSON(t, resp); !strings.Contains(got, `"rows":[{"NULL":1,"NULL_2":2,"region":"north","region_2":3}]`) {
+					t.Errorf("structuredContent = %s，每个透视列都应保留", got)
+				}
+			},
+		},
+		{
+			name: "too many distinct values",
+			args: map[string]interface{}{"table_name": "orders", "row": "region", "column": "sku"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				rows := sqlmock.NewRows([]string{"sku"})
+				for i := 0; i <= maxPivotColumns; i++ {
+					rows.AddRow(i)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `sku`")).WillReturnRows(rows)
+			},
+			wantErr: "列 'sku' 的不同值超过 30 个，无法透视",
+		},
+	})
+}
+
+func TestJoinCount(t *testing.T) {
+	runToolCases(t, "join_count", []toolCase{
+		{
+			name:   "rejects table outside allowlist",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders"} },
+			args: map[string]interface{}{"left_table": "orders", "left_column": "user_id",
+				"right_table": "users", "right_column": "id"},
+			wantErr: "不允许访问表 'users'",
+		},
+		{
+			name: "JOIN COUNT with aliases",
+			args: map[string]interface{}{"left_table": "orders", "left_column": "user_id",
+				"right_table": "users", "right_column": "id"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`orders` AS l JOIN `testdb`.`users` AS r ON l.`user_id` = r.`id`")).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(128))
+			},
+			want: []string{"orders.user_id = users.id 匹配行数: 128\n"},
+		},
+		{
+			name: "self join",
+			args: map[string]interface{}{"left_table": "employees", "left_column": "manager_id",
+				"right_table": "employees", "right_column": "id"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("FROM `testdb`.`employees` AS l JOIN `testdb`.`employees` AS r ON l.`manager_id` = r.`id`")).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(9))
+			},
+			want: []string{"匹配行数: 9"},
+		},
+		{
+			name: "rejects invalid identifiers",
+			args: map[string]interface{}{"left_table": "orders", "left_column": "user_id) OR (1",
+				"right_table": "users", "right_column": "id"},
+			wantErr: `非法的标识符: "user_id) OR (1"`,
+		},
+	})
+}
+
+func TestKeysetPage(t *testing.T) {
+	// users 的主键为 id，email 上有单列唯一索引，(tenant_id, code) 为组合唯一索引
+	expectUniqueKeys := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SHOW KEYS FROM `testdb`.`users` WHERE Non_unique = 0")).
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name"}).
+				AddRow("users", 0, "PRIMARY", 1, "id").
+				AddRow("users", 0, "uk_email", 1, "email").
+				AddRow("users", 0, "uk_tenant_code", 1, "tenant_id").
+				AddRow("users", 0, "uk_tenant_code", 2, "code"))
+	}
+	runToolCases(t, "keyset_page", []toolCase{
+		{
+			name: "last page",
+			args: map[string]interface{}{"table_name": "users", "key_column": "id", "limit": 5},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` ORDER BY `id` LIMIT 5")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			want: []string{"next_after: null（已到最后一页）"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if got := structuredJSON(t, resp); !strings.Contains(got, `"row_count":1`) || !strings.HasSuffix(got, `"next_after":null}`) {
+					t.Errorf("structuredContent = %s，期望 next_after 为 null", got)
+				}
+			},
+		},
+		{
+			name: "full page continues after the given key",
+			args: map[string]interface{}{"table_name": "users", "key_column": "id", "after": 20, "limit": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` WHERE `id` > ? ORDER BY `id` LIMIT 2") + "$").
+					WithArgs(int64(20)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(21, "amy").AddRow(25, "ben"))
+			},
+			want: []string{"amy", "ben", "\nnext_after: 25\n"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if got := structuredJSON(t, resp); !strings.Contains(got, `"name":"ben"`) || !strings.HasSuffix(got, `"next_after":25}`) {
+					t.Errorf("structuredContent = %s，期望 next_after 为 25", got)
+				}
+			},
+		},
+		{
+			name: "string keys are printed as JSON",
+			args: map[string]interface{}{"table_name": "users", "key_column": "EMAIL", "after": "b@x", "limit": 1},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` WHERE `email` > ? ORDER BY `email` LIMIT 1")).
+					WithArgs("b@x").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("c@x"))
+			},
+			want: []string{`next_after: "c@x"`},
+		},
+		{
+			name: "rejects a column outside any unique key",
+			args: map[string]interface{}{"table_name": "users", "key_column": "name"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+			},
+			wantErr: "列 'name' 不是表 'users' 的主键或单列唯一索引",
+		},
+		{
+			name: "rejects a column of a composite unique key",
+			args: map[string]interface{}{"table_name": "users", "key_column": "tenant_id"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+			},
+			wantErr: "列 'tenant_id' 不是表 'users' 的主键或单列唯一索引",
+		},
+		{
+			name: "rejects a non-scalar after",
+			args: map[string]interface{}{"table_name": "users", "key_column": "id", "after": []interface{}{1}},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUniqueKeys(mock)
+			},
+			wantErr: "after",
+			check: func(t *testing.T, resp MCPResponse) {
+				if resp.Error == nil || resp.Error.Code != -32602 {
+					t.Errorf("期望 -32602，得到 %+v", resp.Error)
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 列类型只接受简单形式，如 INT UNSIGNED、VARCHAR(255)、DECIMAL(10,2)
+var columnTypePattern = regexp.MustCompile(`(?i)^[a-z]+( ?\(\d+( ?, ?\d+)?\))?( (unsigned|zerofill|binary))*$`)
+
+// 检查 DDL 是否启用并校验表名
+func (s *MCPServer) ddlTable(ctx context.Context, id interface{}, args map[string]interface{}) (string, string, *MCPResponse) {
+	if s.isReadOnly() || !s.config.AllowDDL {
+		resp := s.errorResponse(id, "DDL 未启用，需要以 --allow-ddl 启动或设置 MYSQL_READ_ONLY=false 且 MYSQL_ALLOW_DDL=true")
+		return "", "", &resp
+	}
+	return s.targetTable(ctx, id, args)
+}
+
+// 把值渲染为 SQL 字面量，DDL 语句不支持参数绑定
+func sqlLiteral(v interface{}) (string, bool) {
+	val, ok := bindValue(v)
+	if !ok {
+		return "", false
+	}
+	switch val := val.(type) {
+	case nil:
+		return "NULL", true
+	case bool:
+		if val {
+			return "TRUE", true
+		}
+		return "FALSE", true
+	case int64:
+		return strconv.FormatInt(val, 10), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	default:
+		return quoteString(val.(string)), true
+	}
+}
+
+// 用单引号包裹字符串，并转义其中的引号和反斜杠
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// 把列定义数组转为 DDL 片段，field 用于错误路径
+func columnDefinitions(field string, raw []interface{}) ([]string, error) {
+	defs := make([]string, 0, len(raw))
+	for i, item := range raw {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		col, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, &argumentError{Path: path, Reason: "必须是对象"}
+		}
+		name, _ := col["name"].(string)
+		if err := validateIdentifier(name); err != nil {
+			return nil, err
+		}
+		colType, _ := col["type"].(string)
+		colType = strings.TrimSpace(colType)
+		if !columnTypePattern.MatchString(colType) {
+			return nil, &argumentError{Path: path + ".type", Reason: "不支持的列类型"}
+		}
+
+		def := quoteIdentifier(name) + " " + strings.ToUpper(colType)
+		if nullable, ok := col["nullable"].(bool); ok && !nullable {
+			def += " NOT NULL"
+		}
+		if v, ok := col["default"]; ok {
+			literal, ok := sqlLiteral(v)
+			if !ok {
+				return nil, &argumentError{Path: path + ".default", Reason: "只能是字符串、数字、布尔值或 null"}
+			}
+			def += " DEFAULT " + literal
+		}
+		if autoIncrement, _ := col["auto_increment"].(bool); autoIncrement {
+			def += " AUTO_INCREMENT"
+		}
+		if comment, _ := col["comment"].(string); comment != "" {
+			def += " COMMENT " + quoteString(comment)
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// 校验并引用列名数组
+func quotedColumns(field string, raw []interface{}) ([]string, error) {
+	columns := make([]string, 0, len(raw))
+	for i, item := range raw {
+		name, ok := item.(string)
+		if !ok {
+			return nil, &argumentError{Path: fmt.Sprintf("%s[%d]", field, i), Reason: "必须是字符串"}
+		}
+		if err := validateIdentifier(name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, quoteIdentifier(name))
+	}
+	return columns, nil
+}
+
+func (s *MCPServer) createTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, table, errResp := s.ddlTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	rawColumns, _ := args["columns"].([]interface{})
+	if len(rawColumns) == 0 {
+		return s.queryErrorResponse(id, &argumentError{Path: "columns", Reason: "不能为空"})
+	}
+	defs, err := columnDefinitions("columns", rawColumns)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if rawKey, _ := args["primary_key"].([]interface{}); len(rawKey) > 0 {
+		key, err := quotedColumns("primary_key", rawKey)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(key, ", ")+")")
+	}
+
+	query := "CREATE TABLE "
+	if ifNotExists, _ := args["if_not_exists"].(bool); ifNotExists {
+		query += "IF NOT EXISTS "
+	}
+	query += table + " (\n  " + strings.Join(defs, ",\n  ") + "\n)"
+	if comment, _ := args["comment"].(string); comment != "" {
+		query += " COMMENT " + quoteString(comment)
+	}
+
+	if _, _, err := s.execWrite(ctx, query); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("表 '%s' 已创建\n\n%s\n", tableName, query))
+}
+
+func (s *MCPServer) alterTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, table, errResp := s.ddlTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+
+	var clauses []string
+	if raw, _ := args["add_columns"].([]interface{}); len(raw) > 0 {
+		defs, err := columnDefinitions("add_columns", raw)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		for _, def := range defs {
+			clauses = append(clauses, "ADD COLUMN "+def)
+		}
+	}
+	if raw, _ := args["modify_columns"].([]interface{}); len(raw) > 0 {
+		defs, err := columnDefinitions("modify_columns", raw)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		for _, def := range defs {
+			clauses = append(clauses, "MODIFY COLUMN "+def)
+		}
+	}
+	if raw, _ := args["drop_columns"].([]interface{}); len(raw) > 0 {
+		columns, err := quotedColumns("drop_columns", raw)
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		for _, col := range columns {
+			clauses = append(clauses, "DROP COLUMN "+col)
+		}
+	}
+	if len(clauses) == 0 {
+		return s.errorResponse(id, "至少需要 add_columns、modify_columns、drop_columns 之一")
+	}
+
+	query := "ALTER TABLE " + table + "\n  " + strings.Join(clauses, ",\n  ")
+	if _, _, err := s.execWrite(ctx, query); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("表 '%s' 已修改\n\n%s\n", tableName, query))
+}
+
+func (s *MCPServer) dropTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, table, errResp := s.ddlTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	if confirmName, _ := args["confirm_table_name"].(string); confirmName != tableName {
+		return s.errorResponse(id, "confirm_table_name 与 table_name 不一致，已取消操作")
+	}
+	confirmed, err := s.confirmWithUser(ctx, args, fmt.Sprintf("即将删除表 '%s' 及其全部数据，此操作不可撤销。确认执行？", tableName))
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	query := "DROP TABLE "
+	if ifExists, _ := args["if_exists"].(bool); ifExists {
+		query += "IF EXISTS "
+	}
+	query += table
+	if _, _, err := s.execWrite(ctx, query); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("表 '%s' 已删除\n", tableName))
+}
+
+func (s *MCPServer) createIndex(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	_, table, errResp := s.ddlTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	indexName, _ := args["index_name"].(string)
+	if err := validateIdentifier(indexName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	rawColumns, _ := args["columns"].([]interface{})
+	if len(rawColumns) == 0 {
+		return s.queryErrorResponse(id, &argumentError{Path: "columns", Reason: "不能为空"})
+	}
+	columns, err := quotedColumns("columns", rawColumns)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	query := "CREATE "
+	if unique, _ := args["unique"].(bool); unique {
+		query += "UNIQUE "
+	}
+	query += fmt.Sprintf("INDEX %s ON %s (%s)", quoteIdentifier(indexName), table, strings.Join(columns, ", "))
+	if _, _, err := s.execWrite(ctx, query); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("索引 '%s' 已创建\n\n%s\n", indexName, query))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 开启 DDL 工具
+func allowDDL(cfg *MySQLConfig) { cfg.AllowDDL = true }
+
+func TestCreateTable(t *testing.T) {
+	runToolCases(t, "create_table", []toolCase{
+		{
+			name:   "builds column definitions",
+			config: allowDDL,
+			args: map[string]interface{}{
+				"table_name": "tags",
+				"columns": []interface{}{
+					map[string]interface{}{"name": "id", "type": "int unsigned", "nullable": false, "auto_increment": true},
+					map[string]interface{}{"name": "label", "type": "varchar(64)", "default": "it's"},
+				},
+				"primary_key":   []interface{}{"id"},
+				"if_not_exists": true,
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `testdb`.`tags` (\n" +
+					"  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,\n" +
+					"  `label` VARCHAR(64) DEFAULT 'it''s',\n" +
+					"  PRIMARY KEY (`id`)\n)")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"表 'tags' 已创建"},
+		},
+		{
+			name:   "rejects unsupported column type",
+			config: allowDDL,
+			args: map[string]interface{}{"table_name": "tags",
+				"columns": []interface{}{map[string]interface{}{"name": "id", "type": "int; DROP TABLE x"}}},
+			wantErr: "columns[0].type: 不支持的列类型",
+		},
+		{
+			name:    "requires allow-ddl",
+			args:    map[string]interface{}{"table_name": "tags", "columns": []interface{}{map[string]interface{}{"name": "id", "type": "int"}}},
+			wantErr: "工具 create_table 已禁用",
+		},
+	})
+}
+
+func TestAlterTable(t *testing.T) {
+	runToolCases(t, "alter_table", []toolCase{
+		{
+			name:   "combines clauses",
+			config: allowDDL,
+			args: map[string]interface{}{"table_name": "tags",
+				"add_columns":  []interface{}{map[string]interface{}{"name": "color", "type": "char(7)"}},
+				"drop_columns": []interface{}{"legacy"}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `testdb`.`tags`\n  ADD COLUMN `color` CHAR(7),\n  DROP COLUMN `legacy`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"表 'tags' 已修改"},
+		},
+		{
+			name:    "requires a change",
+			config:  allowDDL,
+			args:    map[string]interface{}{"table_name": "tags"},
+			wantErr: "至少需要 add_columns、modify_columns、drop_columns 之一",
+		},
+	})
+}
+
+func TestDropTable(t *testing.T) {
+	runToolCases(t, "drop_table", []toolCase{
+		{
+			name:   "drops after confirmation",
+			config: allowDDL,
+			args:   map[string]interface{}{"table_name": "tags", "confirm_table_name": "tags", "confirm": true, "if_exists": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS `testdb`.`tags`")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"表 'tags' 已删除"},
+		},
+		{
+			name:    "confirmation name mismatch",
+			config:  allowDDL,
+			args:    map[string]interface{}{"table_name": "tags", "confirm_table_name": "tag", "confirm": true},
+			wantErr: "confirm_table_name 与 table_name 不一致",
+		},
+	})
+}
+
+func TestCreateIndex(t *testing.T) {
+	runToolCases(t, "create_index", []toolCase{
+		{
+			name:   "unique index",
+			config: allowDDL,
+			args: map[string]interface{}{"table_name": "users", "index_name": "uk_email",
+				"columns": []interface{}{"email"}, "unique": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("CREATE UNIQUE INDEX `uk_email` ON `testdb`.`users` (`email`)")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"索引 'uk_email' 已创建"},
+		},
+	})
+}
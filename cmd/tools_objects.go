@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// call_procedure 每个结果集最多保留的行数
+const maxProcedureResultRows = 1000
+
+func (s *MCPServer) listRoutines(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	query := `
+		SELECT r.ROUTINE_NAME, r.ROUTINE_TYPE, COALESCE(r.DTD_IDENTIFIER, ''),
+			COALESCE(GROUP_CONCAT(CONCAT_WS(' ', p.PARAMETER_MODE, p.PARAMETER_NAME, p.DTD_IDENTIFIER)
+				ORDER BY p.ORDINAL_POSITION SEPARATOR ', '), ''),
+			r.ROUTINE_COMMENT
+		FROM information_schema.ROUTINES r
+		LEFT JOIN information_schema.PARAMETERS p
+			ON p.SPECIFIC_SCHEMA = r.ROUTINE_SCHEMA AND p.SPECIFIC_NAME = r.SPECIFIC_NAME AND p.ORDINAL_POSITION > 0
+		WHERE r.ROUTINE_SCHEMA = ?`
+	queryArgs := []interface{}{database}
+	if routineType, _ := args["routine_type"].(string); routineType != "" {
+		query += " AND r.ROUTINE_TYPE = ?"
+		queryArgs = append(queryArgs, strings.ToUpper(routineType))
+	}
+	query += `
+		GROUP BY r.ROUTINE_NAME, r.ROUTINE_TYPE, r.DTD_IDENTIFIER, r.ROUTINE_COMMENT
+		ORDER BY r.ROUTINE_TYPE, r.ROUTINE_NAME`
+
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	count := 0
+	for rows.Next() {
+		var name, routineType, returns, params, comment string
+		if err := rows.Scan(&name, &routineType, &returns, &params, &comment); err != nil {
+			continue
+		}
+		line := fmt.Sprintf("%s %s(%s)", strings.ToLower(routineType), name, params)
+		if returns != "" {
+			line += " RETURNS " + returns
+		}
+		if comment != "" {
+			line += " -- " + comment
+		}
+		sb.WriteString(line + "\n")
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, fmt.Sprintf("数据库 '%s' 中没有存储过程或函数\n", database))
+	}
+	return s.textResponse(id, fmt.Sprintf("数据库 '%s' 的存储过程和函数 (%d):\n\n%s", database, count, sb.String()))
+}
+
+// 读取 routine_type 参数，返回 SHOW CREATE 使用的关键字
+func routineKeyword(args map[string]interface{}) string {
+	if routineType, _ := args["routine_type"].(string); strings.EqualFold(routineType, "function") {
+		return "FUNCTION"
+	}
+	return "PROCEDURE"
+}
+
+func (s *MCPServer) showRoutine(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	name, _ := args["routine_name"].(string)
+	if err := validateIdentifier(name); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	keyword := routineKeyword(args)
+
+	ddl, err := s.showCreate(ctx, fmt.Sprintf("SHOW CREATE %s %s", keyword, qualifiedTable(database, name)), 2)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if ddl == "" {
+		// 没有该例程的权限时定义列为 NULL
+		return s.errorResponse(id, fmt.Sprintf("无权查看 %s '%s' 的定义", strings.ToLower(keyword), name))
+	}
+	return s.textResponse(id, ddl+"\n")
+}
+
+// 读取当前结果集的全部行，超过 maxProcedureResultRows 的部分只计数
+func scanResultSet(rows *sql.Rows) (*QueryResult, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns}
+	for rows.Next() {
+		result.TotalRows++
+		if len(result.Rows) >= maxProcedureResultRows {
+			result.Truncated = true
+			continue
+		}
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.Count = len(result.Rows)
+	if !result.Truncated {
+		result.TotalRows = 0
+	}
+	return result, rows.Err()
+}
+
+func (s *MCPServer) callProcedure(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	name, _ := args["procedure_name"].(string)
+	if err := validateIdentifier(name); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	raw, _ := args["params"].([]interface{})
+	params, err := bindParams(raw)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(params)), ", ")
+	query := fmt.Sprintf("CALL %s(%s)", qualifiedTable(database, name), placeholders)
+	confirmed, err := s.confirmWithUser(ctx, args, fmt.Sprintf("即将调用存储过程 '%s'，它可能修改数据。确认执行？", name))
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+	if err := s.ensureConnection(ctx); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	// 用户确认之后才开始计时，与 runQuery 相同，超时或取消时 KILL QUERY 终止服务端的调用
+	timeout := s.queryTimeout(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 在切换到当前数据库的连接上调用，未限定的名称使用 use_database 选择的数据库
+	conn, release, err := s.queryConn(ctx)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("获取数据库连接错误: %w", err))
+	}
+	defer release()
+	var connID int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("获取连接 ID 错误: %v", err))
+	}
+	stopKill := context.AfterFunc(ctx, func() { s.killQuery(connID) })
+	defer stopKill()
+	restoreDatabase, err := s.useSessionDatabase(ctx, conn)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	defer restoreDatabase()
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query, params...)
+	if err != nil {
+		s.logQuery(ctx, query, time.Since(start), 0, err)
+		return s.queryErrorResponse(id, queryTimeoutError(ctx, fmt.Errorf("Database error: %w", &statementError{Statement: query, Err: err}), timeout))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	sets, total := 0, 0
+	for {
+		result, err := scanResultSet(rows)
+		if err != nil {
+			s.logQuery(ctx, query, time.Since(start), total, err)
+			return s.queryErrorResponse(id, queryTimeoutError(ctx, fmt.Errorf("Database error: %w", &statementError{Statement: query, Err: err}), timeout))
+		}
+		total += result.Count
+		if len(result.Columns) > 0 {
+			sets++
+			sb.WriteString(fmt.Sprintf("-- 结果集 %d\n", sets))
+			sb.WriteString(formatQueryResult(result) + truncationNote(result) + "\n")
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.logQuery(ctx, query, time.Since(start), total, err)
+		return s.queryErrorResponse(id, queryTimeoutError(ctx, fmt.Errorf("Database error: %w", &statementError{Statement: query, Err: err}), timeout))
+	}
+	s.logQuery(ctx, query, time.Since(start), total, nil)
+	if sets == 0 {
+		return s.textResponse(id, fmt.Sprintf("存储过程 '%s' 执行成功，没有返回结果集\n", name))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+func (s *MCPServer) listViews(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, IS_UPDATABLE, SECURITY_TYPE FROM information_schema.VIEWS
+		WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	count := 0
+	for rows.Next() {
+		var name, updatable, security string
+		if err := rows.Scan(&name, &updatable, &security); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(name) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%-30s 可更新: %-3s SQL SECURITY %s\n", name, updatable, security))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, fmt.Sprintf("数据库 '%s' 中没有视图\n", database))
+	}
+	return s.textResponse(id, fmt.Sprintf("数据库 '%s' 的视图 (%d):\n\n%s", database, count, sb.String()))
+}
+
+func (s *MCPServer) describeView(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	viewName, _ := args["view_name"].(string)
+	if err := validateIdentifier(viewName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(viewName) {
+		return s.tableNotAllowed(id, viewName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	var updatable, checkOption, security, definer string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT IS_UPDATABLE, CHECK_OPTION, SECURITY_TYPE, DEFINER FROM information_schema.VIEWS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, database, viewName).Scan(&updatable, &checkOption, &security, &definer)
+	if err == sql.ErrNoRows {
+		return s.errorResponse(id, fmt.Sprintf("视图 '%s' 不存在", viewName))
+	}
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	ddl, err := s.showCreate(ctx, "SHOW CREATE VIEW "+qualifiedTable(database, viewName), 1)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("视图 '%s':\n\n", viewName))
+	sb.WriteString(fmt.Sprintf("可更新:        %s\n", updatable))
+	sb.WriteString(fmt.Sprintf("CHECK OPTION:  %s\n", checkOption))
+	sb.WriteString(fmt.Sprintf("SQL SECURITY:  %s\n", security))
+	sb.WriteString(fmt.Sprintf("DEFINER:       %s\n", definer))
+
+	// VIEW_TABLE_USAGE 从 MySQL 8.0.13 开始提供，旧版本不显示依赖的表
+	tables, err := s.schemaObjects(ctx, `
+		SELECT TABLE_NAME, TABLE_SCHEMA FROM information_schema.VIEW_TABLE_USAGE
+		WHERE VIEW_SCHEMA = ? AND VIEW_NAME = ? ORDER BY TABLE_SCHEMA, TABLE_NAME`, database, viewName)
+	if err == nil && len(tables) > 0 {
+		names := make([]string, len(tables))
+		for i, t := range tables {
+			names[i] = t[0]
+			if t[1] != database {
+				names[i] = t[1] + "." + t[0]
+			}
+		}
+		sb.WriteString(fmt.Sprintf("依赖的表:      %s\n", strings.Join(names, ", ")))
+	}
+
+	sb.WriteString("\n" + ddl + "\n")
+	return s.textResponse(id, sb.String())
+}
+
+func (s *MCPServer) listTriggers(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, errResp := s.optionalTableArg(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	query := `
+		SELECT TRIGGER_NAME, EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_STATEMENT
+		FROM information_schema.TRIGGERS
+		WHERE TRIGGER_SCHEMA = ?`
+	queryArgs := []interface{}{database}
+	if tableName != "" {
+		query += " AND EVENT_OBJECT_TABLE = ?"
+		queryArgs = append(queryArgs, tableName)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_ORDER", queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	count := 0
+	for rows.Next() {
+		var name, table, timing, event, body string
+		if err := rows.Scan(&name, &table, &timing, &event, &body); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(table) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s %s ON %s FOR EACH ROW\n%s\n\n", name, timing, event, table, body))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, "没有找到触发器\n")
+	}
+	return s.textResponse(id, fmt.Sprintf("触发器 (%d):\n\n%s", count, sb.String()))
+}
+
+func (s *MCPServer) listEvents(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT EVENT_NAME, EVENT_TYPE, EXECUTE_AT, INTERVAL_VALUE, INTERVAL_FIELD, STARTS, ENDS,
+			STATUS, LAST_EXECUTED, EVENT_DEFINITION
+		FROM information_schema.EVENTS
+		WHERE EVENT_SCHEMA = ? ORDER BY EVENT_NAME`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	count := 0
+	for rows.Next() {
+		var name, eventType, status, body string
+		var executeAt, intervalValue, intervalField, starts, ends, lastExecuted sql.NullString
+		if err := rows.Scan(&name, &eventType, &executeAt, &intervalValue, &intervalField, &starts, &ends,
+			&status, &lastExecuted, &body); err != nil {
+			continue
+		}
+		schedule := "AT " + executeAt.String
+		if eventType == "RECURRING" {
+			schedule = fmt.Sprintf("EVERY %s %s", intervalValue.String, intervalField.String)
+			if starts.Valid {
+				schedule += " STARTS " + starts.String
+			}
+			if ends.Valid {
+				schedule += " ENDS " + ends.String
+			}
+		}
+		last := "从未执行"
+		if lastExecuted.Valid {
+			last = lastExecuted.String
+		}
+		sb.WriteString(fmt.Sprintf("%s [%s]\n  调度: %s\n  上次执行: %s\n  %s\n\n", name, status, schedule, last, body))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, fmt.Sprintf("数据库 '%s' 中没有定时事件\n", database))
+	}
+
+	// 事件调度器关闭时事件不会执行
+	var scheduler string
+	if err := s.db.QueryRowContext(ctx, "SELECT @@GLOBAL.event_scheduler").Scan(&scheduler); err == nil {
+		sb.WriteString(fmt.Sprintf("event_scheduler: %s\n", scheduler))
+	}
+	return s.textResponse(id, fmt.Sprintf("数据库 '%s' 的定时事件 (%d):\n\n%s", database, count, sb.String()))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListRoutines(t *testing.T) {
+	routineColumns := []string{"ROUTINE_NAME", "ROUTINE_TYPE", "DTD_IDENTIFIER", "PARAMS", "ROUTINE_COMMENT"}
+	runToolCases(t, "list_routines", []toolCase{
+		{
+			name: "procedures and functions",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.ROUTINES r").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(routineColumns).
+						AddRow("tax", "FUNCTION", "decimal(10,2)", "amount decimal(10,2)", "").
+						AddRow("archive", "PROCEDURE", "", "IN before date", "move old rows"))
+			},
+			want: []string{"数据库 'testdb' 的存储过程和函数 (2)",
+				"function tax(amount decimal(10,2)) RETURNS decimal(10,2)",
+				"procedure archive(IN before date) -- move old rows"},
+		},
+		{
+			name: "filter by type",
+			args: map[string]interface{}{"routine_type": "procedure"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AND r.ROUTINE_TYPE = ?")).WithArgs(testDatabase, "PROCEDURE").
+					WillReturnRows(sqlmock.NewRows(routineColumns))
+			},
+			want: []string{"数据库 'testdb' 中没有存储过程或函数"},
+		},
+	})
+}
+
+func TestShowRoutine(t *testing.T) {
+	routineColumns := []string{"Function", "sql_mode", "Create Function"}
+	runToolCases(t, "show_routine", []toolCase{
+		{
+			name: "function definition",
+			args: map[string]interface{}{"routine_name": "tax", "routine_type": "function"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE FUNCTION `testdb`.`tax`")).
+					WillReturnRows(sqlmock.NewRows(routineColumns).AddRow("tax", "", "CREATE FUNCTION `tax`() RETURNS int RETURN 1"))
+			},
+			want: []string{"CREATE FUNCTION `tax`()"},
+		},
+		{
+			name: "definition hidden without privilege",
+			args: map[string]interface{}{"routine_name": "archive"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE PROCEDURE `testdb`.`archive`")).
+					WillReturnRows(sqlmock.NewRows(routineColumns).AddRow("archive", "", nil))
+			},
+			wantErr: "无权查看 procedure 'archive' 的定义",
+		},
+	})
+}
+
+func TestCallProcedure(t *testing.T) {
+	runToolCases(t, "call_procedure", []toolCase{
+		{
+			name:   "returns every result set",
+			config: allowWrites,
+			args:   map[string]interface{}{"procedure_name": "report", "params": []interface{}{2024}, "confirm": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("CALL `testdb`.`report`(?)")).WithArgs(int64(2024)).
+					WillReturnRows(
+						sqlmock.NewRows([]string{"month"}).AddRow(1),
+						sqlmock.NewRows([]string{"total"}).AddRow(99),
+					)
+			},
+			want: []string{"-- 结果集 1", "-- 结果集 2", "99"},
+		},
+		{
+			name: "timeout kills the call",
+			config: func(cfg *MySQLConfig) {
+				allowWrites(cfg)
+				cfg.QueryTimeoutMs = 50
+			},
+			args: map[string]interface{}{"procedure_name": "rebuild_stats", "confirm": true},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("CALL `testdb`.`rebuild_stats`()")).
+					WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"n"}))
+				mock.ExpectExec(regexp.QuoteMeta("KILL QUERY 42")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: "查询超时",
+		},
+		{
+			name:    "requires allow-writes",
+			args:    map[string]interface{}{"procedure_name": "report", "confirm": true},
+			wantErr: "工具 call_procedure 已禁用",
+		},
+	})
+}
+
+func TestListViews(t *testing.T) {
+	runToolCases(t, "list_views", []toolCase{
+		{
+			name:   "skips views outside allowlist",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"active_users"} },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.VIEWS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "IS_UPDATABLE", "SECURITY_TYPE"}).
+						AddRow("active_users", "YES", "DEFINER").
+						AddRow("salaries", "NO", "INVOKER"))
+			},
+			want: []string{"数据库 'testdb' 的视图 (1)", "active_users"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if text := responseText(resp); strings.Contains(text, "salaries") {
+					t.Errorf("不应列出白名单外的视图:\n%s", text)
+				}
+			},
+		},
+	})
+}
+
+func TestDescribeView(t *testing.T) {
+	runToolCases(t, "describe_view", []toolCase{
+		{
+			name: "metadata, dependencies and definition",
+			args: map[string]interface{}{"view_name": "active_users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT IS_UPDATABLE, CHECK_OPTION, SECURITY_TYPE, DEFINER").WithArgs(testDatabase, "active_users").
+					WillReturnRows(sqlmock.NewRows([]string{"IS_UPDATABLE", "CHECK_OPTION", "SECURITY_TYPE", "DEFINER"}).
+						AddRow("YES", "NONE", "DEFINER", "root@localhost"))
+				mock.ExpectQuery(regexp.QuoteMeta("SHOW CREATE VIEW `testdb`.`active_users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"View", "Create View"}).
+						AddRow("active_users", "CREATE VIEW `active_users` AS select 1"))
+				mock.ExpectQuery("FROM information_schema.VIEW_TABLE_USAGE").WithArgs(testDatabase, "active_users").
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_SCHEMA"}).
+						AddRow("users", "testdb").
+						AddRow("logins", "audit"))
+			},
+			want: []string{"DEFINER:       root@localhost", "依赖的表:      users, audit.logins", "CREATE VIEW `active_users`"},
+		},
+		{
+			name: "missing view",
+			args: map[string]interface{}{"view_name": "nope"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.VIEWS").WithArgs(testDatabase, "nope").
+					WillReturnRows(sqlmock.NewRows([]string{"IS_UPDATABLE", "CHECK_OPTION", "SECURITY_TYPE", "DEFINER"}))
+			},
+			wantErr: "视图 'nope' 不存在",
+		},
+	})
+}
+
+func TestListTriggers(t *testing.T) {
+	runToolCases(t, "list_triggers", []toolCase{
+		{
+			name: "triggers on one table",
+			args: map[string]interface{}{"table_name": "orders"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AND EVENT_OBJECT_TABLE = ?")).WithArgs(testDatabase, "orders").
+					WillReturnRows(sqlmock.NewRows([]string{"TRIGGER_NAME", "EVENT_OBJECT_TABLE", "ACTION_TIMING", "EVENT_MANIPULATION", "ACTION_STATEMENT"}).
+						AddRow("orders_bi", "orders", "BEFORE", "INSERT", "SET NEW.created_at = NOW()"))
+			},
+			want: []string{"触发器 (1)", "orders_bi: BEFORE INSERT ON orders FOR EACH ROW\nSET NEW.created_at = NOW()"},
+		},
+	})
+}
+
+func TestListEvents(t *testing.T) {
+	runToolCases(t, "list_events", []toolCase{
+		{
+			name: "recurring event and scheduler state",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.EVENTS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"EVENT_NAME", "EVENT_TYPE", "EXECUTE_AT", "INTERVAL_VALUE",
+						"INTERVAL_FIELD", "STARTS", "ENDS", "STATUS", "LAST_EXECUTED", "EVENT_DEFINITION"}).
+						AddRow("purge", "RECURRING", nil, "1", "DAY", "2024-01-01 00:00:00", nil, "ENABLED", nil, "DELETE FROM logs"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.event_scheduler")).
+					WillReturnRows(sqlmock.NewRows([]string{"event_scheduler"}).AddRow("OFF"))
+			},
+			want: []string{"purge [ENABLED]", "调度: EVERY 1 DAY STARTS 2024-01-01 00:00:00", "上次执行: 从未执行", "event_scheduler: OFF"},
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// statement_digest 的排序方式对应的列
+var digestOrderColumns = map[string]string{
+	"total_latency":   "SUM_TIMER_WAIT",
+	"avg_latency":     "AVG_TIMER_WAIT",
+	"exec_count":      "COUNT_STAR",
+	"rows_examined":   "SUM_ROWS_EXAMINED",
+	"tmp_disk_tables": "SUM_CREATED_TMP_DISK_TABLES",
+}
+
+// statement_digest 默认和最多返回的语句数
+const (
+	defaultDigestLimit = 10
+	maxDigestLimit     = 100
+)
+
+func (s *MCPServer) statementDigest(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	orderBy, _ := args["order_by"].(string)
+	if orderBy == "" {
+		orderBy = "total_latency"
+	}
+	orderColumn, ok := digestOrderColumns[orderBy]
+	if !ok {
+		return s.errorResponse(id, fmt.Sprintf("不支持的 order_by: %s", orderBy))
+	}
+	limit := defaultDigestLimit
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+	if limit < 1 || limit > maxDigestLimit {
+		return s.errorResponse(id, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxDigestLimit))
+	}
+	format, _ := args["format"].(string)
+
+	// 计时器单位为皮秒
+	query := fmt.Sprintf(`
+		SELECT LEFT(DIGEST_TEXT, 300) AS digest_text,
+			COUNT_STAR AS exec_count,
+			ROUND(SUM_TIMER_WAIT / 1e12, 3) AS total_latency_s,
+			ROUND(AVG_TIMER_WAIT / 1e9, 3) AS avg_latency_ms,
+			ROUND(MAX_TIMER_WAIT / 1e9, 3) AS max_latency_ms,
+			SUM_ROWS_EXAMINED AS rows_examined,
+			SUM_ROWS_SENT AS rows_sent,
+			ROUND(SUM_ROWS_EXAMINED / NULLIF(SUM_ROWS_SENT, 0), 1) AS examined_per_sent,
+			SUM_CREATED_TMP_TABLES AS tmp_tables,
+			SUM_CREATED_TMP_DISK_TABLES AS tmp_disk_tables,
+			SUM_NO_INDEX_USED AS no_index_used,
+			LAST_SEEN AS last_seen
+		FROM performance_schema.events_statements_summary_by_digest
+		WHERE SCHEMA_NAME = ? AND DIGEST_TEXT IS NOT NULL
+		ORDER BY %s DESC
+		LIMIT %d`, orderColumn, limit)
+
+	result, err := s.runQuery(ctx, query, database)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.queryResultResponse(id, result, format)
+}
+
+// SHOW ENGINE INNODB STATUS 中的一节
+type innodbSection struct {
+	Name string
+	Body string
+}
+
+// 判断是否为分隔行（全部由 - 或 = 组成）
+func isSeparatorLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 3 && strings.Trim(line, "-=") == ""
+}
+
+// 按 "-----\n标题\n-----" 的格式把 InnoDB 状态拆成若干节
+func parseInnodbStatus(status string) []innodbSection {
+	lines := strings.Split(status, "\n")
+	var sections []innodbSection
+	var current *innodbSection
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			sections = append(sections, *current)
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		if i+2 < len(lines) && isSeparatorLine(lines[i]) && !isSeparatorLine(lines[i+1]) && isSeparatorLine(lines[i+2]) {
+			flush()
+			current = &innodbSection{Name: strings.TrimSpace(lines[i+1])}
+			body = nil
+			i += 2
+			continue
+		}
+		if current != nil && !isSeparatorLine(lines[i]) {
+			body = append(body, lines[i])
+		}
+	}
+	flush()
+	return sections
+}
+
+func (s *MCPServer) innodbStatus(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	var engine, name, status string
+	if err := s.db.QueryRowContext(ctx, "SHOW ENGINE INNODB STATUS").Scan(&engine, &name, &status); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	// 死锁和事务信息中的语句可能包含敏感数据，未开启 MYSQL_ALLOW_ADMIN 时脱敏显示
+	if !s.config.AllowAdmin {
+		status = redactSQL(status)
+	}
+	sections := parseInnodbStatus(status)
+
+	wanted := make(map[string]bool)
+	if raw, ok := args["sections"].([]interface{}); ok {
+		for _, v := range raw {
+			if name, ok := v.(string); ok {
+				wanted[strings.ToUpper(strings.TrimSpace(name))] = true
+			}
+		}
+	}
+
+	var sb strings.Builder
+	var names []string
+	for _, section := range sections {
+		names = append(names, section.Name)
+		if len(wanted) > 0 && !wanted[section.Name] {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("== %s ==\n%s\n\n", section.Name, section.Body))
+	}
+	if len(wanted) > 0 && !wanted["LATEST DETECTED DEADLOCK"] {
+		// 没有请求死锁信息时仍提示最近是否发生过死锁
+		for _, section := range sections {
+			if section.Name == "LATEST DETECTED DEADLOCK" {
+				sb.WriteString("提示: 存在最近一次死锁记录，可请求 LATEST DETECTED DEADLOCK 一节查看\n\n")
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return s.textResponse(id, fmt.Sprintf("没有匹配的节，可用的节: %s\n", strings.Join(names, ", ")))
+	}
+	return s.textResponse(id, sb.String())
+}
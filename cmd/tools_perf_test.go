@@ -0,0 +1,52 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInnodbStatus(t *testing.T) {
+	status := "\n=====================================\nINNODB MONITOR OUTPUT\n=====================================\n" +
+		"------------\nTRANSACTIONS\n------------\nTrx id counter 100\n" +
+		"------------------------\nLATEST DETECTED DEADLOCK\n------------------------\nUPDATE t SET a = 'secret'\n"
+	expectStatus := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SHOW ENGINE INNODB STATUS")).
+			WillReturnRows(sqlmock.NewRows([]string{"Type", "Name", "Status"}).AddRow("InnoDB", "", status))
+	}
+	runToolCases(t, "innodb_status", []toolCase{
+		{
+			name:   "selected section with deadlock hint",
+			args:   map[string]interface{}{"sections": []interface{}{"transactions"}},
+			expect: expectStatus,
+			want:   []string{"== TRANSACTIONS ==\nTrx id counter 100", "存在最近一次死锁记录"},
+		},
+		{
+			name:   "redacts statements without admin",
+			expect: expectStatus,
+			want:   []string{"== LATEST DETECTED DEADLOCK ==\nUPDATE t SET a = '?'"},
+		},
+	})
+}
+
+func TestStatementDigest(t *testing.T) {
+	runToolCases(t, "statement_digest", []toolCase{
+		{
+			name: "orders by the requested column",
+			args: map[string]interface{}{"order_by": "exec_count", "limit": 5},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(`(?s)FROM performance_schema.events_statements_summary_by_digest.*ORDER BY COUNT_STAR DESC\s+LIMIT 5`).
+					WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"digest_text", "exec_count"}).AddRow("SELECT * FROM `users`", 42))
+			},
+			want: []string{"SELECT * FROM `users`"},
+		},
+		{
+			name:    "limit out of range",
+			args:    map[string]interface{}{"limit": 500},
+			wantErr: "limit 必须在 1 到 100 之间",
+		},
+	})
+}
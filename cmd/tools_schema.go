@@ -0,0 +1,708 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dump_schema 最多导出的表数量
+const maxDumpSchemaTables = 200
+
+// 单个索引及其按顺序排列的列
+type indexInfo struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+func (s *MCPServer) listAllIndexes(ctx context.Context, id interface{}, database string) MCPResponse {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	// 按 表 -> 索引 -> 列 分组，保持查询返回的顺序
+	var tables []string
+	indexes := make(map[string][]*indexInfo)
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var nonUnique int
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
+			continue
+		}
+
+		tableIndexes, ok := indexes[tableName]
+		if !ok {
+			tables = append(tables, tableName)
+		}
+		if n := len(tableIndexes); n == 0 || tableIndexes[n-1].Name != indexName {
+			tableIndexes = append(tableIndexes, &indexInfo{Name: indexName, Unique: nonUnique == 0})
+		}
+		last := tableIndexes[len(tableIndexes)-1]
+		last.Columns = append(last.Columns, columnName)
+		indexes[tableName] = tableIndexes
+	}
+
+	result := fmt.Sprintf("数据库 '%s' 的索引 (%d 张表):\n\n", database, len(tables))
+	for _, tableName := range tables {
+		result += tableName + ":\n"
+		for _, idx := range indexes[tableName] {
+			unique := ""
+			if idx.Unique {
+				unique = " [UNIQUE]"
+			}
+			result += fmt.Sprintf("  %s%s: %s\n", idx.Name, unique, strings.Join(idx.Columns, ", "))
+		}
+	}
+	if len(tables) == 0 {
+		result += "没有找到索引\n"
+	}
+
+	return s.textResponse(id, result)
+}
+
+func (s *MCPServer) collationAudit(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var schemaCharset, schemaCollation string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA
+		WHERE SCHEMA_NAME = ?
+	`, database).Scan(&schemaCharset, &schemaCollation)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.TABLE_NAME, c.COLUMN_NAME, c.CHARACTER_SET_NAME, c.COLLATION_NAME, t.TABLE_COLLATION
+		FROM information_schema.COLUMNS c
+		JOIN information_schema.TABLES t
+			ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+		WHERE c.TABLE_SCHEMA = ? AND c.COLLATION_NAME IS NOT NULL AND t.TABLE_TYPE = 'BASE TABLE'
+		ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION
+	`, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var tables []string
+	tableCollations := make(map[string]string)
+	issues := make(map[string][]string)
+	charsetMismatch := false
+	for rows.Next() {
+		var tableName, columnName, columnCharset, columnCollation, tableCollation string
+		if err := rows.Scan(&tableName, &columnName, &columnCharset, &columnCollation, &tableCollation); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
+			continue
+		}
+		if _, ok := tableCollations[tableName]; !ok {
+			tableCollations[tableName] = tableCollation
+			if tableCollation != schemaCollation {
+				tables = append(tables, tableName)
+				issues[tableName] = append(issues[tableName],
+					fmt.Sprintf("  (表默认值 %s 与库默认值不一致)", tableCollation))
+			}
+		}
+		// 与表默认值或库默认值不一致的列都列出，字符集不同的单独标出
+		if columnCollation != tableCollation || columnCollation != schemaCollation {
+			if _, ok := issues[tableName]; !ok {
+				tables = append(tables, tableName)
+			}
+			note := ""
+			if columnCharset != schemaCharset {
+				note = " [字符集不同]"
+				charsetMismatch = true
+			}
+			issues[tableName] = append(issues[tableName],
+				fmt.Sprintf("  %s: %s / %s (表默认 %s)%s", columnName, columnCharset, columnCollation, tableCollation, note))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	result := fmt.Sprintf("数据库 '%s' 默认字符集: %s，排序规则: %s\n\n", database, schemaCharset, schemaCollation)
+	if len(tables) == 0 {
+		result += "所有表和列的字符集和排序规则一致\n"
+	}
+	for _, tableName := range tables {
+		result += tableName + ":\n" + strings.Join(issues[tableName], "\n") + "\n"
+	}
+	if charsetMismatch {
+		result += "\n字符集不同的列与其他列 JOIN 或比较时会发生隐式转换，可能无法使用索引，或报 Illegal mix of collations 错误\n"
+	}
+
+	return s.textResponse(id, result)
+}
+
+type schemaColumn struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default"`
+	Key      string  `json:"key,omitempty"`
+}
+
+type schemaIndex struct {
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique"`
+	Columns []string `json:"columns"`
+}
+
+type schemaForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+type schemaTable struct {
+	Name        string              `json:"name"`
+	Columns     []*schemaColumn     `json:"columns"`
+	Indexes     []*schemaIndex      `json:"indexes"`
+	ForeignKeys []*schemaForeignKey `json:"foreign_keys"`
+}
+
+type schemaDump struct {
+	Database  string         `json:"database"`
+	Tables    []*schemaTable `json:"tables"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+// 从 information_schema 读取当前连接的默认数据库（use_database 选择的数据库）的完整结构，遵循表白名单
+func (s *MCPServer) loadSchema(ctx context.Context) (*schemaDump, error) {
+	return s.loadSchemaFrom(ctx, s.db, s.currentDatabase(ctx))
+}
+
+// 从指定连接读取数据库 database 的完整结构，遵循表白名单
+func (s *MCPServer) loadSchemaFrom(ctx context.Context, db *sql.DB, database string) (*schemaDump, error) {
+	dump := &schemaDump{Database: database}
+	tables := make(map[string]*schemaTable)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, database)
+	if err != nil {
+		return nil, err
+	}
+	progress := newProgressReporter(ctx)
+	columnCount := 0
+	for rows.Next() {
+		columnCount++
+		progress.update(columnCount, fmt.Sprintf("已读取 %d 列定义", columnCount))
+
+		var tableName, nullable string
+		var defaultValue sql.NullString
+		col := &schemaColumn{}
+		if err := rows.Scan(&tableName, &col.Name, &col.Type, &nullable, &defaultValue, &col.Key); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
+			continue
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			if len(dump.Tables) >= maxDumpSchemaTables {
+				dump.Truncated = true
+				continue
+			}
+			table = &schemaTable{Name: tableName}
+			tables[tableName] = table
+			dump.Tables = append(dump.Tables, table)
+		}
+		col.Nullable = nullable == "YES"
+		if defaultValue.Valid {
+			col.Default = &defaultValue.String
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`, database)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var nonUnique int
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			continue
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+		if n := len(table.Indexes); n == 0 || table.Indexes[n-1].Name != indexName {
+			table.Indexes = append(table.Indexes, &schemaIndex{Name: indexName, Unique: nonUnique == 0})
+		}
+		idx := table.Indexes[len(table.Indexes)-1]
+		idx.Columns = append(idx.Columns, columnName)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+	`, database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, constraintName, columnName, refTable, refColumn string
+		if err := rows.Scan(&tableName, &constraintName, &columnName, &refTable, &refColumn); err != nil {
+			continue
+		}
+		table, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+		if n := len(table.ForeignKeys); n == 0 || table.ForeignKeys[n-1].Name != constraintName {
+			table.ForeignKeys = append(table.ForeignKeys, &schemaForeignKey{Name: constraintName, ReferencedTable: refTable})
+		}
+		fk := table.ForeignKeys[len(table.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, columnName)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+	}
+
+	return dump, rows.Err()
+}
+
+func (s *MCPServer) dumpSchema(ctx context.Context, id interface{}, database string) MCPResponse {
+	dump, err := s.loadSchemaFrom(ctx, s.db, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+
+	resultText := string(data) + "\n"
+	if dump.Truncated {
+		resultText += fmt.Sprintf("\n（表数量超过上限 %d，其余表未导出）\n", maxDumpSchemaTables)
+	}
+
+	return s.textResponse(id, resultText)
+}
+
+// 返回表中空间类型（GEOMETRY、POINT 等）的列
+func (s *MCPServer) geometryColumns(ctx context.Context, database, tableName string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+			AND DATA_TYPE IN ('geometry', 'point', 'linestring', 'polygon', 'multipoint',
+				'multilinestring', 'multipolygon', 'geometrycollection', 'geomcollection')
+	`, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			continue
+		}
+		columns[columnName] = true
+	}
+	return columns, rows.Err()
+}
+
+// 按列顺序生成 SELECT 列表，空间列替换为 ST_AsText(col) AS col
+func (s *MCPServer) selectListWithWKT(ctx context.Context, database, tableName string, geometryColumns map[string]bool) (string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION
+	`, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			continue
+		}
+		if geometryColumns[columnName] {
+			items = append(items, fmt.Sprintf("ST_AsText(%s) AS %s", quoteIdentifier(columnName), quoteIdentifier(columnName)))
+		} else {
+			items = append(items, quoteIdentifier(columnName))
+		}
+	}
+	return strings.Join(items, ", "), rows.Err()
+}
+
+func (s *MCPServer) indexCoverage(ctx context.Context, id interface{}, database, tableName string) MCPResponse {
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION
+	`, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	var columns []string
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			continue
+		}
+		columns = append(columns, columnName)
+	}
+	rows.Close()
+	if len(columns) == 0 {
+		return s.errorResponse(id, fmt.Sprintf("表 '%s' 不存在", tableName))
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT INDEX_NAME, COLUMN_NAME, SEQ_IN_INDEX
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX
+	`, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	indexed := make(map[string]bool)
+	indexColumns := make(map[string][]string)
+	for rows.Next() {
+		var indexName, columnName string
+		var seq int
+		if err := rows.Scan(&indexName, &columnName, &seq); err != nil {
+			continue
+		}
+		indexed[columnName] = true
+		indexColumns[indexName] = append(indexColumns[indexName], columnName)
+	}
+	rows.Close()
+
+	// 组合索引（多于一列）的第一列
+	leading := make(map[string][]string)
+	for indexName, cols := range indexColumns {
+		if len(cols) > 1 {
+			leading[cols[0]] = append(leading[cols[0]], indexName)
+		}
+	}
+
+	var indexedList, unindexedList, leadingList []string
+	for _, col := range columns {
+		if indexed[col] {
+			indexedList = append(indexedList, col)
+		} else {
+			unindexedList = append(unindexedList, col)
+		}
+		if names, ok := leading[col]; ok {
+			sort.Strings(names)
+			leadingList = append(leadingList, fmt.Sprintf("%s (%s)", col, strings.Join(names, ", ")))
+		}
+	}
+
+	result := fmt.Sprintf("表 '%s' 的索引覆盖情况:\n\n", tableName)
+	result += fmt.Sprintf("已索引的列 (%d): %s\n", len(indexedList), strings.Join(indexedList, ", "))
+	result += fmt.Sprintf("未索引的列 (%d): %s\n", len(unindexedList), strings.Join(unindexedList, ", "))
+	result += fmt.Sprintf("组合索引前导列 (%d): %s\n", len(leadingList), strings.Join(leadingList, ", "))
+
+	return s.textResponse(id, result)
+}
+
+func (s *MCPServer) columnsDetailed(ctx context.Context, id interface{}, database, tableName string) MCPResponse {
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ORDINAL_POSITION, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY, EXTRA, COLUMN_DEFAULT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION
+	`, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	result := fmt.Sprintf("表 '%s' 的列详情:\n\n", tableName)
+	result += fmt.Sprintf("%-5s %-20s %-25s %-10s %-6s %-20s %-15s\n",
+		"序号", "字段名", "完整类型", "是否为空", "键", "额外信息", "默认值")
+	result += strings.Repeat("-", 110) + "\n"
+
+	count := 0
+	for rows.Next() {
+		var position int
+		var columnName, columnType, nullable, key, extra string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&position, &columnName, &columnType, &nullable, &key, &extra, &defaultValue); err != nil {
+			continue
+		}
+		defaultStr := "NULL"
+		if defaultValue.Valid {
+			defaultStr = defaultValue.String
+		}
+		result += fmt.Sprintf("%-5d %-20s %-25s %-10s %-6s %-20s %-15s\n",
+			position, columnName, columnType, nullable, key, extra, defaultStr)
+		count++
+	}
+	if count == 0 {
+		return s.errorResponse(id, fmt.Sprintf("表 '%s' 不存在", tableName))
+	}
+
+	return s.textResponse(id, result)
+}
+
+// COLUMN_KEY 的缩写
+var columnKeyAbbreviations = map[string]string{
+	"PRI": "PK",
+	"UNI": "UQ",
+	"MUL": "IDX",
+}
+
+func (s *MCPServer) compactSchema(ctx context.Context, id interface{}, database string) MCPResponse {
+	text, err := s.compactSchemaText(ctx, database)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if text == "" {
+		return s.textResponse(id, "没有找到表\n")
+	}
+
+	return s.textResponse(id, text)
+}
+
+// 每张表一行的精简结构：table(col PK, col2, ...)，没有表时返回空串
+func (s *MCPServer) compactSchemaText(ctx context.Context, database string) (string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_KEY
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, database)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var tables []string
+	columns := make(map[string][]string)
+	for rows.Next() {
+		var tableName, columnName, key string
+		if err := rows.Scan(&tableName, &columnName, &key); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
+			continue
+		}
+		if _, ok := columns[tableName]; !ok {
+			tables = append(tables, tableName)
+		}
+		if abbr, ok := columnKeyAbbreviations[key]; ok {
+			columnName += " " + abbr
+		}
+		columns[tableName] = append(columns[tableName], columnName)
+	}
+
+	var lines []string
+	for _, tableName := range tables {
+		lines = append(lines, fmt.Sprintf("%s(%s)", tableName, strings.Join(columns[tableName], ", ")))
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// 外键关系，列按约束中的顺序排列
+type foreignKey struct {
+	Table             string   `json:"table"`
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update"`
+	OnDelete          string   `json:"on_delete"`
+}
+
+// 读取数据库中的外键；tableName 非空时只返回该表引用其他表和被其他表引用的外键
+func (s *MCPServer) loadForeignKeys(ctx context.Context, database, tableName string) ([]*foreignKey, error) {
+	query := `
+		SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+			r.UPDATE_RULE, r.DELETE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+			AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL`
+	args := []interface{}{database}
+	if tableName != "" {
+		query += " AND (k.TABLE_NAME = ? OR k.REFERENCED_TABLE_NAME = ?)"
+		args = append(args, tableName, tableName)
+	}
+	query += " ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []*foreignKey
+	for rows.Next() {
+		var table, name, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&table, &name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(table) || !s.isTableAllowed(refTable) {
+			continue
+		}
+		if n := len(fks); n == 0 || fks[n-1].Table != table || fks[n-1].Name != name {
+			fks = append(fks, &foreignKey{Table: table, Name: name, ReferencedTable: refTable, OnUpdate: onUpdate, OnDelete: onDelete})
+		}
+		fk := fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+	}
+	return fks, rows.Err()
+}
+
+func (s *MCPServer) listForeignKeys(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, _ := args["table_name"].(string)
+	if tableName != "" {
+		if err := validateIdentifier(tableName); err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		if !s.isTableAllowed(tableName) {
+			return s.tableNotAllowed(id, tableName)
+		}
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	fks, err := s.loadForeignKeys(ctx, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if len(fks) == 0 {
+		return s.textResponse(id, "没有找到外键\n")
+	}
+
+	var sb strings.Builder
+	for _, fk := range fks {
+		sb.WriteString(fmt.Sprintf("%s.%s (%s) -> %s (%s) ON DELETE %s ON UPDATE %s\n",
+			fk.Table, fk.Name, strings.Join(fk.Columns, ", "),
+			fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "),
+			fk.OnDelete, fk.OnUpdate))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+// search_columns 最多返回的列数
+const maxSearchColumnsResults = 200
+
+// 按名称或注释查找列，pattern 为不区分大小写的子串
+func (s *MCPServer) searchColumns(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return s.errorResponse(id, "pattern is required")
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	like := "%" + escapeLike(pattern) + "%"
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+			AND (COLUMN_NAME LIKE ? OR COLUMN_COMMENT LIKE ? OR TABLE_NAME LIKE ?)
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, database, like, like, like)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var lines []string
+	truncated := false
+	for rows.Next() {
+		var tableName, columnName, columnType, comment string
+		if err := rows.Scan(&tableName, &columnName, &columnType, &comment); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(tableName) {
+			continue
+		}
+		if len(lines) >= maxSearchColumnsResults {
+			truncated = true
+			break
+		}
+		line := fmt.Sprintf("%s.%s %s", tableName, columnName, columnType)
+		if comment != "" {
+			line += "  -- " + comment
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if len(lines) == 0 {
+		return s.textResponse(id, fmt.Sprintf("没有找到名称或注释包含 '%s' 的列\n", pattern))
+	}
+
+	result := fmt.Sprintf("名称或注释包含 '%s' 的列 (%d):\n\n%s\n", pattern, len(lines), strings.Join(lines, "\n"))
+	if truncated {
+		result += fmt.Sprintf("\n（结果超过 %d 列，请使用更精确的 pattern）\n", maxSearchColumnsResults)
+	}
+	return s.textResponse(id, result)
+}
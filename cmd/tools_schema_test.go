@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListForeignKeys(t *testing.T) {
+	runToolCases(t, "list_foreign_keys", []toolCase{
+		{
+			name: "formats constraints",
+			args: map[string]interface{}{"table_name": "orders"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase, "orders", "orders").
+					WillReturnRows(sqlmock.NewRows(foreignKeyColumns).
+						AddRow("orders", "fk_user", "user_id", "users", "id", "RESTRICT", "CASCADE"))
+			},
+			want: []string{"orders.fk_user (user_id) -> users (id) ON DELETE CASCADE ON UPDATE RESTRICT"},
+		},
+		{
+			name: "no foreign keys",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(foreignKeyColumns))
+			},
+			want: []string{"没有找到外键"},
+		},
+	})
+}
+
+func TestSearchColumns(t *testing.T) {
+	runToolCases(t, "search_columns", []toolCase{
+		{
+			name: "matches names and comments",
+			args: map[string]interface{}{"pattern": "mail"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, "%mail%", "%mail%", "%mail%").
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_COMMENT"}).
+						AddRow("users", "email", "varchar(255)", "登录邮箱"))
+			},
+			want: []string{"名称或注释包含 'mail' 的列 (1)", "users.email varchar(255)  -- 登录邮箱"},
+		},
+		{
+			name: "escapes LIKE wildcards",
+			args: map[string]interface{}{"pattern": "a_b"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, `%a\_b%`, `%a\_b%`, `%a\_b%`).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "COLUMN_COMMENT"}))
+			},
+			want: []string{"没有找到名称或注释包含 'a_b' 的列"},
+		},
+	})
+}
+
+// collation_audit 的两次查询：库默认值和各列的字符集
+func expectCollationAudit(mock sqlmock.Sqlmock, columns *sqlmock.Rows) {
+	mock.ExpectQuery("FROM information_schema.SCHEMATA").WithArgs(testDatabase).
+		WillReturnRows(sqlmock.NewRows([]string{"DEFAULT_CHARACTER_SET_NAME", "DEFAULT_COLLATION_NAME"}).
+			AddRow("utf8mb4", "utf8mb4_0900_ai_ci"))
+	mock.ExpectQuery("FROM information_schema.COLUMNS c").WithArgs(testDatabase).WillReturnRows(columns)
+}
+
+// collation_audit 查询各列返回的列
+var collationColumns = []string{"TABLE_NAME", "COLUMN_NAME", "CHARACTER_SET_NAME", "COLLATION_NAME", "TABLE_COLLATION"}
+
+func TestCollationAudit(t *testing.T) {
+	runToolCases(t, "collation_audit", []toolCase{
+		{
+			name: "consistent schema",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectCollationAudit(mock, sqlmock.NewRows(collationColumns).
+					AddRow("users", "name", "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci"))
+			},
+			want: []string{"默认字符集: utf8mb4，排序规则: utf8mb4_0900_ai_ci", "所有表和列的字符集和排序规则一致"},
+		},
+		{
+			name: "divergent collations are flagged",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectCollationAudit(mock, sqlmock.NewRows(collationColumns).
+					AddRow("users", "name", "utf8mb4", "utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci").
+					AddRow("users", "code", "utf8mb4", "utf8mb4_bin", "utf8mb4_0900_ai_ci").
+					AddRow("legacy", "title", "latin1", "latin1_swedish_ci", "latin1_swedish_ci"))
+			},
+			want: []string{
+				"users:\n  code: utf8mb4 / utf8mb4_bin (表默认 utf8mb4_0900_ai_ci)\n",
+				"legacy:\n  (表默认值 latin1_swedish_ci 与库默认值不一致)\n  title: latin1 / latin1_swedish_ci (表默认 latin1_swedish_ci) [字符集不同]\n",
+				"Illegal mix of collations",
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				if text := responseText(resp); strings.Contains(text, "name:") {
+					t.Errorf("与默认值一致的列不应列出:\n%s", text)
+				}
+			},
+		},
+	})
+}
+
+func TestCompactSchema(t *testing.T) {
+	runToolCases(t, "compact_schema", []toolCase{
+		{
+			name: "empty database",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_KEY"}))
+			},
+			want: []string{"没有找到表"},
+		},
+		{
+			name: "one line per table with key abbreviations",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_KEY"}).
+						AddRow("orders", "id", "PRI").
+						AddRow("orders", "user_id", "MUL").
+						AddRow("orders", "total", "").
+						AddRow("users", "id", "PRI").
+						AddRow("users", "email", "UNI"))
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				want := "orders(id PK, user_id IDX, total)\nusers(id PK, email UQ)\n"
+				if text := responseText(resp); text != want {
+					t.Errorf("精简结构 = %q，期望 %q", text, want)
+				}
+			},
+		},
+		{
+			name:   "tables outside the allowlist are skipped",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"users"} },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_KEY"}).
+						AddRow("secrets", "id", "PRI").
+						AddRow("users", "id", "PRI"))
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				if text := responseText(resp); text != "users(id PK)\n" {
+					t.Errorf("精简结构 = %q，期望只包含 users", text)
+				}
+			},
+		},
+		{
+			name:    "rejects database outside allowlist",
+			args:    map[string]interface{}{"database": "other"},
+			wantErr: "不允许访问数据库 'other'",
+		},
+	})
+}
+
+// loadSchemaFrom 依次查询列、索引和外键
+func expectLoadSchema(mock sqlmock.Sqlmock, database string, columns, indexes, fks *sqlmock.Rows) {
+	mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(database).WillReturnRows(columns)
+	mock.ExpectQuery("FROM information_schema.STATISTICS").WithArgs(database).WillReturnRows(indexes)
+	mock.ExpectQuery("FROM information_schema.KEY_COLUMN_USAGE").WithArgs(database).WillReturnRows(fks)
+}
+
+var (
+	schemaColumnColumns = []string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT", "COLUMN_KEY"}
+	schemaIndexColumns  = []string{"TABLE_NAME", "INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}
+	schemaFKColumns     = []string{"TABLE_NAME", "CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}
+)
+
+func TestDumpSchema(t *testing.T) {
+	runToolCases(t, "dump_schema", []toolCase{
+		{
+			name: "single table",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectLoadSchema(mock, testDatabase,
+					sqlmock.NewRows(schemaColumnColumns).AddRow("users", "id", "int", "NO", nil, "PRI"),
+					sqlmock.NewRows(schemaIndexColumns).AddRow("users", "PRIMARY", 0, "id"),
+					sqlmock.NewRows(schemaFKColumns))
+			},
+			want: []string{`"database": "testdb"`, `"name": "users"`, `"name": "PRIMARY"`},
+		},
+		{
+			name: "two tables with indexes and foreign keys",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectLoadSchema(mock, testDatabase,
+					sqlmock.NewRows(schemaColumnColumns).
+						AddRow("orders", "id", "int", "NO", nil, "PRI").
+						AddRow("orders", "user_id", "int", "YES", nil, "MUL").
+						AddRow("users", "id", "int", "NO", nil, "PRI").
+						AddRow("users", "status", "varchar(16)", "NO", "active", ""),
+					sqlmock.NewRows(schemaIndexColumns).
+						AddRow("orders", "PRIMARY", 0, "id").
+						AddRow("orders", "idx_user", 1, "user_id").
+						AddRow("users", "PRIMARY", 0, "id"),
+					sqlmock.NewRows(schemaFKColumns).
+						AddRow("orders", "fk_user", "user_id", "users", "id"))
+			},
+			check: func(t *testing.T, resp MCPResponse) {
+				var dump schemaDump
+				if err := json.Unmarshal([]byte(responseText(resp)), &dump); err != nil {
+					t.Fatalf("解析结果失败: %v\n%s", err, responseText(resp))
+				}
+				if len(dump.Tables) != 2 || dump.Tables[0].Name != "orders" || dump.Tables[1].Name != "users" {
+					t.Fatalf("表 = %+v，期望 orders、users", dump.Tables)
+				}
+				orders, users := dump.Tables[0], dump.Tables[1]
+				if len(orders.Columns) != 2 || !orders.Columns[1].Nullable || orders.Columns[1].Key != "MUL" {
+					t.Errorf("orders 的列不正确: %+v", orders.Columns)
+				}
+				if len(orders.Indexes) != 2 || orders.Indexes[1].Name != "idx_user" || orders.Indexes[1].Unique {
+					t.Errorf("orders 的索引不正确: %+v", orders.Indexes)
+				}
+				if len(orders.ForeignKeys) != 1 || orders.ForeignKeys[0].ReferencedTable != "users" {
+					t.Errorf("orders 的外键不正确: %+v", orders.ForeignKeys)
+				}
+				if def := users.Columns[1].Default; def == nil || *def != "active" {
+					t.Errorf("users.status 的默认值 = %v，期望 active", def)
+				}
+				if len(users.ForeignKeys) != 0 {
+					t.Errorf("users 不应有外键: %+v", users.ForeignKeys)
+				}
+			},
+		},
+	})
+}
+
+func TestIndexCoverage(t *testing.T) {
+	runToolCases(t, "index_coverage", []toolCase{
+		{
+			name: "missing table",
+			args: map[string]interface{}{"table_name": "ghost"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, "ghost").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+			},
+			wantErr: "表 'ghost' 不存在",
+		},
+		{
+			name: "classifies indexed, unindexed and leading columns",
+			args: map[string]interface{}{"table_name": "orders"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, "orders").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).
+						AddRow("id").AddRow("user_id").AddRow("status").AddRow("created_at").AddRow("note"))
+				mock.ExpectQuery("FROM information_schema.STATISTICS").WithArgs(testDatabase, "orders").
+					WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "COLUMN_NAME", "SEQ_IN_INDEX"}).
+						AddRow("PRIMARY", "id", 1).
+						AddRow("idx_user_created", "user_id", 1).
+						AddRow("idx_user_created", "created_at", 2).
+						AddRow("idx_user_status", "user_id", 1).
+						AddRow("idx_user_status", "status", 2))
+			},
+			want: []string{
+				"已索引的列 (4): id, user_id, status, created_at\n",
+				"未索引的列 (1): note\n",
+				"组合索引前导列 (1): user_id (idx_user_created, idx_user_status)\n",
+			},
+		},
+	})
+}
+
+func TestColumnsDetailed(t *testing.T) {
+	runToolCases(t, "columns_detailed", []toolCase{
+		{
+			name: "missing table",
+			args: map[string]interface{}{"table_name": "ghost"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, "ghost").
+					WillReturnRows(sqlmock.NewRows([]string{"ORDINAL_POSITION", "COLUMN_NAME", "COLUMN_TYPE",
+						"IS_NULLABLE", "COLUMN_KEY", "EXTRA", "COLUMN_DEFAULT"}))
+			},
+			wantErr: "表 'ghost' 不存在",
+		},
+		{
+			name: "one line per column in ordinal order",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("ORDER BY ORDINAL_POSITION")).WithArgs(testDatabase, "users").
+					WillReturnRows(sqlmock.NewRows([]string{"ORDINAL_POSITION", "COLUMN_NAME", "COLUMN_TYPE",
+						"IS_NULLABLE", "COLUMN_KEY", "EXTRA", "COLUMN_DEFAULT"}).
+						AddRow(1, "id", "bigint unsigned", "NO", "PRI", "auto_increment", nil).
+						AddRow(2, "email", "varchar(255)", "NO", "UNI", "", nil).
+						AddRow(3, "status", "enum('active','banned')", "YES", "", "", "active"))
+			},
+			want: []string{"表 'users' 的列详情:"},
+			check: func(t *testing.T, resp MCPResponse) {
+				text := responseText(resp)
+				var lines []string
+				for _, line := range strings.Split(text, "\n") {
+					if fields := strings.Fields(line); len(fields) > 0 && (fields[0] == "1" || fields[0] == "2" || fields[0] == "3") {
+						lines = append(lines, strings.Join(fields, " "))
+					}
+				}
+				want := []string{
+					"1 id bigint unsigned NO PRI auto_increment NULL",
+					"2 email varchar(255) NO UNI NULL",
+					"3 status enum('active','banned') YES active",
+				}
+				if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+					t.Errorf("列详情 = %q，期望 %q", lines, want)
+				}
+			},
+		},
+	})
+}
+
+func TestListAllIndexes(t *testing.T) {
+	runToolCases(t, "list_all_indexes", []toolCase{
+		{
+			name:   "groups columns by table and index",
+			config: func(cfg *MySQLConfig) { cfg.AllowedTables = []string{"orders", "users"} },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.STATISTICS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(schemaIndexColumns).
+						AddRow("orders", "PRIMARY", 0, "id").
+						AddRow("orders", "idx_user_created", 1, "user_id").
+						AddRow("orders", "idx_user_created", 1, "created_at").
+						AddRow("secrets", "PRIMARY", 0, "id").
+						AddRow("users", "uk_email", 0, "email"))
+			},
+			want: []string{
+				"数据库 'testdb' 的索引 (2 张表):\n\n" +
+					"orders:\n  PRIMARY [UNIQUE]: id\n  idx_user_created: user_id, created_at\n" +
+					"users:\n  uk_email [UNIQUE]: email\n",
+			},
+		},
+		{
+			name: "no indexes",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.STATISTICS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows(schemaIndexColumns))
+			},
+			want: []string{"数据库 'testdb' 的索引 (0 张表)", "没有找到索引"},
+		},
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (s *MCPServer) snapshotPath(name string) (string, error) {
+	if err := validateIdentifier(name); err != nil {
+		return "", fmt.Errorf("非法的快照名称: %q", name)
+	}
+	return filepath.Join(s.config.SnapshotDir, name+".json"), nil
+}
+
+func (s *MCPServer) saveSchemaSnapshot(ctx context.Context, id interface{}, name string) MCPResponse {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	dump, err := s.loadSchema(ctx)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+
+	if err := os.MkdirAll(s.config.SnapshotDir, 0o755); err != nil {
+		return s.errorResponse(id, fmt.Sprintf("创建快照目录失败: %v", err))
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return s.errorResponse(id, fmt.Sprintf("保存快照失败: %v", err))
+	}
+
+	return s.textResponse(id, fmt.Sprintf("已保存结构快照 '%s' (%d 张表) 到 %s\n", name, len(dump.Tables), path))
+}
+
+func (s *MCPServer) diffSchemaSnapshot(ctx context.Context, id interface{}, name string) MCPResponse {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("读取快照失败: %v", err))
+	}
+	var saved schemaDump
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return s.errorResponse(id, fmt.Sprintf("解析快照失败: %v", err))
+	}
+
+	current, err := s.loadSchema(ctx)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	changes := diffSchemas(&saved, current)
+	if len(changes) == 0 {
+		return s.textResponse(id, fmt.Sprintf("当前结构与快照 '%s' 一致\n", name))
+	}
+	result := fmt.Sprintf("当前结构与快照 '%s' 的差异 (%d 处):\n\n", name, len(changes))
+	result += strings.Join(changes, "\n") + "\n"
+
+	return s.textResponse(id, result)
+}
+
+// 比较两份结构，返回以 + / - / ~ 开头的差异描述（新增/删除/变更）
+func diffSchemas(old, cur *schemaDump) []string {
+	oldTables := make(map[string]*schemaTable)
+	for _, t := range old.Tables {
+		oldTables[t.Name] = t
+	}
+	curTables := make(map[string]*schemaTable)
+	for _, t := range cur.Tables {
+		curTables[t.Name] = t
+	}
+
+	var changes []string
+	for _, t := range old.Tables {
+		if _, ok := curTables[t.Name]; !ok {
+			changes = append(changes, fmt.Sprintf("- 表 %s", t.Name))
+		}
+	}
+	for _, t := range cur.Tables {
+		oldTable, ok := oldTables[t.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("+ 表 %s", t.Name))
+			continue
+		}
+		changes = append(changes, diffColumns(t.Name, oldTable.Columns, t.Columns)...)
+		changes = append(changes, diffIndexes(t.Name, oldTable.Indexes, t.Indexes)...)
+	}
+	return changes
+}
+
+func diffIndexes(tableName string, oldIndexes, curIndexes []*schemaIndex) []string {
+	oldByName := make(map[string]*schemaIndex)
+	for _, idx := range oldIndexes {
+		oldByName[idx.Name] = idx
+	}
+	curByName := make(map[string]*schemaIndex)
+	for _, idx := range curIndexes {
+		curByName[idx.Name] = idx
+	}
+
+	var changes []string
+	for _, idx := range oldIndexes {
+		if _, ok := curByName[idx.Name]; !ok {
+			changes = append(changes, fmt.Sprintf("- 索引 %s.%s %s", tableName, idx.Name, describeIndex(idx)))
+		}
+	}
+	for _, idx := range curIndexes {
+		oldIndex, ok := oldByName[idx.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("+ 索引 %s.%s %s", tableName, idx.Name, describeIndex(idx)))
+			continue
+		}
+		if desc := describeIndex(oldIndex); desc != describeIndex(idx) {
+			changes = append(changes, fmt.Sprintf("~ 索引 %s.%s: %s -> %s", tableName, idx.Name, desc, describeIndex(idx)))
+		}
+	}
+	return changes
+}
+
+// 索引的简短描述，用于比较和展示
+func describeIndex(idx *schemaIndex) string {
+	desc := "(" + strings.Join(idx.Columns, ", ") + ")"
+	if idx.Unique {
+		desc = "UNIQUE " + desc
+	}
+	return desc
+}
+
+func diffColumns(tableName string, oldColumns, curColumns []*schemaColumn) []string {
+	oldByName := make(map[string]*schemaColumn)
+	for _, c := range oldColumns {
+		oldByName[c.Name] = c
+	}
+	curByName := make(map[string]*schemaColumn)
+	for _, c := range curColumns {
+		curByName[c.Name] = c
+	}
+
+	var changes []string
+	for _, c := range oldColumns {
+		if _, ok := curByName[c.Name]; !ok {
+			changes = append(changes, fmt.Sprintf("- 列 %s.%s (%s)", tableName, c.Name, c.Type))
+		}
+	}
+	for _, c := range curColumns {
+		oldColumn, ok := oldByName[c.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("+ 列 %s.%s (%s)", tableName, c.Name, c.Type))
+			continue
+		}
+		if desc := describeColumn(oldColumn); desc != describeColumn(c) {
+			changes = append(changes, fmt.Sprintf("~ 列 %s.%s: %s -> %s", tableName, c.Name, desc, describeColumn(c)))
+		}
+	}
+	return changes
+}
+
+// 列定义的简短描述，用于比较和展示
+func describeColumn(c *schemaColumn) string {
+	desc := c.Type
+	if !c.Nullable {
+		desc += " NOT NULL"
+	}
+	if c.Default != nil {
+		desc += " DEFAULT " + *c.Default
+	}
+	if c.Key != "" {
+		desc += " " + c.Key
+	}
+	return desc
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 单张 users 表的结构
+func expectUsersSchema(mock sqlmock.Sqlmock) {
+	expectLoadSchema(mock, testDatabase,
+		sqlmock.NewRows(schemaColumnColumns).AddRow("users", "id", "int", "NO", nil, "PRI"),
+		sqlmock.NewRows(schemaIndexColumns).AddRow("users", "PRIMARY", 0, "id"),
+		sqlmock.NewRows(schemaFKColumns))
+}
+
+func TestSaveSchemaSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	runToolCases(t, "save_schema_snapshot", []toolCase{
+		{
+			name:   "writes the snapshot file",
+			config: func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:   map[string]interface{}{"name": "baseline"},
+			expect: expectUsersSchema,
+			want:   []string{"已保存结构快照 'baseline' (1 张表)"},
+			check: func(t *testing.T, resp MCPResponse) {
+				if _, err := os.Stat(filepath.Join(dir, "baseline.json")); err != nil {
+					t.Errorf("快照文件未写入: %v", err)
+				}
+			},
+		},
+		{
+			name:    "rejects path-like names",
+			config:  func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:    map[string]interface{}{"name": "../etc"},
+			wantErr: "非法的快照名称",
+		},
+	})
+}
+
+func TestDiffSchemaSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	saved := `{"database": "testdb", "tables": [{"name": "users",
+		"columns": [{"name": "id", "type": "int", "nullable": false, "key": "PRI"}],
+		"indexes": [{"name": "PRIMARY", "unique": true, "columns": ["id"]}]}]}`
+	if err := os.WriteFile(filepath.Join(dir, "baseline.json"), []byte(saved), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runToolCases(t, "diff_schema_snapshot", []toolCase{
+		{
+			name:   "unchanged schema",
+			config: func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:   map[string]interface{}{"name": "baseline"},
+			expect: expectUsersSchema,
+			want:   []string{"当前结构与快照 'baseline' 一致"},
+		},
+		{
+			name:   "added column and index detected",
+			config: func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:   map[string]interface{}{"name": "baseline"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectLoadSchema(mock, testDatabase,
+					sqlmock.NewRows(schemaColumnColumns).
+						AddRow("users", "id", "int", "NO", nil, "PRI").
+						AddRow("users", "email", "varchar(255)", "YES", nil, "UNI"),
+					sqlmock.NewRows(schemaIndexColumns).
+						AddRow("users", "PRIMARY", 0, "id").
+						AddRow("users", "uq_email", 0, "email"),
+					sqlmock.NewRows(schemaFKColumns))
+			},
+			want: []string{
+				"当前结构与快照 'baseline' 的差异 (2 处):",
+				"+ 列 users.email (varchar(255))\n",
+				"+ 索引 users.uq_email UNIQUE (email)\n",
+			},
+		},
+		{
+			name:   "dropped and added tables",
+			config: func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:   map[string]interface{}{"name": "baseline"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectLoadSchema(mock, testDatabase,
+					sqlmock.NewRows(schemaColumnColumns).AddRow("accounts", "id", "bigint", "NO", nil, "PRI"),
+					sqlmock.NewRows(schemaIndexColumns).AddRow("accounts", "PRIMARY", 0, "id"),
+					sqlmock.NewRows(schemaFKColumns))
+			},
+			want: []string{"- 表 users\n", "+ 表 accounts\n"},
+		},
+		{
+			name:    "missing snapshot",
+			config:  func(cfg *MySQLConfig) { cfg.SnapshotDir = dir },
+			args:    map[string]interface{}{"name": "nope"},
+			wantErr: "读取快照失败",
+		},
+	})
+}
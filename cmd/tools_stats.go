@@ -0,0 +1,636 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 预估行数超过该值的表在精确计数时需要 force=true，避免意外的全表扫描
+const exactCountThreshold = 1000000
+
+// information_schema.TABLES 中的表行数预估
+type tableEstimate struct {
+	Name string
+	Rows int64
+}
+
+// 读取表的预估行数；tableName 为空时返回全部表（按名称排序）
+func (s *MCPServer) tableEstimates(ctx context.Context, database, tableName string) ([]tableEstimate, error) {
+	query := `
+		SELECT TABLE_NAME, COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'`
+	args := []interface{}{database}
+	if tableName != "" {
+		query += " AND TABLE_NAME = ?"
+		args = append(args, tableName)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY TABLE_NAME", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var estimates []tableEstimate
+	for rows.Next() {
+		var e tableEstimate
+		if err := rows.Scan(&e.Name, &e.Rows); err != nil {
+			continue
+		}
+		if s.isTableAllowed(e.Name) {
+			estimates = append(estimates, e)
+		}
+	}
+	return estimates, rows.Err()
+}
+
+// 读取可选的 table_name 参数并校验
+func (s *MCPServer) optionalTableArg(id interface{}, args map[string]interface{}) (string, *MCPResponse) {
+	tableName, _ := args["table_name"].(string)
+	if tableName == "" {
+		return "", nil
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		resp := s.queryErrorResponse(id, err)
+		return "", &resp
+	}
+	if !s.isTableAllowed(tableName) {
+		resp := s.tableNotAllowed(id, tableName)
+		return "", &resp
+	}
+	return tableName, nil
+}
+
+func (s *MCPServer) countRows(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, errResp := s.optionalTableArg(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	mode, _ := args["mode"].(string)
+	force, _ := args["force"].(bool)
+
+	estimates, err := s.tableEstimates(ctx, database, tableName)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	counts := &structuredRowCounts{Mode: "estimated", Tables: []tableRowCount{}}
+	if len(estimates) == 0 {
+		if mode == "exact" {
+			counts.Mode = "exact"
+		}
+		return withStructuredContent(s.textResponse(id, "没有找到表\n"), counts)
+	}
+
+	var sb strings.Builder
+	if mode != "exact" {
+		sb.WriteString("预估行数（来自 information_schema.TABLES，InnoDB 的误差可能较大）:\n\n")
+		for _, e := range estimates {
+			sb.WriteString(fmt.Sprintf("%-30s ~%d\n", e.Name, e.Rows))
+			counts.Tables = append(counts.Tables, tableRowCount{Table: e.Name, Rows: e.Rows})
+		}
+		return withStructuredContent(s.textResponse(id, sb.String()), counts)
+	}
+
+	sb.WriteString("精确行数（COUNT(*)）:\n\n")
+	counts.Mode = "exact"
+	var skipped []string
+	progress := newProgressReporter(ctx)
+	for i, e := range estimates {
+		if e.Rows > exactCountThreshold && !force {
+			skipped = append(skipped, fmt.Sprintf("%s（预估 %d 行）", e.Name, e.Rows))
+			counts.Skipped = append(counts.Skipped, tableRowCount{Table: e.Name, Rows: e.Rows})
+			continue
+		}
+		// 经由 runQuery 执行，大表计数同样受查询超时限制，取消时终止服务端查询并写入查询日志
+		result, err := s.runQuery(ctx, "SELECT COUNT(*) FROM "+qualifiedTable(database, e.Name))
+		if err != nil {
+			return s.queryErrorResponse(id, err)
+		}
+		var count sql.NullInt64
+		if err := scanResultRow(result, 0, &count); err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+		sb.WriteString(fmt.Sprintf("%-30s %d\n", e.Name, count.Int64))
+		counts.Tables = append(counts.Tables, tableRowCount{Table: e.Name, Rows: count.Int64})
+		progress.update(i+1, fmt.Sprintf("已统计 %d/%d 张表", i+1, len(estimates)))
+	}
+	if len(skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("\n以下表预估超过 %d 行，精确计数需要全表扫描，已跳过（传入 force=true 强制统计）:\n%s\n",
+			exactCountThreshold, strings.Join(skipped, "\n")))
+	}
+	return withStructuredContent(s.textResponse(id, sb.String()), counts)
+}
+
+// 以 B/KB/MB/GB 显示字节数
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+func (s *MCPServer) tableStats(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, errResp := s.optionalTableArg(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	query := `
+		SELECT TABLE_NAME, COALESCE(ENGINE, ''), COALESCE(TABLE_ROWS, 0), COALESCE(AVG_ROW_LENGTH, 0),
+			COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0), AUTO_INCREMENT, COALESCE(TABLE_COLLATION, '')
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'`
+	queryArgs := []interface{}{database}
+	if tableName != "" {
+		query += " AND TABLE_NAME = ?"
+		queryArgs = append(queryArgs, tableName)
+	}
+	query += " ORDER BY DATA_LENGTH + INDEX_LENGTH DESC, TABLE_NAME"
+
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-30s %-8s %12s %10s %10s %10s %14s %s\n",
+		"表名", "引擎", "预估行数", "平均行长", "数据大小", "索引大小", "AUTO_INCREMENT", "排序规则"))
+	sb.WriteString(strings.Repeat("-", 120) + "\n")
+	var totalData, totalIndex int64
+	count := 0
+	for rows.Next() {
+		var name, engine, collation string
+		var tableRows, avgRow, dataLength, indexLength int64
+		var autoIncrement sql.NullInt64
+		if err := rows.Scan(&name, &engine, &tableRows, &avgRow, &dataLength, &indexLength, &autoIncrement, &collation); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(name) {
+			continue
+		}
+		next := "-"
+		if autoIncrement.Valid {
+			next = fmt.Sprintf("%d", autoIncrement.Int64)
+		}
+		sb.WriteString(fmt.Sprintf("%-30s %-8s %12d %10s %10s %10s %14s %s\n",
+			name, engine, tableRows, formatBytes(avgRow), formatBytes(dataLength), formatBytes(indexLength), next, collation))
+		totalData += dataLength
+		totalIndex += indexLength
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, "没有找到表\n")
+	}
+	if count > 1 {
+		sb.WriteString(fmt.Sprintf("\n共 %d 张表，数据 %s，索引 %s\n", count, formatBytes(totalData), formatBytes(totalIndex)))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+// 预估行数不超过该值的表用 ORDER BY RAND() 抽样，更大的表按主键区间抽样
+const sampleRandThreshold = 100000
+
+// 没有整数主键的大表按 RAND() 过滤抽样时，候选行数为所需行数的倍数，预估行数偏大时也能取够
+const sampleOversampling = 10
+
+// sample_rows 默认和最多返回的行数
+const (
+	defaultSampleRows = 10
+	maxSampleRows     = 100
+)
+
+// 查找表的单列整数主键，没有时返回空字符串
+func (s *MCPServer) integerPrimaryKey(ctx context.Context, database, tableName string) (string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, DATA_TYPE FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_KEY = 'PRI'`, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var columns, types []string
+	for rows.Next() {
+		var column, dataType string
+		if err := rows.Scan(&column, &dataType); err != nil {
+			return "", err
+		}
+		columns = append(columns, column)
+		types = append(types, strings.ToLower(dataType))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(columns) != 1 {
+		return "", nil
+	}
+	switch types[0] {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return columns[0], nil
+	}
+	return "", nil
+}
+
+// 按主键区间抽样：在 [min, max] 内随机取 n 个起点，每个起点取其后第一行。
+// 主键不连续时不同起点可能落到同一行，因此结果可能少于 n 行。
+func (s *MCPServer) primaryKeySampleQuery(ctx context.Context, table, pk string, n int) (string, error) {
+	var minID, maxID sql.NullInt64
+	query := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", pk, pk, table)
+	if err := s.db.QueryRowContext(ctx, query).Scan(&minID, &maxID); err != nil {
+		return "", &statementError{Statement: query, Err: err}
+	}
+	if !minID.Valid {
+		return "", nil
+	}
+
+	starts := make(map[int64]bool, n)
+	span := maxID.Int64 - minID.Int64 + 1
+	for i := 0; i < n*2 && len(starts) < n; i++ {
+		starts[minID.Int64+rand.Int63n(span)] = true
+	}
+	points := make([]int64, 0, len(starts))
+	for p := range starts {
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = fmt.Sprintf("(SELECT * FROM %s WHERE %s >= %d ORDER BY %s LIMIT 1)", table, pk, p, pk)
+	}
+	return "SELECT DISTINCT * FROM (" + strings.Join(parts, " UNION ALL ") + ") AS sample", nil
+}
+
+func (s *MCPServer) sampleRows(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, errResp := s.optionalTableArg(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	if tableName == "" {
+		return s.errorResponse(id, "table_name is required")
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	n := defaultSampleRows
+	if v, ok := args["size"].(float64); ok {
+		n = int(v)
+	}
+	if n <= 0 || n > maxSampleRows {
+		return s.errorResponse(id, fmt.Sprintf("size 必须在 1 到 %d 之间", maxSampleRows))
+	}
+	format, _ := args["format"].(string)
+	table := qualifiedTable(database, tableName)
+
+	// 未开放 information_schema 时无法得知表大小，只能退回 ORDER BY RAND()
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY RAND() LIMIT %d", table, n)
+	method := "ORDER BY RAND()"
+	if s.config.AllowInformationSchema {
+		estimates, err := s.tableEstimates(ctx, database, tableName)
+		if err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+		}
+		if len(estimates) == 0 {
+			return s.errorResponse(id, fmt.Sprintf("表 '%s' 不存在", tableName))
+		}
+		if estimates[0].Rows > sampleRandThreshold {
+			pk, err := s.integerPrimaryKey(ctx, database, tableName)
+			if err != nil {
+				return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+			}
+			if pk != "" {
+				pkQuery, err := s.primaryKeySampleQuery(ctx, table, quoteIdentifier(pk), n)
+				if err != nil {
+					return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+				}
+				if pkQuery != "" {
+					query, method = pkQuery, fmt.Sprintf("主键 %s 区间抽样", pk)
+				}
+			} else {
+				// 没有整数主键：按预估行数换算抽样比例，先用 RAND() 过滤出约 sampleOversampling 倍的候选行，
+				// 再在候选行中随机取 n 行。直接 LIMIT 会在扫描到 n 行时停止，结果偏向表的前部
+				fraction := float64(n*sampleOversampling) / float64(estimates[0].Rows)
+				query = fmt.Sprintf("SELECT * FROM %s WHERE RAND() < %g ORDER BY RAND() LIMIT %d", table, fraction, n)
+				method = "RAND() 过滤抽样"
+			}
+		}
+	}
+
+	s.logEvent(ctx, "debug", "表 %s 抽样方式: %s", tableName, method)
+	result, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.queryResultResponse(id, result, format)
+}
+
+// profile_column 默认和最多返回的高频值数量
+const (
+	defaultProfileTopK = 10
+	maxProfileTopK     = 50
+)
+
+// 读取必填的 table_name 和 column_name 参数并校验
+func (s *MCPServer) tableColumnArgs(id interface{}, args map[string]interface{}) (string, string, *MCPResponse) {
+	tableName, _ := args["table_name"].(string)
+	columnName, _ := args["column_name"].(string)
+	for _, name := range []string{tableName, columnName} {
+		if err := validateIdentifier(name); err != nil {
+			resp := s.queryErrorResponse(id, err)
+			return "", "", &resp
+		}
+	}
+	if !s.isTableAllowed(tableName) {
+		resp := s.tableNotAllowed(id, tableName)
+		return "", "", &resp
+	}
+	return tableName, columnName, nil
+}
+
+func (s *MCPServer) profileColumn(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, columnName, errResp := s.tableColumnArgs(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	topK := defaultProfileTopK
+	if v, ok := args["top_k"].(float64); ok {
+		topK = int(v)
+	}
+	if topK < 0 || topK > maxProfileTopK {
+		return s.errorResponse(id, fmt.Sprintf("top_k 必须在 0 到 %d 之间", maxProfileTopK))
+	}
+
+	table := qualifiedTable(database, tableName)
+	column := quoteIdentifier(columnName)
+	query := fmt.Sprintf(
+		"SELECT COUNT(*), COUNT(%[1]s), COUNT(DISTINCT %[1]s), MIN(%[1]s), MAX(%[1]s), AVG(CHAR_LENGTH(%[1]s)) FROM %[2]s",
+		column, table)
+	summary, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	var totalCount, nonNullCount, distinctCount sql.NullInt64
+	var minValue, maxValue sql.NullString
+	var avgLength sql.NullFloat64
+	if err := scanResultRow(summary, 0, &totalCount, &nonNullCount, &distinctCount, &minValue, &maxValue, &avgLength); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	total, nonNull, distinct := totalCount.Int64, nonNullCount.Int64, distinctCount.Int64
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("表 '%s' 列 '%s' 的数据概况:\n\n", tableName, columnName))
+	sb.WriteString(fmt.Sprintf("总行数:     %d\n", total))
+	nullRate := 0.0
+	if total > 0 {
+		nullRate = float64(total-nonNull) / float64(total) * 100
+	}
+	sb.WriteString(fmt.Sprintf("NULL:       %d (%.2f%%)\n", total-nonNull, nullRate))
+	sb.WriteString(fmt.Sprintf("不同值:     %d\n", distinct))
+	if minValue.Valid {
+		sb.WriteString(fmt.Sprintf("最小值:     %s\n", minValue.String))
+		sb.WriteString(fmt.Sprintf("最大值:     %s\n", maxValue.String))
+	}
+	if avgLength.Valid {
+		sb.WriteString(fmt.Sprintf("平均长度:   %.1f\n", avgLength.Float64))
+	}
+	if topK == 0 || nonNull == 0 {
+		return s.textResponse(id, sb.String())
+	}
+
+	query = fmt.Sprintf("SELECT %[1]s, COUNT(*) AS cnt FROM %[2]s WHERE %[1]s IS NOT NULL GROUP BY %[1]s ORDER BY cnt DESC LIMIT %[3]d",
+		column, table, topK)
+	top, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	sb.WriteString(fmt.Sprintf("\n出现最多的 %d 个值:\n", topK))
+	for i := range top.Rows {
+		var value sql.NullString
+		var count sql.NullInt64
+		if err := scanResultRow(top, i, &value, &count); err != nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %-40s %d (%.2f%%)\n", value.String, count.Int64, float64(count.Int64)/float64(total)*100))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+// column_histogram 默认和最多的分桶数
+const (
+	defaultHistogramBins = 10
+	maxHistogramBins     = 100
+)
+
+// 按列类型返回用于分桶的数值表达式，日期时间列换算为秒；不支持的类型返回空字符串
+func histogramExpr(column, typeName string) (expr string, isTime bool) {
+	switch strings.ToUpper(typeName) {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT",
+		"DECIMAL", "FLOAT", "DOUBLE", "YEAR":
+		return column, false
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return "TO_SECONDS(" + column + ")", true
+	}
+	return "", false
+}
+
+func (s *MCPServer) columnHistogram(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, columnName, errResp := s.tableColumnArgs(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	bins := defaultHistogramBins
+	if v, ok := args["bins"].(float64); ok {
+		bins = int(v)
+	}
+	if bins < 1 || bins > maxHistogramBins {
+		return s.errorResponse(id, fmt.Sprintf("bins 必须在 1 到 %d 之间", maxHistogramBins))
+	}
+
+	table := qualifiedTable(database, tableName)
+	column := quoteIdentifier(columnName)
+
+	// 通过空结果集取得列类型，不依赖 information_schema
+	probe := fmt.Sprintf("SELECT %s FROM %s LIMIT 0", column, table)
+	probed, err := s.runQuery(ctx, probe)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if len(probed.ColumnTypes) == 0 {
+		return s.errorResponse(id, fmt.Sprintf("无法获取列 '%s' 的类型", columnName))
+	}
+	typeName := probed.ColumnTypes[0]
+	expr, isTime := histogramExpr(column, typeName)
+	if expr == "" {
+		return s.errorResponse(id, fmt.Sprintf("列 '%s' 的类型 %s 不支持分桶，只支持数值和日期时间列", columnName, typeName))
+	}
+
+	var minValue, maxValue sql.NullFloat64
+	var minLabel sql.NullString
+	query := fmt.Sprintf("SELECT MIN(%[1]s), MAX(%[1]s), DATE_FORMAT(MIN(%[2]s), '%%Y-%%m-%%d %%H:%%i:%%s') FROM %[3]s", expr, column, table)
+	if !isTime {
+		query = fmt.Sprintf("SELECT MIN(%[1]s), MAX(%[1]s), NULL FROM %[2]s", expr, table)
+	}
+	bounds, err := s.runQuery(ctx, query)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if err := scanResultRow(bounds, 0, &minValue, &maxValue, &minLabel); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if !minValue.Valid {
+		return s.textResponse(id, fmt.Sprintf("列 '%s' 没有非 NULL 值\n", columnName))
+	}
+
+	lo, hi := minValue.Float64, maxValue.Float64
+	width := (hi - lo) / float64(bins)
+	if width == 0 {
+		bins, width = 1, 1
+	}
+	query = fmt.Sprintf(
+		"SELECT LEAST(FLOOR((%[1]s - ?) / ?), ?) AS bucket, COUNT(*) FROM %[2]s WHERE %[3]s IS NOT NULL GROUP BY bucket ORDER BY bucket",
+		expr, table, column)
+	buckets, err := s.runQuery(ctx, query, lo, width, bins-1)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	counts := make([]int64, bins)
+	var total, peak int64
+	for i := range buckets.Rows {
+		var bucket, bucketCount sql.NullInt64
+		if err := scanResultRow(buckets, i, &bucket, &bucketCount); err != nil {
+			continue
+		}
+		count := bucketCount.Int64
+		if bucket.Int64 >= 0 && bucket.Int64 < int64(bins) {
+			counts[bucket.Int64] = count
+		}
+		total += count
+		if count > peak {
+			peak = count
+		}
+	}
+
+	// 日期时间列的桶边界以最小值为基准换算回时间
+	var base time.Time
+	if isTime {
+		base, _ = time.Parse("2006-01-02 15:04:05", minLabel.String)
+	}
+	label := func(v float64) string {
+		if isTime {
+			return base.Add(time.Duration(v-lo) * time.Second).Format("2006-01-02 15:04:05")
+		}
+		return strconv.FormatFloat(v, 'g', 8, 64)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("表 '%s' 列 '%s'（%s）的分布，共 %d 个非 NULL 值:\n\n", tableName, columnName, typeName, total))
+	for i, count := range counts {
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("█", int(count*40/peak))
+		}
+		closing := ")"
+		if i == bins-1 {
+			closing = "]"
+		}
+		sb.WriteString(fmt.Sprintf("[%s, %s%s %d %s\n",
+			label(lo+float64(i)*width), label(lo+float64(i+1)*width), closing, count, bar))
+	}
+	return s.textResponse(id, sb.String())
+}
+
+func (s *MCPServer) partitionInfo(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, errResp := s.optionalTableArg(id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	query := `
+		SELECT TABLE_NAME, PARTITION_NAME, COALESCE(SUBPARTITION_NAME, ''), PARTITION_METHOD,
+			COALESCE(PARTITION_EXPRESSION, ''), COALESCE(PARTITION_DESCRIPTION, ''),
+			COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0)
+		FROM information_schema.PARTITIONS
+		WHERE TABLE_SCHEMA = ? AND PARTITION_NAME IS NOT NULL`
+	queryArgs := []interface{}{database}
+	if tableName != "" {
+		query += " AND TABLE_NAME = ?"
+		queryArgs = append(queryArgs, tableName)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY TABLE_NAME, PARTITION_ORDINAL_POSITION, SUBPARTITION_ORDINAL_POSITION", queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	current := ""
+	count := 0
+	for rows.Next() {
+		var table, partition, subpartition, method, expression, description string
+		var tableRows, dataLength, indexLength int64
+		if err := rows.Scan(&table, &partition, &subpartition, &method, &expression, &description,
+			&tableRows, &dataLength, &indexLength); err != nil {
+			continue
+		}
+		if !s.isTableAllowed(table) {
+			continue
+		}
+		if table != current {
+			current = table
+			sb.WriteString(fmt.Sprintf("\n表 '%s': PARTITION BY %s (%s)\n", table, method, expression))
+			sb.WriteString(fmt.Sprintf("  %-24s %-30s %12s %10s %10s\n", "分区", "边界", "预估行数", "数据大小", "索引大小"))
+		}
+		name := partition
+		if subpartition != "" {
+			name += "/" + subpartition
+		}
+		sb.WriteString(fmt.Sprintf("  %-24s %-30s %12d %10s %10s\n",
+			name, description, tableRows, formatBytes(dataLength), formatBytes(indexLength)))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	if count == 0 {
+		return s.textResponse(id, "没有找到分区表\n")
+	}
+	return s.textResponse(id, "分区信息:\n"+sb.String())
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// information_schema.TABLES 的预估行数查询
+func expectTableEstimates(mock sqlmock.Sqlmock, rows *sqlmock.Rows, args ...driver.Value) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT TABLE_NAME, COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES")).
+		WithArgs(args...).WillReturnRows(rows)
+}
+
+func TestCountRows(t *testing.T) {
+	estimateColumns := []string{"TABLE_NAME", "TABLE_ROWS"}
+	runToolCases(t, "count_rows", []toolCase{
+		{
+			name: "estimates",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock,
+					sqlmock.NewRows(estimateColumns).AddRow("orders", 1200).AddRow("users", 30), testDatabase)
+			},
+			want:  []string{"预估行数", "orders", "~1200", "~30"},
+			check: wantStructured(`{"mode":"estimated","tables":[{"table":"orders","rows":1200},{"table":"users","rows":30}]}`),
+		},
+		{
+			name: "exact counts skip large tables",
+			args: map[string]interface{}{"mode": "exact"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock,
+					sqlmock.NewRows(estimateColumns).AddRow("events", 5000000).AddRow("users", 30), testDatabase)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(31))
+			},
+			want: []string{"精确行数", "31", "events（预估 5000000 行）"},
+			check: wantStructured(`{"mode":"exact","tables":[{"table":"users","rows":31}],` +
+				`"skipped":[{"table":"events","rows":5000000}]}`),
+		},
+		{
+			name:   "exact count gets the execution-time hint",
+			config: func(cfg *MySQLConfig) { cfg.MaxExecutionTimeMs = 2000 },
+			args:   map[string]interface{}{"table_name": "users", "mode": "exact"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock, sqlmock.NewRows(estimateColumns).AddRow("users", 30), testDatabase, "users")
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(2000) */ COUNT(*) FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(31))
+			},
+			want: []string{"users", "31"},
+		},
+		{
+			name: "exact count error",
+			args: map[string]interface{}{"mode": "exact"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock, sqlmock.NewRows(estimateColumns).AddRow("users", 30), testDatabase)
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users`")).
+					WillReturnError(errors.New("Lock wait timeout exceeded"))
+			},
+			wantErr: "Lock wait timeout exceeded",
+		},
+	})
+}
+
+func TestTableStats(t *testing.T) {
+	runToolCases(t, "table_stats", []toolCase{
+		{
+			name: "one table",
+			args: map[string]interface{}{"table_name": "users"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("AND TABLE_NAME = ? ORDER BY DATA_LENGTH + INDEX_LENGTH DESC")).
+					WithArgs(testDatabase, "users").
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "ENGINE", "TABLE_ROWS", "AVG_ROW_LENGTH",
+						"DATA_LENGTH", "INDEX_LENGTH", "AUTO_INCREMENT", "TABLE_COLLATION"}).
+						AddRow("users", "InnoDB", 30, 512, 16384, 2048, 31, "utf8mb4_0900_ai_ci"))
+			},
+			want: []string{"users", "InnoDB", "16.0 KB", "2.0 KB", "utf8mb4_0900_ai_ci"},
+		},
+	})
+}
+
+func TestSampleRows(t *testing.T) {
+	runToolCases(t, "sample_rows", []toolCase{
+		{
+			name: "small table uses ORDER BY RAND()",
+			args: map[string]interface{}{"table_name": "users", "size": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock,
+					sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_ROWS"}).AddRow("users", 30), testDatabase, "users")
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`users` ORDER BY RAND() LIMIT 2")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(17))
+			},
+			want: []string{"17"},
+		},
+		{
+			name: "large table without an integer key samples from random candidates",
+			args: map[string]interface{}{"table_name": "events", "size": 5},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock,
+					sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_ROWS"}).AddRow("events", 500000), testDatabase, "events")
+				mock.ExpectQuery("FROM information_schema.COLUMNS").WithArgs(testDatabase, "events").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).AddRow("uuid", "char"))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `testdb`.`events` WHERE RAND() < 0.0001 ORDER BY RAND() LIMIT 5") + "$").
+					WillReturnRows(sqlmock.NewRows([]string{"uuid"}).AddRow("a1").AddRow("f9"))
+			},
+			want: []string{"f9"},
+		},
+		{
+			name: "missing table",
+			args: map[string]interface{}{"table_name": "nope"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectTableEstimates(mock,
+					sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_ROWS"}), testDatabase, "nope")
+			},
+			wantErr: "表 'nope' 不存在",
+		},
+	})
+}
+
+func TestProfileColumn(t *testing.T) {
+	runToolCases(t, "profile_column", []toolCase{
+		{
+			name: "summary and top values",
+			args: map[string]interface{}{"table_name": "users", "column_name": "country", "top_k": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*), COUNT(`country`), COUNT(DISTINCT `country`)")).
+					WillReturnRows(sqlmock.NewRows([]string{"total", "non_null", "distinct", "min", "max", "avg"}).
+						AddRow(10, 8, 3, "CN", "US", 2.0))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("GROUP BY `country` ORDER BY cnt DESC LIMIT 2")).
+					WillReturnRows(sqlmock.NewRows([]string{"country", "cnt"}).AddRow("CN", 5).AddRow("US", 2))
+			},
+			want: []string{"总行数:     10", "NULL:       2 (20.00%)", "不同值:     3", "出现最多的 2 个值", "CN", "(50.00%)"},
+		},
+		{
+			name:   "both queries get the execution-time hint",
+			config: func(cfg *MySQLConfig) { cfg.MaxExecutionTimeMs = 2000 },
+			args:   map[string]interface{}{"table_name": "users", "column_name": "country", "top_k": 1},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(2000) */ COUNT(*), COUNT(`country`)")).
+					WillReturnRows(sqlmock.NewRows([]string{"total", "non_null", "distinct", "min", "max", "avg"}).
+						AddRow(4, 4, 1, "CN", "CN", 2.0))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(2000) */ `country`, COUNT(*) AS cnt")).
+					WillReturnRows(sqlmock.NewRows([]string{"country", "cnt"}).AddRow("CN", 4))
+			},
+			want: []string{"总行数:     4", "(100.00%)"},
+		},
+		{
+			name: "all NULL column skips the top values",
+			args: map[string]interface{}{"table_name": "users", "column_name": "country"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*), COUNT(`country`), COUNT(DISTINCT `country`)")).
+					WillReturnRows(sqlmock.NewRows([]string{"total", "non_null", "distinct", "min", "max", "avg"}).
+						AddRow(3, 0, 0, nil, nil, nil))
+			},
+			want: []string{"NULL:       3 (100.00%)"},
+		},
+	})
+}
+
+func TestColumnHistogram(t *testing.T) {
+	runToolCases(t, "column_histogram", []toolCase{
+		{
+			name: "numeric buckets",
+			args: map[string]interface{}{"table_name": "users", "column_name": "age", "bins": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `age` FROM `testdb`.`users` LIMIT 0")).
+					WillReturnRows(sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("age").OfType("INT", int64(0))))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(`age`), MAX(`age`), NULL FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"min", "max", "label"}).AddRow(0, 100, nil))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT LEAST(FLOOR((`age` - ?) / ?), ?) AS bucket")).
+					WithArgs(float64(0), float64(50), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(0, 3).AddRow(1, 1))
+			},
+			want: []string{"共 4 个非 NULL 值", "[0, 50) 3", "[50, 100] 1"},
+		},
+		{
+			name: "all NULL column",
+			args: map[string]interface{}{"table_name": "users", "column_name": "age"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `age` FROM `testdb`.`users` LIMIT 0")).
+					WillReturnRows(sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("age").OfType("INT", int64(0))))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(`age`), MAX(`age`), NULL FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"min", "max", "label"}).AddRow(nil, nil, nil))
+			},
+			want: []string{"列 'age' 没有非 NULL 值"},
+		},
+		{
+			name: "bucket query error",
+			args: map[string]interface{}{"table_name": "users", "column_name": "age", "bins": 2},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `age` FROM `testdb`.`users` LIMIT 0")).
+					WillReturnRows(sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("age").OfType("INT", int64(0))))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(`age`), MAX(`age`), NULL FROM `testdb`.`users`")).
+					WillReturnRows(sqlmock.NewRows([]string{"min", "max", "label"}).AddRow(0, 100, nil))
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT LEAST(FLOOR((`age` - ?) / ?), ?) AS bucket")).
+					WillReturnError(errors.New("Query execution was interrupted"))
+			},
+			wantErr: "Query execution was interrupted",
+		},
+		{
+			name: "unsupported type",
+			args: map[string]interface{}{"table_name": "users", "column_name": "name"},
+			expect: func(mock sqlmock.Sqlmock) {
+				expectConnectionID(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT `name` FROM `testdb`.`users` LIMIT 0")).
+					WillReturnRows(sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("name").OfType("VARCHAR", "")))
+			},
+			wantErr: "列 'name' 的类型 VARCHAR 不支持分桶",
+		},
+	})
+}
+
+func TestPartitionInfo(t *testing.T) {
+	runToolCases(t, "partition_info", []toolCase{
+		{
+			name: "range partitions",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.PARTITIONS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "PARTITION_NAME", "SUBPARTITION_NAME", "PARTITION_METHOD",
+						"PARTITION_EXPRESSION", "PARTITION_DESCRIPTION", "TABLE_ROWS", "DATA_LENGTH", "INDEX_LENGTH"}).
+						AddRow("events", "p2023", "", "RANGE", "year(`created_at`)", "2024", 100, 16384, 0).
+						AddRow("events", "pmax", "", "RANGE", "year(`created_at`)", "MAXVALUE", 5, 16384, 0))
+			},
+			want: []string{"表 'events': PARTITION BY RANGE (year(`created_at`))", "p2023", "MAXVALUE"},
+		},
+		{
+			name: "no partitioned tables",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM information_schema.PARTITIONS").WithArgs(testDatabase).
+					WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}))
+			},
+			want: []string{"没有找到分区表"},
+		},
+	})
+}
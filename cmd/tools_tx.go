@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// execute_transaction 支持的隔离级别
+var isolationLevels = map[string]sql.IsolationLevel{
+	"READ UNCOMMITTED": sql.LevelReadUncommitted,
+	"READ COMMITTED":   sql.LevelReadCommitted,
+	"REPEATABLE READ":  sql.LevelRepeatableRead,
+	"SERIALIZABLE":     sql.LevelSerializable,
+}
+
+// 事务中允许的语句，DDL 会隐式提交因此不允许
+var transactionStatements = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "REPLACE"}
+
+// 事务中单条语句的执行结果
+type statementResult struct {
+	Index        int                      `json:"index"`
+	RowsAffected int64                    `json:"rows_affected,omitempty"`
+	LastInsertID int64                    `json:"last_insert_id,omitempty"`
+	Rows         []map[string]interface{} `json:"rows,omitempty"`
+	DurationMs   float64                  `json:"duration_ms"`
+}
+
+type transactionStatement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// 解析 statements 参数并检查语句类型
+func parseTransactionStatements(raw []interface{}) ([]transactionStatement, error) {
+	if len(raw) == 0 {
+		return nil, &argumentError{Path: "statements", Reason: "不能为空"}
+	}
+	stmts := make([]transactionStatement, len(raw))
+	for i, item := range raw {
+		path := fmt.Sprintf("statements[%d]", i)
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, &argumentError{Path: path, Reason: "必须是对象"}
+		}
+		query, _ := obj["sql"].(string)
+		if !isTransactionStatement(query) {
+			return nil, &argumentError{Path: path + ".sql", Reason: "只允许 " + strings.Join(transactionStatements, "、") + " 语句"}
+		}
+		keyword := strings.ToUpper(strings.Fields(query)[0])
+		if (keyword == "UPDATE" || keyword == "DELETE") && findTopLevelKeyword(query, 0, "WHERE") < 0 {
+			return nil, &argumentError{Path: path + ".sql", Reason: keyword + " 必须带 WHERE 条件"}
+		}
+		rawParams, _ := obj["params"].([]interface{})
+		args, err := bindParams(rawParams)
+		if err != nil {
+			argErr := err.(*argumentError)
+			argErr.Path = path + "." + argErr.Path
+			return nil, argErr
+		}
+		stmts[i] = transactionStatement{SQL: query, Args: args}
+	}
+	return stmts, nil
+}
+
+func isTransactionStatement(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	keyword := strings.ToUpper(fields[0])
+	for _, allowed := range transactionStatements {
+		if keyword == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MCPServer) executeTransaction(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	if s.isReadOnly() || !s.config.AllowWrites {
+		return s.errorResponse(id, "写操作未启用，需要以 --allow-writes 启动或设置 MYSQL_READ_ONLY=false 且 MYSQL_ALLOW_WRITES=true")
+	}
+	raw, _ := args["statements"].([]interface{})
+	stmts, err := parseTransactionStatements(raw)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	opts := &sql.TxOptions{}
+	if level, _ := args["isolation_level"].(string); level != "" {
+		isolation, ok := isolationLevels[level]
+		if !ok {
+			return s.queryErrorResponse(id, &argumentError{Path: "isolation_level", Reason: "不支持的隔离级别"})
+		}
+		opts.Isolation = isolation
+	}
+	for i, stmt := range stmts {
+		if err := s.checkQueryTables(stmt.SQL); err != nil {
+			return s.queryErrorResponse(id, fmt.Errorf("第 %d 条语句: %w", i+1, err))
+		}
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "即将在一个事务中执行以下 %d 条语句：\n", len(stmts))
+	for i, stmt := range stmts {
+		fmt.Fprintf(&summary, "%d. %s\n", i+1, stmt.SQL)
+	}
+	summary.WriteString("确认执行？")
+	confirmed, err := s.confirmWithUser(ctx, args, summary.String())
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	if err := s.ensureConnection(ctx); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	// 在切换到当前数据库的连接上开启事务，未限定的表名使用 use_database 选择的数据库
+	conn, release, err := s.queryConn(ctx)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("获取数据库连接错误: %w", err))
+	}
+	defer release()
+	restoreDatabase, err := s.useSessionDatabase(ctx, conn)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	defer restoreDatabase()
+	tx, err := conn.BeginTx(ctx, opts)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	results := make([]statementResult, 0, len(stmts))
+	for i, stmt := range stmts {
+		result, err := s.execTransactionStatement(ctx, tx, stmt)
+		if err != nil {
+			tx.Rollback()
+			return s.queryErrorResponse(id, fmt.Errorf("第 %d 条语句失败，事务已回滚: %w", i+1, err))
+		}
+		result.Index = i
+		results = append(results, result)
+	}
+	if err := tx.Commit(); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("提交事务错误: %w", err))
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"committed": true,
+		"results":   results,
+	}, "", "  ")
+	if err != nil {
+		return s.errorResponse(id, fmt.Sprintf("编码结果错误: %v", err))
+	}
+	return s.textResponse(id, string(data))
+}
+
+// 在事务中执行一条语句，SELECT 返回结果行，其余返回影响行数
+func (s *MCPServer) execTransactionStatement(ctx context.Context, tx *sql.Tx, stmt transactionStatement) (statementResult, error) {
+	var result statementResult
+	start := time.Now()
+
+	if !strings.EqualFold(strings.Fields(stmt.SQL)[0], "SELECT") {
+		res, err := tx.ExecContext(ctx, stmt.SQL, stmt.Args...)
+		if err == nil {
+			result.RowsAffected, _ = res.RowsAffected()
+			result.LastInsertID, _ = res.LastInsertId()
+		}
+		s.logQuery(ctx, stmt.SQL, time.Since(start), int(result.RowsAffected), err)
+		if err != nil {
+			return result, &statementError{Statement: stmt.SQL, Err: err}
+		}
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		return result, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, stmt.SQL, stmt.Args...)
+	if err != nil {
+		s.logQuery(ctx, stmt.SQL, time.Since(start), 0, err)
+		return result, &statementError{Statement: stmt.SQL, Err: err}
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return result, err
+	}
+	columnTypes, _ := rows.ColumnTypes()
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return result, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			dbType := ""
+			if i < len(columnTypes) {
+				dbType = columnTypes[i].DatabaseTypeName()
+			}
+			row[col] = convertValue(dbType, values[i])
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	err = rows.Err()
+	s.logQuery(ctx, stmt.SQL, time.Since(start), len(result.Rows), err)
+	if err != nil {
+		return result, &statementError{Statement: stmt.SQL, Err: err}
+	}
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExecuteTransaction(t *testing.T) {
+	runToolCases(t, "execute_transaction", []toolCase{
+		{
+			name:   "commits all statements",
+			config: allowWrites,
+			args: map[string]interface{}{"confirm": true, "statements": []interface{}{
+				map[string]interface{}{"sql": "INSERT INTO orders (user_id) VALUES (?)", "params": []interface{}{3}},
+				map[string]interface{}{"sql": "SELECT COUNT(*) AS n FROM orders WHERE user_id = ?", "params": []interface{}{3}},
+			}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO orders (user_id) VALUES (?)")).WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(11, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS n FROM orders WHERE user_id = ?")).WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+				mock.ExpectCommit()
+			},
+			want: []string{`"committed": true`, `"last_insert_id": 11`, `"n": 2`},
+		},
+		{
+			name:   "rolls back on failure",
+			config: allowWrites,
+			args: map[string]interface{}{"confirm": true, "statements": []interface{}{
+				map[string]interface{}{"sql": "UPDATE users SET name = 'x' WHERE id = 1"},
+				map[string]interface{}{"sql": "DELETE FROM users WHERE id = 2"},
+			}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET name = 'x' WHERE id = 1")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = 2")).WillReturnError(errors.New("lock wait timeout"))
+				mock.ExpectRollback()
+			},
+			wantErr: "第 2 条语句失败，事务已回滚",
+		},
+		{
+			name:   "UPDATE requires WHERE",
+			config: allowWrites,
+			args: map[string]interface{}{"confirm": true, "statements": []interface{}{
+				map[string]interface{}{"sql": "UPDATE users SET name = 'x'"},
+			}},
+			wantErr: "statements[0].sql: UPDATE 必须带 WHERE 条件",
+		},
+		{
+			name:   "rejects DDL",
+			config: allowWrites,
+			args: map[string]interface{}{"confirm": true, "statements": []interface{}{
+				map[string]interface{}{"sql": "DROP TABLE users"},
+			}},
+			wantErr: "statements[0].sql: 只允许 SELECT、INSERT、UPDATE、DELETE、REPLACE 语句",
+		},
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (s *MCPServer) truncateTable(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	if s.isReadOnly() || !s.config.AllowTruncate {
+		return s.errorResponse(id, "truncate_table 未启用，需要设置 MYSQL_READ_ONLY=false 且 MYSQL_ALLOW_TRUNCATE=true")
+	}
+
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		return s.errorResponse(id, "table_name is required")
+	}
+	if confirm, _ := args["confirm"].(bool); !confirm {
+		return s.errorResponse(id, "confirm 必须为 true")
+	}
+	if confirmName, _ := args["confirm_table_name"].(string); confirmName != tableName {
+		return s.errorResponse(id, "confirm_table_name 与 table_name 不一致，已取消操作")
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !s.isTableAllowed(tableName) {
+		return s.tableNotAllowed(id, tableName)
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	table := qualifiedTable(database, tableName)
+
+	var rowCount int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&rowCount); err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+	confirmed, err := s.confirmWithUser(ctx, args, fmt.Sprintf("即将清空表 '%s'（当前 %d 行），此操作不可撤销。确认执行？", tableName, rowCount))
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	query := "TRUNCATE TABLE " + table
+	start := time.Now()
+	_, err = s.db.ExecContext(ctx, query)
+	s.logQuery(ctx, query, time.Since(start), 0, err)
+	if err != nil {
+		return s.queryErrorResponse(id, fmt.Errorf("Database error: %w", err))
+	}
+
+	return s.textResponse(id, fmt.Sprintf("表 '%s' 已清空\n", tableName))
+}
+
+// 检查行级写操作是否启用并校验表名
+func (s *MCPServer) rowWriteTable(ctx context.Context, id interface{}, args map[string]interface{}) (string, string, *MCPResponse) {
+	if s.isReadOnly() || !s.config.AllowWrites {
+		resp := s.errorResponse(id, "写操作未启用，需要以 --allow-writes 启动或设置 MYSQL_READ_ONLY=false 且 MYSQL_ALLOW_WRITES=true")
+		return "", "", &resp
+	}
+	return s.targetTable(ctx, id, args)
+}
+
+// 读取并校验 table_name 和 database 参数，同时返回限定后的表名 `db`.`table`
+func (s *MCPServer) targetTable(ctx context.Context, id interface{}, args map[string]interface{}) (string, string, *MCPResponse) {
+	tableName, ok := args["table_name"].(string)
+	if !ok {
+		resp := s.errorResponse(id, "table_name is required")
+		return "", "", &resp
+	}
+	if err := validateIdentifier(tableName); err != nil {
+		resp := s.queryErrorResponse(id, err)
+		return "", "", &resp
+	}
+	if !s.isTableAllowed(tableName) {
+		resp := s.tableNotAllowed(id, tableName)
+		return "", "", &resp
+	}
+	database, err := s.databaseArg(ctx, args)
+	if err != nil {
+		resp := s.queryErrorResponse(id, err)
+		return "", "", &resp
+	}
+	return tableName, qualifiedTable(database, tableName), nil
+}
+
+// 把列名->值转为按列名排序的列和绑定参数
+func columnValues(field string, values map[string]interface{}) ([]string, []interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil, &argumentError{Path: field, Reason: "不能为空"}
+	}
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		if err := validateIdentifier(col); err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	args := make([]interface{}, len(columns))
+	for i, col := range columns {
+		val, ok := bindValue(values[col])
+		if !ok {
+			return nil, nil, &argumentError{Path: field + "." + col, Reason: "只能是字符串、数字、布尔值或 null"}
+		}
+		args[i] = val
+	}
+	return columns, args, nil
+}
+
+// 过滤条件不能为空，避免误改整张表
+func requiredFilterClause(args map[string]interface{}) (string, []interface{}, error) {
+	filters, _ := args["filters"].(map[string]interface{})
+	if len(filters) == 0 {
+		return "", nil, &argumentError{Path: "filters", Reason: "不能为空"}
+	}
+	return buildFilterClause(filters)
+}
+
+// 执行写语句并记录审计日志，返回影响行数
+func (s *MCPServer) execWrite(ctx context.Context, query string, args ...interface{}) (int64, int64, error) {
+	start := time.Now()
+	result, err := s.db.ExecContext(ctx, query, args...)
+	var affected, lastID int64
+	if err == nil {
+		affected, _ = result.RowsAffected()
+		lastID, _ = result.LastInsertId()
+	}
+	s.logQuery(ctx, query, time.Since(start), int(affected), err)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Database error: %w", &statementError{Statement: query, Err: err})
+	}
+	return affected, lastID, nil
+}
+
+// 预览匹配的行数并请求用户确认
+func (s *MCPServer) confirmRowWrite(ctx context.Context, toolArgs map[string]interface{}, action, tableName, table, where string, args []interface{}) (bool, error) {
+	var rowCount int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where)
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&rowCount); err != nil {
+		return false, fmt.Errorf("Database error: %w", err)
+	}
+	return s.confirmWithUser(ctx, toolArgs, fmt.Sprintf("即将%s表 '%s' 中的 %d 行。确认执行？", action, tableName, rowCount))
+}
+
+func (s *MCPServer) insertRow(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	_, table, errResp := s.rowWriteTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	values, _ := args["values"].(map[string]interface{})
+	columns, queryArgs, err := columnValues("values", values)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+
+	affected, lastID, err := s.execWrite(ctx, query, queryArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	text := fmt.Sprintf("已插入 %d 行\n", affected)
+	if lastID != 0 {
+		text += fmt.Sprintf("自增 ID: %d\n", lastID)
+	}
+	return s.textResponse(id, text)
+}
+
+func (s *MCPServer) updateRows(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, table, errResp := s.rowWriteTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	set, _ := args["set"].(map[string]interface{})
+	columns, setArgs, err := columnValues("set", set)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	where, whereArgs, err := requiredFilterClause(args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	confirmed, err := s.confirmRowWrite(ctx, args, "更新", tableName, table, where, whereArgs)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	assignments := make([]string, len(columns))
+	for i, col := range columns {
+		assignments[i] = quoteIdentifier(col) + " = ?"
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), where)
+
+	affected, _, err := s.execWrite(ctx, query, append(setArgs, whereArgs...)...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("已更新 %d 行\n", affected))
+}
+
+func (s *MCPServer) deleteRows(ctx context.Context, id interface{}, args map[string]interface{}) MCPResponse {
+	tableName, table, errResp := s.rowWriteTable(ctx, id, args)
+	if errResp != nil {
+		return *errResp
+	}
+	where, whereArgs, err := requiredFilterClause(args)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+
+	confirmed, err := s.confirmRowWrite(ctx, args, "删除", tableName, table, where, whereArgs)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	if !confirmed {
+		return s.textResponse(id, "用户未确认，已取消操作\n")
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)
+	affected, _, err := s.execWrite(ctx, query, whereArgs...)
+	if err != nil {
+		return s.queryErrorResponse(id, err)
+	}
+	return s.textResponse(id, fmt.Sprintf("已删除 %d 行\n", affected))
+}
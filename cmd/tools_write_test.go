@@ -0,0 +1,132 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 开启行级写操作
+func allowWrites(cfg *MySQLConfig) { cfg.AllowWrites = true }
+
+// 开启 truncate_table，测试配置默认不是只读模式
+func allowTruncate(cfg *MySQLConfig) { cfg.AllowTruncate = true }
+
+func TestTruncateTable(t *testing.T) {
+	runToolCases(t, "truncate_table", []toolCase{
+		{
+			name:   "truncates after double confirmation",
+			config: allowTruncate,
+			args:   map[string]interface{}{"table_name": "logs", "confirm": true, "confirm_table_name": "logs"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`logs`")).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(120))
+				mock.ExpectExec(regexp.QuoteMeta("TRUNCATE TABLE `testdb`.`logs`")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: []string{"表 'logs' 已清空"},
+		},
+		{
+			name:    "confirmation name mismatch",
+			config:  allowTruncate,
+			args:    map[string]interface{}{"table_name": "logs", "confirm": true, "confirm_table_name": "log"},
+			wantErr: "confirm_table_name 与 table_name 不一致，已取消操作",
+		},
+		{
+			name:    "requires confirm",
+			config:  allowTruncate,
+			args:    map[string]interface{}{"table_name": "logs", "confirm": false, "confirm_table_name": "logs"},
+			wantErr: "confirm 必须为 true",
+		},
+		{
+			name:    "disabled by default",
+			args:    map[string]interface{}{"table_name": "logs", "confirm": true, "confirm_table_name": "logs"},
+			wantErr: "truncate_table 未启用",
+		},
+		{
+			name:    "hidden in read-only mode",
+			config:  func(cfg *MySQLConfig) { cfg.ReadOnly = true; cfg.AllowTruncate = true },
+			args:    map[string]interface{}{"table_name": "logs", "confirm": true, "confirm_table_name": "logs"},
+			wantErr: "工具 truncate_table 已禁用",
+		},
+	})
+}
+
+func TestInsertRow(t *testing.T) {
+	runToolCases(t, "insert_row", []toolCase{
+		{
+			name:   "inserts with sorted columns",
+			config: allowWrites,
+			args:   map[string]interface{}{"table_name": "users", "values": map[string]interface{}{"name": "ann", "age": 30}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `testdb`.`users` (`age`, `name`) VALUES (?, ?)")).
+					WithArgs(int64(30), "ann").
+					WillReturnResult(sqlmock.NewResult(7, 1))
+			},
+			want: []string{"已插入 1 行", "自增 ID: 7"},
+		},
+		{
+			name:    "requires allow-writes",
+			args:    map[string]interface{}{"table_name": "users", "values": map[string]interface{}{"name": "ann"}},
+			wantErr: "工具 insert_row 已禁用",
+		},
+		{
+			name:    "rejects empty values",
+			config:  allowWrites,
+			args:    map[string]interface{}{"table_name": "users", "values": map[string]interface{}{}},
+			wantErr: "values: 不能为空",
+		},
+	})
+}
+
+func TestUpdateRows(t *testing.T) {
+	runToolCases(t, "update_rows", []toolCase{
+		{
+			name:   "updates after confirmation",
+			config: allowWrites,
+			args: map[string]interface{}{"table_name": "users", "confirm": true,
+				"set": map[string]interface{}{"name": "bob"}, "filters": map[string]interface{}{"id": 3}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users` WHERE `id` = ?")).WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `testdb`.`users` SET `name` = ? WHERE `id` = ?")).
+					WithArgs("bob", int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: []string{"已更新 1 行"},
+		},
+		{
+			name:    "requires filters",
+			config:  allowWrites,
+			args:    map[string]interface{}{"table_name": "users", "confirm": true, "set": map[string]interface{}{"name": "bob"}},
+			wantErr: "filters: 缺少必填参数",
+		},
+	})
+}
+
+func TestDeleteRows(t *testing.T) {
+	runToolCases(t, "delete_rows", []toolCase{
+		{
+			name:   "deletes after confirmation",
+			config: allowWrites,
+			args:   map[string]interface{}{"table_name": "users", "confirm": true, "filters": map[string]interface{}{"id": 3}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users` WHERE `id` = ?")).WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `testdb`.`users` WHERE `id` = ?")).WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: []string{"已删除 1 行"},
+		},
+		{
+			name:   "requires confirm without elicitation",
+			config: allowWrites,
+			args:   map[string]interface{}{"table_name": "users", "filters": map[string]interface{}{"id": 3}},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `testdb`.`users` WHERE `id` = ?")).WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+			},
+			wantErr: "需要传入 confirm: true",
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// 一次工具调用中执行过的 SQL，作为响应的 _meta 返回，便于客户端审计和追踪
+type callTrace struct {
+	mu      sync.Mutex
+	queries []tracedQuery
+}
+
+type tracedQuery struct {
+	SQL          string  `json:"sql"`
+	DurationMs   float64 `json:"duration_ms"`
+	Rows         int     `json:"rows"`
+	RowsExamined *int64  `json:"rows_examined,omitempty"`
+}
+
+func (t *callTrace) add(q tracedQuery) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries = append(t.queries, q)
+}
+
+// 从 performance_schema 读取连接上一条语句扫描的行数；不可用（未开启或无权限）时返回 nil，
+// 之后不再尝试
+func (s *MCPServer) rowsExamined(ctx context.Context, conn *sql.Conn) *int64 {
+	if s.perfSchemaUnavailable.Load() {
+		return nil
+	}
+	var examined int64
+	err := conn.QueryRowContext(ctx, `
+		SELECT ROWS_EXAMINED
+		FROM performance_schema.events_statements_history
+		WHERE THREAD_ID = PS_CURRENT_THREAD_ID()
+		ORDER BY EVENT_ID DESC
+		LIMIT 1
+	`).Scan(&examined)
+	if err != nil {
+		s.perfSchemaUnavailable.Store(true)
+		return nil
+	}
+	return &examined
+}
+
+// 把请求的 _meta 原样带回，并附加本次调用执行的 SQL
+func withResponseMeta(resp MCPResponse, requestMeta map[string]interface{}, trace *callTrace) MCPResponse {
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return resp
+	}
+
+	meta := make(map[string]interface{}, len(requestMeta)+1)
+	for k, v := range requestMeta {
+		meta[k] = v
+	}
+	trace.mu.Lock()
+	if len(trace.queries) > 0 {
+		meta["mysql-mcp/queries"] = trace.queries
+	}
+	trace.mu.Unlock()
+	if len(meta) > 0 {
+		result["_meta"] = meta
+	}
+	return resp
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 单个 HTTP 请求体的最大字节数
+const maxHTTPBodyBytes = 4 << 20
+
+// 启动 MCP Streamable HTTP 传输，在 /mcp 上接收 JSON-RPC 消息
+func (s *MCPServer) serveHTTP(ctx context.Context, addr string) error {
+	sessions := newHTTPSessions()
+	defer sessions.closeAll()
+	go sessions.expire(ctx, time.Duration(s.config.HTTPSessionIdleMinutes)*time.Minute)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		s.handleHTTP(sessions, w, r)
+	})
+
+	log.Printf("HTTP 传输监听: %s/mcp", addr)
+	return s.listenAndServe(ctx, &http.Server{Addr: addr, Handler: s.requireAuth(s.requireOrigin(mux))}, nil)
+}
+
+func (s *MCPServer) handleHTTP(sessions *httpSessions, w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		// 客户端主动结束会话
+		if !sessions.remove(r.Header.Get(sessionIDHeader)) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		// 暂不支持通过 GET 打开服务端主动推送的 SSE 流
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBodyBytes))
+	if err != nil {
+		http.Error(w, "read body failed", http.StatusBadRequest)
+		return
+	}
+
+	var req MCPRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, MCPResponse{
+			Jsonrpc: "2.0",
+			Error: &MCPError{
+				Code:    -32700,
+				Message: "Parse error",
+			},
+		})
+		return
+	}
+
+	session, ok := sessions.resolve(w, r, req)
+	if !ok {
+		return
+	}
+	// 客户端断开连接时 r.Context() 被取消，正在执行的查询随之终止
+	ctx := session.context(r.Context())
+
+	// 通知和客户端的响应不需要回复，处理后返回 202 且不带响应体
+	if !req.expectsResponse() {
+		s.handleRequest(ctx, req)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		// 以 SSE 流返回时，处理过程中的通知（如进度）先于最终响应发送
+		stream := &sseStream{w: w}
+		ctx = withNotifier(ctx, stream.send)
+		stream.send(s.handleRequest(ctx, req))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.handleRequest(ctx, req))
+}
+
+// 客户端在 Accept 中声明支持 text/event-stream 时以 SSE 流返回
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("编码响应错误: %v", err)
+	}
+}
+
+// 单个 POST 请求对应的 SSE 响应流，首次发送时写出响应头
+type sseStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	started bool
+}
+
+func (st *sseStream) send(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("编码响应错误: %v", err)
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.started {
+		st.w.Header().Set("Content-Type", "text/event-stream")
+		st.w.Header().Set("Cache-Control", "no-cache")
+		st.w.WriteHeader(http.StatusOK)
+		st.started = true
+	}
+	fmt.Fprintf(st.w, "event: message\ndata: %s\n\n", data)
+	if flusher, ok := st.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// 旧版 SSE 传输（HTTP+SSE，协议版本 2024-11-05）：
+// 客户端 GET /sse 建立事件流，服务端先推送 endpoint 事件，
+// 之后客户端把 JSON-RPC 消息 POST 到该地址，响应通过事件流返回。
+type sseTransport struct {
+	server   *MCPServer
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+	// 服务关闭时关闭，事件流在会话的请求处理完后结束
+	closing chan struct{}
+}
+
+type sseSession struct {
+	ctx      context.Context
+	messages chan []byte
+	done     chan struct{}
+	// 正在处理的 POST 请求
+	pending sync.WaitGroup
+}
+
+// 生成随机会话 ID
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *MCPServer) serveSSE(ctx context.Context, addr string) error {
+	t := &sseTransport{server: s, sessions: make(map[string]*sseSession), closing: make(chan struct{})}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", t.handleStream)
+	mux.HandleFunc("/messages", t.handleMessage)
+
+	srv := &http.Server{Addr: addr, Handler: s.requireAuth(s.requireOrigin(mux))}
+	srv.RegisterOnShutdown(func() { close(t.closing) })
+
+	log.Printf("SSE 传输监听: %s/sse", addr)
+	return s.listenAndServe(ctx, srv, nil)
+}
+
+func (t *sseTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sessionID := newSessionID()
+	session := &sseSession{
+		messages: make(chan []byte, 16),
+		done:     make(chan struct{}),
+	}
+	session.ctx = withNotifier(withInflight(r.Context(), newInflightRequests()), session.notify)
+	session.ctx = withLogLevel(session.ctx, newClientLogLevel())
+	session.ctx = withClientRequests(session.ctx, newClientRequests())
+	session.ctx = withSessionLimiter(session.ctx, newSessionLimiter())
+	session.ctx = withSessionDatabase(session.ctx, &sessionDatabase{})
+	session.ctx = t.server.startSubscriptions(session.ctx)
+	defer t.server.watchToolList(session.notify)()
+	t.mu.Lock()
+	t.sessions[sessionID] = session
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.sessions, sessionID)
+		t.mu.Unlock()
+		close(session.done)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: endpoint\ndata: /messages?sessionId=%s\n\n", sessionID)
+	flusher.Flush()
+
+	closing := t.closing
+	var drained chan struct{}
+	for {
+		select {
+		case data := <-session.messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-closing:
+			// 服务关闭：继续推送消息，直到会话中进行中的请求都已返回
+			closing = nil
+			drained = make(chan struct{})
+			go func() {
+				session.pending.Wait()
+				close(drained)
+			}()
+		case <-drained:
+			for {
+				select {
+				case data := <-session.messages:
+					fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				default:
+					flusher.Flush()
+					return
+				}
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// 把通知或服务端请求放入会话的事件流
+func (session *sseSession) notify(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("编码通知错误: %v", err)
+		return
+	}
+	select {
+	case session.messages <- data:
+	case <-session.done:
+	}
+}
+
+func (t *sseTransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	t.mu.Lock()
+	session, ok := t.sessions[r.URL.Query().Get("sessionId")]
+	t.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBodyBytes))
+	if err != nil {
+		http.Error(w, "read body failed", http.StatusBadRequest)
+		return
+	}
+	var req MCPRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+
+	session.pending.Add(1)
+	defer session.pending.Done()
+	w.WriteHeader(http.StatusAccepted)
+
+	if !req.expectsResponse() {
+		t.server.handleRequest(session.ctx, req)
+		return
+	}
+
+	data, err := json.Marshal(t.server.handleRequest(session.ctx, req))
+	if err != nil {
+		log.Printf("编码响应错误: %v", err)
+		return
+	}
+	select {
+	case session.messages <- data:
+	case <-session.done:
+	}
+}
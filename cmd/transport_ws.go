@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket 保活参数：每 wsPingInterval 发送一次 ping，wsPongWait 内未收到任何消息则断开
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsWriteWait    = 10 * time.Second
+)
+
+func (s *MCPServer) serveWebSocket(ctx context.Context, addr string) error {
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	srv := &http.Server{Addr: addr}
+
+	// 关闭时通知各连接停止读取新消息，等待已收到的请求处理完
+	var conns sync.WaitGroup
+	shutdown := make(chan struct{})
+	srv.RegisterOnShutdown(func() { close(shutdown) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WebSocket 升级失败: %v", err)
+			return
+		}
+		conns.Add(1)
+		defer conns.Done()
+		s.serveWebSocketConn(conn, shutdown)
+	})
+	srv.Handler = s.requireAuth(mux)
+
+	log.Printf("WebSocket 传输监听: %s/ws", addr)
+	return s.listenAndServe(ctx, srv, &conns)
+}
+
+// 在一个连接上复用多个 JSON-RPC 请求：每个请求单独处理，响应按完成顺序写回
+func (s *MCPServer) serveWebSocketConn(conn *websocket.Conn, shutdown <-chan struct{}) {
+	defer conn.Close()
+
+	// 连接关闭时取消该连接上所有未完成的请求
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = withInflight(ctx, newInflightRequests())
+	ctx = withLogLevel(ctx, newClientLogLevel())
+	ctx = withClientRequests(ctx, newClientRequests())
+	ctx = withSessionLimiter(ctx, newSessionLimiter())
+	ctx = withSessionDatabase(ctx, &sessionDatabase{})
+
+	var writeMu sync.Mutex
+	write := func(messageType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteMessage(messageType, data)
+	}
+
+	ctx = withNotifier(ctx, func(msg interface{}) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			log.Printf("编码通知错误: %v", err)
+			return
+		}
+		if err := write(websocket.TextMessage, data); err != nil {
+			log.Printf("WebSocket 写入错误: %v", err)
+		}
+	})
+	ctx = s.startSubscriptions(ctx)
+	defer s.watchToolList(notifierFrom(ctx))()
+
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := write(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			case <-shutdown:
+				// 使阻塞中的 ReadMessage 立即返回，不再接收新请求
+				conn.SetReadDeadline(time.Now())
+				shutdown = nil
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket 读取错误: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req MCPRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			log.Printf("解码请求错误: %v", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := s.handleRequest(ctx, req)
+			if !req.expectsResponse() {
+				return
+			}
+			out, err := json.Marshal(response)
+			if err != nil {
+				log.Printf("编码响应错误: %v", err)
+				return
+			}
+			if err := write(websocket.TextMessage, out); err != nil {
+				log.Printf("WebSocket 写入错误: %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 参数校验错误，Path 为出错参数的路径
+type argumentError struct {
+	Path   string
+	Reason string
+}
+
+func (e *argumentError) Error() string {
+	return e.Path + ": " + e.Reason
+}
+
+// 按工具声明的 inputSchema 校验参数，返回的错误包含出错参数的路径，如 "columns[2]"、"statements[0].sql"。
+// 只支持本服务用到的子集：type、enum、items、properties、required；声明了 properties 的对象不接受未声明的键。
+func validateArguments(schema ToolInputSchema, args map[string]interface{}) *argumentError {
+	return validateObject("", schema.Properties, schema.Required, args)
+}
+
+// 校验对象的必填键、未声明的键和每个键的值，path 为对象自身的路径，顶层为空
+func validateObject(path string, properties map[string]interface{}, required []string, obj map[string]interface{}) *argumentError {
+	for _, name := range required {
+		if v, ok := obj[name]; !ok || v == nil {
+			return &argumentError{Path: propertyPath(path, name), Reason: "缺少必填参数"}
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return &argumentError{Path: propertyPath(path, name), Reason: "未声明的参数"}
+		}
+		if obj[name] == nil {
+			continue
+		}
+		if err := validateValue(propertyPath(path, name), prop, obj[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func propertyPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func validateValue(path string, prop map[string]interface{}, v interface{}) *argumentError {
+	typ, _ := prop["type"].(string)
+	switch typ {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return &argumentError{Path: path, Reason: "应为字符串"}
+		}
+		if enum, ok := prop["enum"].([]string); ok {
+			for _, allowed := range enum {
+				if str == allowed {
+					return nil
+				}
+			}
+			return &argumentError{Path: path, Reason: fmt.Sprintf("取值必须是 %v 之一", enum)}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return &argumentError{Path: path, Reason: "应为整数"}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return &argumentError{Path: path, Reason: "应为数字"}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return &argumentError{Path: path, Reason: "应为布尔值"}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return &argumentError{Path: path, Reason: "应为对象"}
+		}
+		// 没有声明 properties 的对象（如 filters）键名任意，只检查类型
+		if properties, ok := prop["properties"].(map[string]interface{}); ok {
+			required, _ := prop["required"].([]string)
+			return validateObject(path, properties, required, obj)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return &argumentError{Path: path, Reason: "应为数组"}
+		}
+		itemSchema, ok := prop["items"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), itemSchema, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 按名称查找工具定义
+func (s *MCPServer) findTool(name string) (Tool, bool) {
+	for _, tool := range s.toolDefinitions() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// 把 execute_query 的 params 转为绑定参数：只接受标量，整数值的数字按 int64 绑定以免丢失精度
+func bindParams(raw []interface{}) ([]interface{}, error) {
+	args := make([]interface{}, len(raw))
+	for i, v := range raw {
+		val, ok := bindValue(v)
+		if !ok {
+			return nil, &argumentError{Path: fmt.Sprintf("params[%d]", i), Reason: "只能是字符串、数字、布尔值或 null"}
+		}
+		args[i] = val
+	}
+	return args, nil
+}
+
+// 把单个 JSON 值转为绑定参数，非标量返回 false
+func bindValue(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case nil, string, bool:
+		return val, true
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return int64(val), true
+		}
+		return val, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// 不符合 inputSchema 的参数在执行前以 -32602 拒绝，data.path 指出出错的参数
+func TestValidateArguments(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   func(cfg *MySQLConfig)
+		tool     string
+		args     map[string]interface{}
+		wantPath string
+	}{
+		{
+			name:     "undeclared top-level key",
+			tool:     "execute_query",
+			args:     map[string]interface{}{"query": "SELECT 1", "qeury": "SELECT 2"},
+			wantPath: "qeury",
+		},
+		{
+			name:     "tool without parameters",
+			tool:     "list_databases",
+			args:     map[string]interface{}{"pattern": "app_%"},
+			wantPath: "pattern",
+		},
+		{
+			name:     "wrong type in array item",
+			tool:     "check_unique",
+			args:     map[string]interface{}{"table_name": "users", "columns": []interface{}{"email", 3}, "values": []interface{}{"a", "b"}},
+			wantPath: "columns[1]",
+		},
+		{
+			name:   "missing key in an array item object",
+			config: allowWrites,
+			tool:   "execute_transaction",
+			args: map[string]interface{}{"statements": []interface{}{
+				map[string]interface{}{"sql": "DELETE FROM users WHERE id = 1"},
+				map[string]interface{}{"params": []interface{}{1}},
+			}},
+			wantPath: "statements[1].sql",
+		},
+		{
+			name:   "undeclared key in an array item object",
+			config: allowWrites,
+			tool:   "execute_transaction",
+			args: map[string]interface{}{"statements": []interface{}{
+				map[string]interface{}{"sql": "DELETE FROM users WHERE id = ?", "args": []interface{}{1}},
+			}},
+			wantPath: "statements[0].args",
+		},
+		{
+			name:   "wrong type nested in an array item object",
+			config: allowWrites,
+			tool:   "execute_transaction",
+			args: map[string]interface{}{"statements": []interface{}{
+				map[string]interface{}{"sql": 42},
+			}},
+			wantPath: "statements[0].sql",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tc.config != nil {
+				tc.config(&cfg)
+			}
+			s, _ := newTestServer(t, cfg)
+			resp := invokeTool(t, s, context.Background(), tc.tool, tc.args)
+			if resp.Error == nil || resp.Error.Code != -32602 {
+				t.Fatalf("期望 -32602 错误，得到 %+v", resp)
+			}
+			data, _ := resp.Error.Data.(map[string]interface{})
+			if data["path"] != tc.wantPath {
+				t.Errorf("path = %v，期望 %s（%s）", data["path"], tc.wantPath, resp.Error.Message)
+			}
+		})
+	}
+}
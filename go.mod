@@ -1,7 +1,12 @@
 module awesomeProject1
 
-go 1.24
+go 1.24.0
 
-require github.com/go-sql-driver/mysql v1.9.3
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/gorilla/websocket v1.5.3
+	golang.org/x/sync v0.19.0
+)
 
 require filippo.io/edwards25519 v1.1.0 // indirect